@@ -0,0 +1,140 @@
+package rpm
+
+import (
+	"sort"
+)
+
+// ChangeSet is the difference between two sets of RPMs, e.g. two nightly
+// releases, at the package level. Packages are identified by name and
+// arch, so that a new version of a package is an upgrade or a downgrade
+// rather than an addition and a removal.
+type ChangeSet struct {
+	Added      []PackageVersion `json:"added,omitempty"`
+	Removed    []PackageVersion `json:"removed,omitempty"`
+	Upgraded   []VersionChange  `json:"upgraded,omitempty"`
+	Downgraded []VersionChange  `json:"downgraded,omitempty"`
+}
+
+// PackageVersion is a package of a ChangeSet, with its [epoch:]version-release
+type PackageVersion struct {
+	Name    string `json:"name"`
+	Arch    string `json:"arch,omitempty"`
+	Version string `json:"version,omitempty"`
+	Path    string `json:"path"`
+}
+
+// VersionChange is a package found with different versions in two sets
+type VersionChange struct {
+	Name       string `json:"name"`
+	Arch       string `json:"arch,omitempty"`
+	OldVersion string `json:"old_version"`
+	NewVersion string `json:"new_version"`
+	OldPath    string `json:"old_path"`
+	NewPath    string `json:"new_path"`
+}
+
+// Empty indicates if the two sets hold the same package versions
+func (c *ChangeSet) Empty() bool {
+	return len(c.Added)+len(c.Removed)+len(c.Upgraded)+len(c.Downgraded) == 0
+}
+
+// Diff compares the packages of two sets of RPMs. Where a set holds
+// several versions of a package, its newest is compared. RPMs whose header
+// cannot be read are identified by filename and have no version. Each list
+// of the ChangeSet is sorted by package name, then arch.
+func Diff(oldRPMs, newRPMs *RPMs) *ChangeSet {
+	oldPkgs, newPkgs := newestPackages(*oldRPMs), newestPackages(*newRPMs)
+
+	c := &ChangeSet{}
+	for key, n := range newPkgs {
+		o, keyExists := oldPkgs[key]
+		if !keyExists {
+			c.Added = append(c.Added, n)
+			continue
+		}
+
+		change := VersionChange{
+			Name:       n.Name,
+			Arch:       n.Arch,
+			OldVersion: o.Version,
+			NewVersion: n.Version,
+			OldPath:    o.Path,
+			NewPath:    n.Path,
+		}
+		switch cmp := CompareEVR(o.Version, n.Version); {
+		case cmp < 0:
+			c.Upgraded = append(c.Upgraded, change)
+		case cmp > 0:
+			c.Downgraded = append(c.Downgraded, change)
+		}
+	}
+
+	for key, o := range oldPkgs {
+		if _, keyExists := newPkgs[key]; !keyExists {
+			c.Removed = append(c.Removed, o)
+		}
+	}
+
+	sortPackageVersions(c.Added)
+	sortPackageVersions(c.Removed)
+	sortVersionChanges(c.Upgraded)
+	sortVersionChanges(c.Downgraded)
+	return c
+}
+
+// DiffReleases compares the packages of the RPM files of two directories,
+// e.g. the nightly releases of two days, see Diff. Unlike DiffDirs,
+// which compares files by content, it reports version changes.
+func DiffReleases(oldDir, newDir string) (*ChangeSet, error) {
+	oldRPMs, err := listRPMs(osFileSystem{}, oldDir)
+	if err != nil {
+		return nil, err
+	}
+
+	newRPMs, err := listRPMs(osFileSystem{}, newDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return Diff(&oldRPMs, &newRPMs), nil
+}
+
+// newestPackages keys the RPMs by package name and arch,
+// keeping the newest version of each
+func newestPackages(rpms RPMs) map[string]PackageVersion {
+	pkgs := map[string]PackageVersion{}
+	for _, rr := range rpms {
+		pv := PackageVersion{Name: rr.Name(), Path: rr.Path}
+		if m, err := rr.Metadata(); err == nil {
+			pv.Name = m.Name
+			pv.Arch = m.Arch
+			pv.Version = formatEVR(m.Epoch, m.Version, m.Release)
+		}
+
+		key := pv.Name + "." + pv.Arch
+		if prev, keyExists := pkgs[key]; keyExists && CompareEVR(prev.Version, pv.Version) >= 0 {
+			continue
+		}
+		pkgs[key] = pv
+	}
+
+	return pkgs
+}
+
+func sortPackageVersions(pkgs []PackageVersion) {
+	sort.Slice(pkgs, func(i, j int) bool {
+		if pkgs[i].Name != pkgs[j].Name {
+			return pkgs[i].Name < pkgs[j].Name
+		}
+		return pkgs[i].Arch < pkgs[j].Arch
+	})
+}
+
+func sortVersionChanges(changes []VersionChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Name != changes[j].Name {
+			return changes[i].Name < changes[j].Name
+		}
+		return changes[i].Arch < changes[j].Arch
+	})
+}
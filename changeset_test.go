@@ -0,0 +1,72 @@
+package rpm
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDiffReleases(t *testing.T) {
+	oldDir, newDir := t.TempDir(), t.TempDir()
+	for filename, spec := range map[string]fixtureRPM{
+		"Gaudi-1.0.rpm":      {Name: "Gaudi", Version: "1.0", Release: "1"},
+		"ROOT-6.30.rpm":      {Name: "ROOT", Version: "6.30", Release: "1"},
+		"tbb-2021.rpm":       {Name: "tbb", Version: "2021", Release: "1"},
+		"same-1.rpm":         {Name: "same", Version: "1", Release: "1"},
+		"gone-1.rpm":         {Name: "gone", Version: "1", Release: "1"},
+		"ROOT-6.aarch64.rpm": {Name: "ROOT", Version: "6.30", Release: "1", Arch: "aarch64"},
+	} {
+		writeRPM(t, oldDir, filename, spec)
+	}
+	for filename, spec := range map[string]fixtureRPM{
+		"Gaudi-1.1.rpm": {Name: "Gaudi", Version: "1.1", Release: "1"},
+		"ROOT-6.28.rpm": {Name: "ROOT", Version: "6.28", Release: "1"},
+		"tbb-2021.rpm":  {Name: "tbb", Version: "2021", Release: "2"},
+		"same-1.rpm":    {Name: "same", Version: "1", Release: "1"},
+		"fresh-1.rpm":   {Name: "fresh", Version: "1", Release: "1"},
+	} {
+		writeRPM(t, newDir, filename, spec)
+	}
+
+	c, err := DiffReleases(oldDir, newDir)
+	if err != nil {
+		t.Fatalf("DiffReleases failed (%v)", err)
+	}
+
+	if len(c.Added) != 1 || c.Added[0].Name != "fresh" || c.Added[0].Version != "1-1" {
+		t.Errorf("DiffReleases should report fresh as added, got %v", c.Added)
+	}
+
+	if len(c.Removed) != 2 || c.Removed[0].Name != "ROOT" || c.Removed[0].Arch != "aarch64" || c.Removed[1].Name != "gone" {
+		t.Errorf("DiffReleases should report ROOT.aarch64 and gone as removed, got %v", c.Removed)
+	}
+
+	if len(c.Upgraded) != 2 || c.Upgraded[0].Name != "Gaudi" || c.Upgraded[1].NewVersion != "2021-2" {
+		t.Errorf("DiffReleases should report Gaudi and tbb as upgraded, got %v", c.Upgraded)
+	}
+
+	if len(c.Downgraded) != 1 || c.Downgraded[0].OldVersion != "6.30-1" || c.Downgraded[0].NewVersion != "6.28-1" {
+		t.Errorf("DiffReleases should report ROOT as downgraded, got %v", c.Downgraded)
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil || !strings.Contains(string(data), `"downgraded":[{"name":"ROOT","arch":"x86_64","old_version":"6.30-1"`) {
+		t.Errorf("ChangeSet should marshal to JSON, got %s (%v)", data, err)
+	}
+
+	if same := Diff(&RPMs{}, &RPMs{}); !same.Empty() {
+		t.Errorf("Diff of empty sets should be empty, got %v", same)
+	}
+}
+
+func TestDiffNewestVersion(t *testing.T) {
+	dir := t.TempDir()
+	old, _ := New(writeRPM(t, dir, "Gaudi-1.0.rpm", fixtureRPM{Name: "Gaudi", Version: "1.0", Release: "1"}))
+	mid, _ := New(writeRPM(t, dir, "Gaudi-1.1.rpm", fixtureRPM{Name: "Gaudi", Version: "1.1", Release: "1"}))
+	top, _ := New(writeRPM(t, dir, "Gaudi-1.2.rpm", fixtureRPM{Name: "Gaudi", Version: "1.2", Release: "1"}))
+
+	c := Diff(&RPMs{old}, &RPMs{top, mid})
+	if len(c.Upgraded) != 1 || c.Upgraded[0].NewPath != top.Path {
+		t.Errorf("Diff should compare the newest version of each set, got %v", c.Upgraded)
+	}
+}
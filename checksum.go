@@ -0,0 +1,115 @@
+package rpm
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"runtime"
+)
+
+// ErrChecksumMismatch is returned when an RPM file does not
+// match the checksum recorded for it in the repodata
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// WithChecksumCheck makes a RemoteFinder verify each RPM it uses against
+// the checksum of the repodata, sha256 or sha1 on legacy repos, and fail
+// on a mismatch. It has no effect on a Finder of a local directory.
+func WithChecksumCheck() FinderOption {
+	return func(f *Finder) {
+		f.checksumCheck = true
+	}
+}
+
+// newHash returns a hash for the given algorithm name
+func newHash(algo string) (hash.Hash, error) {
+	switch algo {
+	case "md5":
+		return md5.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported checksum algorithm %q", algo)
+}
+
+// Checksum returns the hex encoded checksum of the RPM file, computed
+// with the given algorithm (one of md5, sha1, sha256 or sha512)
+func (r *RPM) Checksum(algo string) (string, error) {
+	h, err := newHash(algo)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := r.open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to checksum %s (%w)", r.Path, err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Checksums returns the checksum of each RPM, keyed by path
+func (r *RPMs) Checksums(algo string) (map[string]string, error) {
+	return r.ChecksumsContext(context.Background(), algo, 1)
+}
+
+// ChecksumsContext returns the checksum of each RPM, keyed by path.
+// Up to concurrency files are hashed in parallel; a concurrency below 1
+// means GOMAXPROCS. Cancelling ctx stops the work before the next file is
+// started and returns the context error, without checksums unless every
+// file was hashed already. Failures for individual files are aggregated
+// into the returned error, alongside the checksums of those files that
+// succeeded.
+func (r *RPMs) ChecksumsContext(ctx context.Context, algo string, concurrency int) (map[string]string, error) {
+	if _, err := newHash(algo); err != nil {
+		return nil, err
+	}
+
+	if concurrency < 1 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+
+	rpms := *r
+	sums := make([]string, len(rpms))
+	errs := make([]error, len(rpms))
+	hashed := make([]bool, len(rpms))
+	err := forEach(ctx, len(rpms), concurrency, func(i int) error {
+		// Failures are aggregated rather than stopping the other files
+		sums[i], errs[i] = rpms[i].Checksum(algo)
+		hashed[i] = true
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("checksum computation interrupted (%w)", err)
+		for _, done := range hashed {
+			if !done {
+				return nil, err
+			}
+		}
+	}
+
+	byPath := map[string]string{}
+	for i, rr := range rpms {
+		if errs[i] == nil {
+			byPath[rr.Path] = sums[i]
+		}
+	}
+
+	return byPath, errors.Join(append(errs, err)...)
+}
@@ -0,0 +1,83 @@
+package rpm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func createChecksumRPMs(t *testing.T, n int) *RPMs {
+	dir := t.TempDir()
+	var rpms RPMs
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("pkg%d.rpm", i))
+		if err := os.WriteFile(path, []byte("abc"), 0644); err != nil {
+			t.Fatalf("failed to write %s (%v)", path, err)
+		}
+		rpms = append(rpms, &RPM{Path: path, Size: 3})
+	}
+
+	return &rpms
+}
+
+func TestRPMChecksum(t *testing.T) {
+	r := (*createChecksumRPMs(t, 1))[0]
+	expect := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+
+	got, err := r.Checksum("sha256")
+	if err != nil {
+		t.Fatalf("Checksum failed (%v)", err)
+	}
+
+	if got != expect {
+		t.Errorf("Checksum should return %s, got %s", expect, got)
+	}
+
+	if _, err := r.Checksum("crc32"); err == nil {
+		t.Errorf("Checksum with an unknown algorithm should fail, got nil")
+	}
+}
+
+func TestRPMsChecksumsContext(t *testing.T) {
+	rpms := createChecksumRPMs(t, 20)
+	*rpms = append(*rpms, &RPM{Path: "/blip/blop.rpm"})
+
+	sums, err := rpms.ChecksumsContext(context.Background(), "md5", 4)
+	if err == nil {
+		t.Errorf("ChecksumsContext should report the missing file, got nil")
+	}
+
+	if len(sums) != 20 {
+		t.Errorf("ChecksumsContext should return 20 checksums, got %d", len(sums))
+	}
+}
+
+func TestRPMsChecksumsContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	sums, err := createChecksumRPMs(t, 5).ChecksumsContext(ctx, "sha1", 2)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ChecksumsContext should return context.Canceled, got %v", err)
+	}
+
+	if sums != nil {
+		t.Errorf("ChecksumsContext should not return checksums when cancelled, got %v", sums)
+	}
+}
+
+func TestRPMsChecksumsContextCancelledAfterHashing(t *testing.T) {
+	// Each of the 5 files is started after an Err call, the 6th is made
+	// once all of them are hashed
+	sums, err := createChecksumRPMs(t, 5).ChecksumsContext(newCountdownCtx(5), "sha1", 2)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ChecksumsContext should return context.Canceled, got %v", err)
+	}
+
+	if len(sums) != 5 {
+		t.Errorf("ChecksumsContext should return the 5 checksums computed before the cancellation, got %v", sums)
+	}
+}
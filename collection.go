@@ -0,0 +1,110 @@
+package rpm
+
+import (
+	"regexp"
+	"sort"
+)
+
+// Filter returns the RPMs for which keep returns true, in order
+func (r RPMs) Filter(keep func(*RPM) bool) RPMs {
+	var kept RPMs
+	for _, rr := range r {
+		if keep(rr) {
+			kept = append(kept, rr)
+		}
+	}
+
+	return kept
+}
+
+// MatchName returns the RPMs whose Name, the filename, matches re
+func (r RPMs) MatchName(re *regexp.Regexp) RPMs {
+	return r.Filter(func(rr *RPM) bool {
+		return re.MatchString(rr.Name())
+	})
+}
+
+// ByArch returns the RPMs built for the given architecture, e.g. x86_64
+// or noarch. RPMs whose header cannot be read are left out.
+func (r RPMs) ByArch(arch string) RPMs {
+	return r.Filter(func(rr *RPM) bool {
+		return rr.Arch() == arch
+	})
+}
+
+// SortByName sorts the RPMs in place by Name, the filename,
+// and returns them for chaining
+func (r RPMs) SortByName() RPMs {
+	sort.SliceStable(r, func(i, j int) bool {
+		return r[i].Name() < r[j].Name()
+	})
+
+	return r
+}
+
+// SortByVersion sorts the RPMs in place by [epoch:]version-release of
+// their header, oldest first, and returns them for chaining. RPMs whose
+// header cannot be read, and so have no version, sort first.
+func (r RPMs) SortByVersion() RPMs {
+	versions := make(map[*RPM]evr, len(r))
+	for _, rr := range r {
+		m := rr.metadata()
+		versions[rr] = evr{epoch: m.Epoch, version: m.Version, release: m.Release}
+	}
+
+	sort.SliceStable(r, func(i, j int) bool {
+		return compareEVR(versions[r[i]], versions[r[j]]) < 0
+	})
+
+	return r
+}
+
+// Union returns the RPMs followed by those of other not already
+// included, comparing RPMs by NEVRA
+func (r RPMs) Union(other RPMs) RPMs {
+	all := append(RPMs(nil), r...)
+	seen := r.identities()
+	for _, rr := range other {
+		if id := identity(rr); !seen[id] {
+			seen[id] = true
+			all = append(all, rr)
+		}
+	}
+
+	return all
+}
+
+// Intersect returns the RPMs that are also in other, comparing RPMs by NEVRA
+func (r RPMs) Intersect(other RPMs) RPMs {
+	ids := other.identities()
+	return r.Filter(func(rr *RPM) bool {
+		return ids[identity(rr)]
+	})
+}
+
+// Diff returns the RPMs that are not in other, comparing RPMs by NEVRA
+func (r RPMs) Diff(other RPMs) RPMs {
+	ids := other.identities()
+	return r.Filter(func(rr *RPM) bool {
+		return !ids[identity(rr)]
+	})
+}
+
+func (r RPMs) identities() map[string]bool {
+	ids := make(map[string]bool, len(r))
+	for _, rr := range r {
+		ids[identity(rr)] = true
+	}
+
+	return ids
+}
+
+// identity keys an RPM by its NEVRA, or by its path if
+// the header cannot be read and so the NEVRA is unknown
+func identity(rr *RPM) string {
+	if id := rr.NEVRA(); id != "" {
+		return id
+	}
+
+	return "path:" + rr.Path
+}
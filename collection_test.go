@@ -0,0 +1,91 @@
+package rpm
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func createCollection(t *testing.T, dir string, specs map[string]fixtureRPM) map[string]*RPM {
+	rpms := map[string]*RPM{}
+	for filename, spec := range specs {
+		r, err := New(writeRPM(t, dir, filename, spec))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rpms[filename] = r
+	}
+
+	return rpms
+}
+
+func TestRPMsFilters(t *testing.T) {
+	c := createCollection(t, t.TempDir(), map[string]fixtureRPM{
+		"Gaudi-1.0.rpm":      {Name: "Gaudi", Version: "1.0", Release: "1"},
+		"GaudiExt-1.0.rpm":   {Name: "GaudiExt", Version: "1.0", Release: "1", Arch: "noarch"},
+		"ROOT-6.aarch64.rpm": {Name: "ROOT", Version: "6", Release: "1", Arch: "aarch64"},
+	})
+	rpms := RPMs{c["ROOT-6.aarch64.rpm"], c["GaudiExt-1.0.rpm"], c["Gaudi-1.0.rpm"]}
+
+	got := rpms.MatchName(regexp.MustCompile(`^Gaudi`)).ByArch("x86_64")
+	if len(got) != 1 || got[0] != c["Gaudi-1.0.rpm"] {
+		t.Errorf("MatchName then ByArch should only keep Gaudi-1.0.rpm, got %v", got.Names())
+	}
+
+	if got := rpms.Filter(func(*RPM) bool { return false }); len(got) != 0 {
+		t.Errorf("Filter should drop all the RPMs, got %v", got.Names())
+	}
+
+	if got := strings.Join(rpms.SortByName().Names(), ","); got != "Gaudi-1.0.rpm,GaudiExt-1.0.rpm,ROOT-6.aarch64.rpm" {
+		t.Errorf("SortByName should sort by filename, got %s", got)
+	}
+}
+
+func TestRPMsSortByVersion(t *testing.T) {
+	c := createCollection(t, t.TempDir(), map[string]fixtureRPM{
+		"a.rpm": {Name: "Gaudi", Version: "1.10", Release: "1"},
+		"b.rpm": {Name: "Gaudi", Version: "1.9", Release: "2"},
+		"c.rpm": {Name: "Gaudi", Version: "1.0", Release: "1", Epoch: 1},
+		"d.rpm": {Name: "Gaudi", Version: "1.9", Release: "10"},
+	})
+	rpms := RPMs{c["c.rpm"], c["a.rpm"], c["d.rpm"], c["b.rpm"]}
+
+	if got := strings.Join(rpms.SortByVersion().Names(), ","); got != "b.rpm,d.rpm,a.rpm,c.rpm" {
+		t.Errorf("SortByVersion should sort oldest first, got %s", got)
+	}
+}
+
+func TestRPMsSetOperations(t *testing.T) {
+	c := createCollection(t, t.TempDir(), map[string]fixtureRPM{
+		"Gaudi-1.0.rpm": {Name: "Gaudi", Version: "1.0", Release: "1"},
+		"Gaudi-1.1.rpm": {Name: "Gaudi", Version: "1.1", Release: "1"},
+		"ROOT-6.rpm":    {Name: "ROOT", Version: "6", Release: "1"},
+	})
+
+	// The same package as Gaudi-1.0.rpm, copied to another directory
+	other := createCollection(t, t.TempDir(), map[string]fixtureRPM{
+		"Gaudi-1.0.rpm": {Name: "Gaudi", Version: "1.0", Release: "1"},
+	})
+
+	a := RPMs{c["Gaudi-1.0.rpm"], c["ROOT-6.rpm"]}
+	b := RPMs{other["Gaudi-1.0.rpm"], c["Gaudi-1.1.rpm"]}
+
+	if got := a.Union(b); len(got) != 3 || got[2] != c["Gaudi-1.1.rpm"] {
+		t.Errorf("Union should add Gaudi-1.1 only, got %v", got.Paths())
+	}
+
+	if got := a.Intersect(b); len(got) != 1 || got[0] != c["Gaudi-1.0.rpm"] {
+		t.Errorf("Intersect should keep Gaudi-1.0 only, got %v", got.Paths())
+	}
+
+	if got := a.Diff(b); len(got) != 1 || got[0] != c["ROOT-6.rpm"] {
+		t.Errorf("Diff should keep ROOT only, got %v", got.Paths())
+	}
+
+	// Unreadable RPMs are compared by path
+	unreadable := RPMs{{Path: filepath.Join(t.TempDir(), "missing.rpm")}}
+	if got := unreadable.Union(unreadable).Union(a); len(got) != 3 {
+		t.Errorf("Union should compare unreadable RPMs by path, got %v", got.Paths())
+	}
+}
@@ -0,0 +1,50 @@
+package rpm
+
+import (
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// FilenameCollisions walks the Finder's directory tree and groups the
+// RPM files sharing a basename whose content differs, i.e. different
+// packages that filename based matching cannot tell apart. The result
+// maps each such basename to the paths of all the files bearing it.
+func (f *Finder) FilenameCollisions() (map[string][]string, error) {
+	byName := map[string][]string{}
+	err := f.files().WalkDir(f.basedir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), ".rpm") {
+			byName[d.Name()] = append(byName[d.Name()], path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	collisions := map[string][]string{}
+	for name, paths := range byName {
+		if len(paths) < 2 {
+			continue
+		}
+
+		sums := map[string]struct{}{}
+		for _, path := range paths {
+			sum, err := (&RPM{Path: path, fsys: f.fsys}).Checksum("sha256")
+			if err != nil {
+				return nil, err
+			}
+			sums[sum] = struct{}{}
+		}
+
+		if len(sums) > 1 {
+			sort.Strings(paths)
+			collisions[name] = paths
+		}
+	}
+
+	return collisions, nil
+}
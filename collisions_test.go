@@ -0,0 +1,37 @@
+package rpm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFinderFilenameCollisions(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"a", "b", "c"} {
+		os.Mkdir(filepath.Join(dir, sub), 0755)
+	}
+
+	// Identical copies are fine, differing content is not
+	write := func(path, content string) {
+		os.WriteFile(filepath.Join(dir, path), []byte(content), 0644)
+	}
+	write("a/same.rpm", "same")
+	write("b/same.rpm", "same")
+	write("a/clash.rpm", "one")
+	write("c/clash.rpm", "two")
+	write("b/unique.rpm", "unique")
+
+	got, err := NewFinder(dir).FilenameCollisions()
+	if err != nil {
+		t.Fatalf("FilenameCollisions failed (%v)", err)
+	}
+
+	if len(got) != 1 || len(got["clash.rpm"]) != 2 {
+		t.Fatalf("FilenameCollisions should only report clash.rpm, got %v", got)
+	}
+
+	if got["clash.rpm"][0] != filepath.Join(dir, "a/clash.rpm") {
+		t.Errorf("FilenameCollisions should sort paths, got %v", got["clash.rpm"])
+	}
+}
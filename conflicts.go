@@ -0,0 +1,222 @@
+package rpm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cavaliergopher/rpm"
+)
+
+// Conflict is a package that appears with several versions in a collection
+// of RPMs. Paths and Versions are parallel, one entry per RPM file.
+type Conflict struct {
+	Name     string
+	Paths    []string
+	Versions []string
+}
+
+func (c Conflict) String() string {
+	var tokens []string
+	for i, path := range c.Paths {
+		tokens = append(tokens, fmt.Sprintf("%s (%s)", c.Versions[i], path))
+	}
+
+	return fmt.Sprintf("%s: %s", c.Name, strings.Join(tokens, ", "))
+}
+
+// ConflictError reports an RPM whose Conflicts or Obsoletes
+// match another RPM found alongside it
+type ConflictError struct {
+	// Path is the RPM declaring the Conflicts or Obsoletes entry
+	Path string
+
+	// Other is the RPM matched by the entry
+	Other string
+
+	// Dependency is the entry itself, as printed by rpm -q --conflicts
+	Dependency string
+
+	// Obsoletes is set if the entry is an Obsoletes rather than a Conflicts
+	Obsoletes bool
+}
+
+func (e *ConflictError) Error() string {
+	verb := "conflicts with"
+	if e.Obsoletes {
+		verb = "obsoletes"
+	}
+
+	return fmt.Sprintf("%s %s %s (%s)", e.Path, verb, e.Other, e.Dependency)
+}
+
+// WithConflictCheck makes Find fail when the found RPMs hold several
+// versions of the same package, see RPMs.Conflicts, or when some of them
+// conflict with or obsolete others, see RPMs.DeclaredConflicts
+func WithConflictCheck() FinderOption {
+	return func(f *Finder) {
+		f.conflictCheck = true
+	}
+}
+
+// Conflicts groups the RPMs by the package name of their header and
+// reports the packages found with more than one [epoch:]version-release.
+// RPMs of the same package and version that differ by arch only do not
+// conflict.
+func (r *RPMs) Conflicts() ([]Conflict, error) {
+	var (
+		names  []string
+		groups = map[string]*Conflict{}
+		evrs   = map[string]map[string]struct{}{}
+	)
+	for _, pkg := range *r {
+		p, err := pkg.header()
+		if err != nil {
+			return nil, err
+		}
+
+		c, keyExists := groups[p.Name()]
+		if !keyExists {
+			c = &Conflict{Name: p.Name()}
+			groups[p.Name()] = c
+			evrs[p.Name()] = map[string]struct{}{}
+			names = append(names, p.Name())
+		}
+
+		version := formatEVR(p.Epoch(), p.Version(), p.Release())
+		c.Paths = append(c.Paths, pkg.Path)
+		c.Versions = append(c.Versions, version)
+		evrs[p.Name()][version] = struct{}{}
+	}
+
+	var conflicts []Conflict
+	for _, name := range names {
+		if len(evrs[name]) > 1 {
+			conflicts = append(conflicts, *groups[name])
+		}
+	}
+
+	return conflicts, nil
+}
+
+// DeclaredConflicts checks the Conflicts and Obsoletes of each RPM against
+// the other RPMs of the collection. A Conflicts entry matches the RPMs
+// providing the capability, an Obsoletes entry those of the package name,
+// in a version within its constraint, as rpm itself does.
+func (r *RPMs) DeclaredConflicts() ([]*ConflictError, error) {
+	headers := make([]*rpm.Package, len(*r))
+	provides := map[string][]int{}
+	for i, pkg := range *r {
+		p, err := pkg.header()
+		if err != nil {
+			return nil, err
+		}
+		headers[i] = p
+
+		provides[p.Name()] = append(provides[p.Name()], i)
+		for _, prov := range p.Provides() {
+			if prov.Name() != p.Name() {
+				provides[prov.Name()] = append(provides[prov.Name()], i)
+			}
+		}
+	}
+
+	var found []*ConflictError
+	for i, p := range headers {
+		for _, c := range p.Conflicts() {
+			for _, j := range provides[c.Name()] {
+				if j != i && provided(headers[j], c) {
+					found = append(found, r.conflictError(i, j, c, false))
+				}
+			}
+		}
+
+		for _, o := range p.Obsoletes() {
+			for j, other := range headers {
+				if j != i && other.Name() == o.Name() && overlaps(o, selfProvide(other)) {
+					found = append(found, r.conflictError(i, j, o, true))
+				}
+			}
+		}
+	}
+
+	return found, nil
+}
+
+func (r *RPMs) conflictError(i, j int, dep rpm.Dependency, obsoletes bool) *ConflictError {
+	return &ConflictError{
+		Path:       (*r)[i].Path,
+		Other:      (*r)[j].Path,
+		Dependency: fmt.Sprint(dep),
+		Obsoletes:  obsoletes,
+	}
+}
+
+// provided indicates if the package provides the capability
+// in a version within the dependency's constraint
+func provided(p *rpm.Package, dep rpm.Dependency) bool {
+	if p.Name() == dep.Name() && overlaps(dep, selfProvide(p)) {
+		return true
+	}
+
+	for _, prov := range p.Provides() {
+		if prov.Name() == dep.Name() && overlaps(dep, prov) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selfProvide is the implicit provide of a package's own name and version
+func selfProvide(p *rpm.Package) rpm.Dependency {
+	return constraint{
+		name:    p.Name(),
+		flags:   rpm.DepFlagEqual,
+		version: formatEVR(p.Epoch(), p.Version(), p.Release()),
+	}
+}
+
+// checkConflicts fails if the RPMs found for the top RPM at path hold
+// several versions of the same package, or if some conflict with or
+// obsolete others, the latter reported as joined *ConflictError
+func checkConflicts(path string, rpms *RPMs) error {
+	conflicts, err := rpms.Conflicts()
+	if err != nil {
+		return err
+	}
+
+	if len(conflicts) == 0 {
+		return checkDeclaredConflicts(path, rpms)
+	}
+
+	var lines []string
+	for _, c := range conflicts {
+		lines = append(lines, c.String())
+	}
+
+	return fmt.Errorf(
+		"%d packages required by %s are found with several versions:\n%s",
+		len(conflicts),
+		path,
+		strings.Join(lines, "\n"),
+	)
+}
+
+func checkDeclaredConflicts(path string, rpms *RPMs) error {
+	declared, err := rpms.DeclaredConflicts()
+	if err != nil {
+		return err
+	}
+
+	if len(declared) == 0 {
+		return nil
+	}
+
+	errs := make([]error, len(declared))
+	for i, c := range declared {
+		errs[i] = c
+	}
+
+	return fmt.Errorf("%d conflicts between the packages required by %s:\n%w", len(declared), path, errors.Join(errs...))
+}
@@ -0,0 +1,131 @@
+package rpm
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cavaliergopher/rpm"
+)
+
+func TestRPMsConflicts(t *testing.T) {
+	dir := t.TempDir()
+	var rpms RPMs
+	for filename, spec := range map[string]fixtureRPM{
+		"Gaudi-1.0.rpm":        {Name: "Gaudi", Version: "1.0", Release: "1"},
+		"Gaudi-1.1.rpm":        {Name: "Gaudi", Version: "1.1", Release: "1"},
+		"ROOT-6.x86_64.rpm":    {Name: "ROOT", Version: "6", Release: "1", Arch: "x86_64"},
+		"ROOT-6.aarch64.rpm":   {Name: "ROOT", Version: "6", Release: "1", Arch: "aarch64"},
+		"renamed-tbb-2020.rpm": {Name: "tbb", Version: "2020", Release: "1"},
+	} {
+		r, err := New(writeRPM(t, dir, filename, spec))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rpms = append(rpms, r)
+	}
+
+	conflicts, err := rpms.Conflicts()
+	if err != nil {
+		t.Fatalf("Conflicts failed (%v)", err)
+	}
+
+	if len(conflicts) != 1 || conflicts[0].Name != "Gaudi" || len(conflicts[0].Paths) != 2 {
+		t.Fatalf("Conflicts should only report Gaudi, got %v", conflicts)
+	}
+
+	got := strings.Join(conflicts[0].Versions, ",")
+	if got != "1.0-1,1.1-1" && got != "1.1-1,1.0-1" {
+		t.Errorf("Conflicts should report the Gaudi versions, got %s", got)
+	}
+}
+
+func TestFinderWithConflictCheck(t *testing.T) {
+	dir := t.TempDir()
+	writeRPM(t, dir, "Athena_22.0.1_x86_64.rpm", fixtureRPM{
+		Name:     "Athena",
+		Version:  "22.0.1",
+		Release:  "1",
+		Requires: []fixtureDep{{Name: "Gaudi"}, {Name: "GaudiPlugins"}},
+	})
+	writeRPM(t, dir, "Gaudi-1.0.rpm", fixtureRPM{Name: "Gaudi", Version: "1.0", Release: "1"})
+	writeRPM(t, dir, "GaudiPlugins-1.1.rpm", fixtureRPM{
+		Name:     "Gaudi",
+		Version:  "1.1",
+		Release:  "1",
+		Provides: []fixtureDep{{Name: "GaudiPlugins"}},
+	})
+
+	if _, err := NewFinder(dir).Find("Athena", "x86_64"); err != nil {
+		t.Errorf("Find should not check conflicts by default, got %v", err)
+	}
+
+	_, err := NewFinder(dir, WithConflictCheck()).Find("Athena", "x86_64")
+	if err == nil || !strings.Contains(err.Error(), "Gaudi: ") {
+		t.Errorf("Find should report the Gaudi conflict, got %v", err)
+	}
+}
+
+func TestRPMsDeclaredConflicts(t *testing.T) {
+	dir := t.TempDir()
+	var rpms RPMs
+	for _, spec := range []fixtureRPM{
+		{Name: "tbb", Version: "2020", Release: "1", Provides: []fixtureDep{{Name: "libtbb.so.2"}}},
+		{Name: "onetbb", Version: "2021", Release: "1", Obsoletes: []fixtureDep{{Name: "tbb", Flags: rpm.DepFlagLesser, Version: "2021"}}},
+		{Name: "old-tbb-user", Version: "1", Release: "1", Conflicts: []fixtureDep{{Name: "libtbb.so.2"}}},
+		{Name: "new-tbb-user", Version: "1", Release: "1", Conflicts: []fixtureDep{{Name: "onetbb", Flags: rpm.DepFlagLesser, Version: "2021"}}},
+	} {
+		r, err := New(writeRPM(t, dir, spec.Name+".rpm", spec))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rpms = append(rpms, r)
+	}
+
+	conflicts, err := rpms.DeclaredConflicts()
+	if err != nil {
+		t.Fatalf("DeclaredConflicts failed (%v)", err)
+	}
+
+	if len(conflicts) != 2 {
+		t.Fatalf("DeclaredConflicts should report 2 conflicts, got %v", conflicts)
+	}
+
+	if c := conflicts[0]; !c.Obsoletes || c.Path != rpms[1].Path || c.Other != rpms[0].Path {
+		t.Errorf("DeclaredConflicts should report onetbb obsoleting tbb, got %v", c)
+	}
+
+	if c := conflicts[1]; c.Obsoletes || c.Path != rpms[2].Path || c.Other != rpms[0].Path || c.Dependency != "libtbb.so.2" {
+		t.Errorf("DeclaredConflicts should report old-tbb-user conflicting with tbb, got %v", c)
+	}
+}
+
+func TestFinderWithConflictCheckDeclared(t *testing.T) {
+	dir := t.TempDir()
+	writeRPM(t, dir, "Athena_22.0.1_x86_64.rpm", fixtureRPM{
+		Name:     "Athena",
+		Version:  "22.0.1",
+		Release:  "1",
+		Requires: []fixtureDep{{Name: "ROOT"}, {Name: "libGaudi.so"}},
+	})
+	writeRPM(t, dir, "ROOT-6.rpm", fixtureRPM{Name: "ROOT", Version: "6", Release: "1"})
+	writeRPM(t, dir, "Gaudi-1.0.rpm", fixtureRPM{
+		Name:      "Gaudi",
+		Version:   "1.0",
+		Release:   "1",
+		Provides:  []fixtureDep{{Name: "libGaudi.so"}},
+		Conflicts: []fixtureDep{{Name: "ROOT", Flags: rpm.DepFlagLesser, Version: "7"}},
+	})
+
+	_, err := NewFinder(dir, WithConflictCheck()).Find("Athena", "x86_64")
+
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("Find should return a ConflictError, got %v", err)
+	}
+
+	if filepath.Base(conflict.Path) != "Gaudi-1.0.rpm" || filepath.Base(conflict.Other) != "ROOT-6.rpm" {
+		t.Errorf("Find should report Gaudi conflicting with ROOT, got %v", conflict)
+	}
+}
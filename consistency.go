@@ -0,0 +1,49 @@
+package rpm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cavaliergopher/rpm"
+)
+
+// SelfConsistency checks the package's own Requires, Provides, Obsoletes
+// and Conflicts for contradictions, such as requiring a capability that
+// the package also conflicts with or obsoletes. Such a package can never
+// be installed, as it breaks dependency resolution.
+func (r *RPM) SelfConsistency() error {
+	p, err := r.header()
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	problems = append(problems, contradictions(p.Requires(), p.Conflicts(), "conflicts with")...)
+	problems = append(problems, contradictions(p.Requires(), p.Obsoletes(), "obsoletes")...)
+
+	if len(problems) > 0 {
+		return fmt.Errorf(
+			"%s has %d self-contradictory dependencies:\n%s",
+			r.Name(),
+			len(problems),
+			strings.Join(problems, "\n"),
+		)
+	}
+
+	return nil
+}
+
+// contradictions lists those requires that are matched
+// by one of the given conflicts or obsoletes
+func contradictions(requires, others []rpm.Dependency, verb string) []string {
+	var found []string
+	for _, req := range requires {
+		for _, other := range others {
+			if req.Name() == other.Name() && overlaps(req, other) {
+				found = append(found, fmt.Sprintf("requires %s but %s %s", req, verb, other))
+			}
+		}
+	}
+
+	return found
+}
@@ -0,0 +1,87 @@
+package rpm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cavaliergopher/rpm"
+)
+
+func TestSelfConsistencyClean(t *testing.T) {
+	path := writeRPM(t, t.TempDir(), "clean.rpm", fixtureRPM{
+		Name:      "clean",
+		Version:   "1.0",
+		Release:   "1",
+		Requires:  []fixtureDep{{Name: "libfoo", Flags: rpm.DepFlagGreaterOrEqual, Version: "2.0"}},
+		Conflicts: []fixtureDep{{Name: "libfoo", Flags: rpm.DepFlagLesser, Version: "1.5"}},
+		Provides:  []fixtureDep{{Name: "oldclean"}},
+		Obsoletes: []fixtureDep{{Name: "oldclean", Flags: rpm.DepFlagLesser, Version: "1.0"}},
+	})
+
+	if err := (&RPM{Path: path}).SelfConsistency(); err != nil {
+		t.Errorf("SelfConsistency should return nil for a clean package, got %v", err)
+	}
+}
+
+func TestSelfConsistencyContradictions(t *testing.T) {
+	path := writeRPM(t, t.TempDir(), "broken.rpm", fixtureRPM{
+		Name:      "broken",
+		Version:   "1.0",
+		Release:   "1",
+		Requires:  []fixtureDep{{Name: "libfoo"}, {Name: "libbar", Flags: rpm.DepFlagEqual, Version: "1.2"}},
+		Conflicts: []fixtureDep{{Name: "libfoo"}},
+		Obsoletes: []fixtureDep{{Name: "libbar", Flags: rpm.DepFlagLesserOrEqual, Version: "1.2"}},
+	})
+
+	err := (&RPM{Path: path}).SelfConsistency()
+	if err == nil {
+		t.Fatalf("SelfConsistency should have returned an error, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "2 self-contradictory") {
+		t.Errorf("SelfConsistency should report 2 problems, got %v", err)
+	}
+}
+
+func TestSelfConsistencyUnreadable(t *testing.T) {
+	if err := (&RPM{Path: "/blip/blop.rpm"}).SelfConsistency(); err == nil {
+		t.Errorf("SelfConsistency should fail for an inexistant RPM, got nil")
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	dep := func(flags int, version string) rpm.Dependency {
+		return &evrDep{flags: flags, version: version}
+	}
+
+	tests := []struct {
+		a, b   rpm.Dependency
+		expect bool
+	}{
+		{dep(0, ""), dep(rpm.DepFlagLesser, "1.0"), true},
+		{dep(rpm.DepFlagGreaterOrEqual, "2.0"), dep(rpm.DepFlagLesser, "1.5"), false},
+		{dep(rpm.DepFlagGreaterOrEqual, "1.0"), dep(rpm.DepFlagLesser, "1.5"), true},
+		{dep(rpm.DepFlagEqual, "1.2"), dep(rpm.DepFlagLesserOrEqual, "1.2"), true},
+		{dep(rpm.DepFlagEqual, "1.2"), dep(rpm.DepFlagLesser, "1.2"), false},
+		{dep(rpm.DepFlagEqual, "1:1.0"), dep(rpm.DepFlagGreater, "2.0"), true},
+		{dep(rpm.DepFlagEqual, "1.0-2"), dep(rpm.DepFlagEqual, "1.0"), true},
+	}
+
+	for i, tt := range tests {
+		if got := overlaps(tt.a, tt.b); got != tt.expect {
+			t.Errorf("overlaps test %d returned %t, expected %t", i, got, tt.expect)
+		}
+	}
+}
+
+// evrDep is a bare rpm.Dependency for testing version constraints
+type evrDep struct {
+	flags   int
+	version string
+}
+
+func (d *evrDep) Name() string    { return "dep" }
+func (d *evrDep) Flags() int      { return d.flags }
+func (d *evrDep) Epoch() int      { return 0 }
+func (d *evrDep) Version() string { return d.version }
+func (d *evrDep) Release() string { return "" }
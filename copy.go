@@ -0,0 +1,111 @@
+package rpm
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// CopyOptions configure RPMs.CopyTo
+type CopyOptions struct {
+	// UseHardlinks makes CopyTo hard link files rather than copying them,
+	// falling back to a copy when linking fails, e.g. across filesystems,
+	// or for RPMs found by a Finder created WithFS
+	UseHardlinks bool
+}
+
+// CopyResult reports how a single RPM was copied
+type CopyResult struct {
+	Src    string
+	Dst    string
+	Linked bool
+}
+
+// CopyTo copies each of the RPMs into dir, creating it if needed,
+// and reports per file whether it was hard linked or copied
+func (r *RPMs) CopyTo(dir string, opts CopyOptions) ([]CopyResult, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var results []CopyResult
+	for _, rr := range *r {
+		res := CopyResult{Src: rr.Path, Dst: filepath.Join(dir, rr.Name())}
+		if opts.UseHardlinks && rr.fsys == nil {
+			res.Linked = link(res.Src, res.Dst)
+		}
+
+		if !res.Linked {
+			if err := copyFile(rr, res.Dst); err != nil {
+				return results, fmt.Errorf("failed to copy %s to %s (%w)", res.Src, dir, err)
+			}
+		}
+		results = append(results, res)
+	}
+
+	return results, nil
+}
+
+// link hard links src to dst, replacing any existing dst file,
+// and indicates if it succeeded
+func link(src, dst string) bool {
+	if sameFile(src, dst) {
+		return true
+	}
+
+	tmp := dst + ".link"
+	os.Remove(tmp)
+	if err := os.Link(src, tmp); err != nil {
+		return false
+	}
+
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return false
+	}
+
+	return true
+}
+
+func sameFile(a, b string) bool {
+	fa, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+
+	fb, err := os.Stat(b)
+	return err == nil && os.SameFile(fa, fb)
+}
+
+// copyFile copies the RPM file to dst via a temporary
+// file, so that dst is never left behind half written
+func copyFile(src *RPM, dst string) error {
+	in, err := src.open()
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	if err := out.Chmod(0644); err != nil {
+		out.Close()
+		return err
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(out.Name(), dst)
+}
@@ -0,0 +1,60 @@
+package rpm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRPMsCopyTo(t *testing.T) {
+	rpms := createChecksumRPMs(t, 2)
+	dst := filepath.Join(t.TempDir(), "staging")
+
+	results, err := rpms.CopyTo(dst, CopyOptions{})
+	if err != nil {
+		t.Fatalf("CopyTo failed (%v)", err)
+	}
+
+	if len(results) != 2 || results[0].Linked {
+		t.Fatalf("CopyTo should copy 2 files, got %+v", results)
+	}
+
+	data, err := os.ReadFile(results[1].Dst)
+	if err != nil || string(data) != "abc" {
+		t.Errorf("CopyTo destination should contain abc, got %q (%v)", data, err)
+	}
+
+	if sameFile(results[0].Src, results[0].Dst) {
+		t.Errorf("CopyTo without hardlinks should not link %s", results[0].Dst)
+	}
+}
+
+func TestRPMsCopyToHardlinks(t *testing.T) {
+	rpms := createChecksumRPMs(t, 2)
+	dst := t.TempDir()
+
+	for i := 0; i < 2; i++ {
+		// Linking twice must leave the source intact
+		results, err := rpms.CopyTo(dst, CopyOptions{UseHardlinks: true})
+		if err != nil {
+			t.Fatalf("CopyTo failed (%v)", err)
+		}
+
+		for _, res := range results {
+			if !res.Linked || !sameFile(res.Src, res.Dst) {
+				t.Errorf("CopyTo should have hard linked %s to %s", res.Src, res.Dst)
+			}
+		}
+	}
+
+	if data, _ := os.ReadFile((*rpms)[0].Path); string(data) != "abc" {
+		t.Errorf("CopyTo should leave the source unchanged, got %q", data)
+	}
+}
+
+func TestRPMsCopyToMissing(t *testing.T) {
+	rpms := &RPMs{&RPM{Path: "/blip/blop.rpm"}}
+	if _, err := rpms.CopyTo(t.TempDir(), CopyOptions{UseHardlinks: true}); err == nil {
+		t.Errorf("CopyTo should fail for an inexistant RPM, got nil")
+	}
+}
@@ -0,0 +1,541 @@
+package rpm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cavaliergopher/rpm"
+)
+
+// XML namespaces of the repodata files
+const (
+	nsCommon    = "http://linux.duke.edu/metadata/common"
+	nsRPM       = "http://linux.duke.edu/metadata/rpm"
+	nsFilelists = "http://linux.duke.edu/metadata/filelists"
+	nsOther     = "http://linux.duke.edu/metadata/other"
+	nsRepo      = "http://linux.duke.edu/metadata/repo"
+)
+
+// Header tags of the package changelog
+const (
+	tagChangelogTime = 1080
+	tagChangelogName = 1081
+	tagChangelogText = 1082
+)
+
+// CreateRepo writes the repodata of the RPMs found in dir and its
+// subdirectories into dir/repodata, as createrepo_c does, so that dir can
+// be served as a yum or dnf repo, e.g. to a RemoteFinder. Any existing
+// repodata is replaced.
+func CreateRepo(dir string) error {
+	return CreateRepoContext(context.Background(), dir, 0)
+}
+
+// CreateRepoContext is like CreateRepo, but gives up as soon as ctx is done.
+// Up to concurrency RPMs are read in parallel; a concurrency below 1 means
+// DefaultConcurrency.
+func CreateRepoContext(ctx context.Context, dir string, concurrency int) error {
+	hrefs, err := repoPackageFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	pkgs := make([]*mdPackage, len(hrefs))
+	err = forEach(ctx, len(hrefs), concurrency, func(i int) error {
+		pkg, err := newMDPackage(&RPM{Path: filepath.Join(dir, filepath.FromSlash(hrefs[i]))}, hrefs[i])
+		pkgs[i] = pkg
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Write the repodata aside, then swap it in
+	tmp := filepath.Join(dir, ".repodata")
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tmp, 0755); err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	files, err := repodataFiles(pkgs)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := os.WriteFile(filepath.Join(tmp, file.name), file.data, 0644); err != nil {
+			return err
+		}
+	}
+
+	repodata := filepath.Join(dir, "repodata")
+	if err := os.RemoveAll(repodata); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, repodata)
+}
+
+// repoPackageFiles returns the slash separated paths, relative to dir,
+// of the RPM files below dir, but for those of hidden directories
+func repoPackageFiles(dir string) ([]string, error) {
+	var hrefs []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if strings.HasSuffix(d.Name(), ".rpm") && !strings.HasSuffix(d.Name(), ".src.rpm") {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			hrefs = append(hrefs, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the RPMs of %s (%w)", dir, err)
+	}
+
+	return hrefs, nil
+}
+
+// repodataFile is a file of the repodata directory of a repo
+type repodataFile struct {
+	name string
+	data []byte
+}
+
+// repodataFiles renders the repodata of the packages: the gzipped primary,
+// filelists and other documents, followed by the repomd.xml indexing them
+func repodataFiles(pkgs []*mdPackage) ([]repodataFile, error) {
+	now := time.Now().Unix()
+	md := mdRepomd{Xmlns: nsRepo, XmlnsRPM: nsRPM, Revision: now}
+
+	var files []repodataFile
+	for _, doc := range []struct {
+		typ  string
+		root interface{}
+	}{
+		{"primary", primaryXML(pkgs)},
+		{"filelists", filelistsXML(pkgs)},
+		{"other", otherXML(pkgs)},
+	} {
+		file, data, err := repodataDocument(doc.typ, doc.root)
+		if err != nil {
+			return nil, err
+		}
+		data.Timestamp = now
+		md.Data = append(md.Data, *data)
+		files = append(files, *file)
+	}
+
+	var buf bytes.Buffer
+	if err := encodeXML(&buf, md); err != nil {
+		return nil, err
+	}
+
+	return append(files, repodataFile{"repomd.xml", buf.Bytes()}), nil
+}
+
+// repodataDocument renders the gzipped XML document of the given type,
+// named after its checksum, and returns it with its repomd entry
+func repodataDocument(typ string, root interface{}) (*repodataFile, *mdData, error) {
+	var doc bytes.Buffer
+	if err := encodeXML(&doc, root); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode %s metadata (%w)", typ, err)
+	}
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	if _, err := zw.Write(doc.Bytes()); err != nil {
+		return nil, nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	sum, openSum := sha256.Sum256(gz.Bytes()), sha256.Sum256(doc.Bytes())
+	name := fmt.Sprintf("%s-%s.xml.gz", hex.EncodeToString(sum[:]), typ)
+
+	return &repodataFile{name, gz.Bytes()}, &mdData{
+		Type:         typ,
+		Checksum:     mdChecksum{Type: "sha256", Value: hex.EncodeToString(sum[:])},
+		OpenChecksum: mdChecksum{Type: "sha256", Value: hex.EncodeToString(openSum[:])},
+		Location:     repoLocation{Href: "repodata/" + name},
+		Size:         int64(gz.Len()),
+		OpenSize:     int64(doc.Len()),
+	}, nil
+}
+
+// encodeXML writes v as an indented XML document
+func encodeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ---------------------------------------------------------------------
+
+// mdPackage is the repodata of a package, in the form of its primary
+// metadata entry, with the files and changelog of the other documents
+type mdPackage struct {
+	XMLName     xml.Name   `xml:"package"`
+	Type        string     `xml:"type,attr"`
+	Name        string     `xml:"name"`
+	Arch        string     `xml:"arch"`
+	Version     mdVersion  `xml:"version"`
+	Checksum    mdChecksum `xml:"checksum"`
+	Summary     string     `xml:"summary"`
+	Description string     `xml:"description"`
+	Packager    string     `xml:"packager"`
+	URL         string     `xml:"url"`
+	Time        struct {
+		File  int64 `xml:"file,attr"`
+		Build int64 `xml:"build,attr"`
+	} `xml:"time"`
+	Size struct {
+		Package   int64 `xml:"package,attr"`
+		Installed int64 `xml:"installed,attr"`
+		Archive   int64 `xml:"archive,attr"`
+	} `xml:"size"`
+	Location repoLocation `xml:"location"`
+	Format   mdFormat     `xml:"format"`
+
+	files      []mdFile
+	changelogs []mdChangelog
+}
+
+type mdVersion struct {
+	Epoch string `xml:"epoch,attr"`
+	Ver   string `xml:"ver,attr"`
+	Rel   string `xml:"rel,attr"`
+}
+
+type mdChecksum struct {
+	Type  string `xml:"type,attr"`
+	PkgID string `xml:"pkgid,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+type mdFormat struct {
+	License     string `xml:"rpm:license"`
+	Vendor      string `xml:"rpm:vendor"`
+	Group       string `xml:"rpm:group"`
+	BuildHost   string `xml:"rpm:buildhost"`
+	SourceRPM   string `xml:"rpm:sourcerpm"`
+	HeaderRange struct {
+		Start int64 `xml:"start,attr"`
+		End   int64 `xml:"end,attr"`
+	} `xml:"rpm:header-range"`
+	Provides  []mdEntry `xml:"rpm:provides>rpm:entry,omitempty"`
+	Requires  []mdEntry `xml:"rpm:requires>rpm:entry,omitempty"`
+	Conflicts []mdEntry `xml:"rpm:conflicts>rpm:entry,omitempty"`
+	Obsoletes []mdEntry `xml:"rpm:obsoletes>rpm:entry,omitempty"`
+
+	Recommends []mdEntry `xml:"rpm:recommends>rpm:entry,omitempty"`
+	Suggests   []mdEntry `xml:"rpm:suggests>rpm:entry,omitempty"`
+
+	Files []mdFile `xml:"file"`
+}
+
+type mdEntry struct {
+	Name  string `xml:"name,attr"`
+	Flags string `xml:"flags,attr,omitempty"`
+	Epoch string `xml:"epoch,attr,omitempty"`
+	Ver   string `xml:"ver,attr,omitempty"`
+	Rel   string `xml:"rel,attr,omitempty"`
+	Pre   string `xml:"pre,attr,omitempty"`
+}
+
+type mdFile struct {
+	Type string `xml:"type,attr,omitempty"`
+	Path string `xml:",chardata"`
+}
+
+type mdChangelog struct {
+	Author string `xml:"author,attr"`
+	Date   int64  `xml:"date,attr"`
+	Text   string `xml:",chardata"`
+}
+
+// mdPackageRef identifies a package in the filelists and other metadata
+type mdPackageRef struct {
+	PkgID   string    `xml:"pkgid,attr"`
+	Name    string    `xml:"name,attr"`
+	Arch    string    `xml:"arch,attr"`
+	Version mdVersion `xml:"version"`
+}
+
+type mdRepomd struct {
+	XMLName  xml.Name `xml:"repomd"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	XmlnsRPM string   `xml:"xmlns:rpm,attr"`
+	Revision int64    `xml:"revision"`
+	Data     []mdData `xml:"data"`
+}
+
+type mdData struct {
+	Type         string       `xml:"type,attr"`
+	Checksum     mdChecksum   `xml:"checksum"`
+	OpenChecksum mdChecksum   `xml:"open-checksum"`
+	Location     repoLocation `xml:"location"`
+	Timestamp    int64        `xml:"timestamp"`
+	Size         int64        `xml:"size"`
+	OpenSize     int64        `xml:"open-size"`
+}
+
+func (pkg *mdPackage) ref() mdPackageRef {
+	return mdPackageRef{PkgID: pkg.Checksum.Value, Name: pkg.Name, Arch: pkg.Arch, Version: pkg.Version}
+}
+
+func primaryXML(pkgs []*mdPackage) interface{} {
+	return struct {
+		XMLName  xml.Name     `xml:"metadata"`
+		Xmlns    string       `xml:"xmlns,attr"`
+		XmlnsRPM string       `xml:"xmlns:rpm,attr"`
+		Count    int          `xml:"packages,attr"`
+		Packages []*mdPackage `xml:"package"`
+	}{Xmlns: nsCommon, XmlnsRPM: nsRPM, Count: len(pkgs), Packages: pkgs}
+}
+
+func filelistsXML(pkgs []*mdPackage) interface{} {
+	type filelistsPackage struct {
+		mdPackageRef
+		Files []mdFile `xml:"file"`
+	}
+
+	entries := make([]filelistsPackage, len(pkgs))
+	for i, pkg := range pkgs {
+		entries[i] = filelistsPackage{pkg.ref(), pkg.files}
+	}
+
+	return struct {
+		XMLName  xml.Name           `xml:"filelists"`
+		Xmlns    string             `xml:"xmlns,attr"`
+		Count    int                `xml:"packages,attr"`
+		Packages []filelistsPackage `xml:"package"`
+	}{Xmlns: nsFilelists, Count: len(pkgs), Packages: entries}
+}
+
+func otherXML(pkgs []*mdPackage) interface{} {
+	type otherPackage struct {
+		mdPackageRef
+		Changelogs []mdChangelog `xml:"changelog"`
+	}
+
+	entries := make([]otherPackage, len(pkgs))
+	for i, pkg := range pkgs {
+		entries[i] = otherPackage{pkg.ref(), pkg.changelogs}
+	}
+
+	return struct {
+		XMLName  xml.Name       `xml:"otherdata"`
+		Xmlns    string         `xml:"xmlns,attr"`
+		Count    int            `xml:"packages,attr"`
+		Packages []otherPackage `xml:"package"`
+	}{Xmlns: nsOther, Count: len(pkgs), Packages: entries}
+}
+
+// newMDPackage reads the repodata of the RPM, located at href in the repo
+func newMDPackage(r *RPM, href string) (*mdPackage, error) {
+	p, err := r.header()
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := r.files().Stat(r.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	sum, err := r.Checksum("sha256")
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := headerRange(r)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &mdPackage{
+		Type:        "rpm",
+		Name:        p.Name(),
+		Arch:        p.Architecture(),
+		Version:     mdVersion{Epoch: strconv.Itoa(p.Epoch()), Ver: p.Version(), Rel: p.Release()},
+		Checksum:    mdChecksum{Type: "sha256", PkgID: "YES", Value: sum},
+		Summary:     p.Summary(),
+		Description: p.Description(),
+		Packager:    p.Packager(),
+		URL:         p.URL(),
+		Location:    repoLocation{Href: href},
+	}
+	pkg.Time.File = fi.ModTime().Unix()
+	pkg.Time.Build = p.BuildTime().Unix()
+	pkg.Size.Package = fi.Size()
+	pkg.Size.Installed = int64(p.Size())
+	pkg.Size.Archive = int64(p.ArchiveSize())
+
+	pkg.Format = mdFormat{
+		License:   p.License(),
+		Vendor:    p.Vendor(),
+		Group:     strings.Join(p.Groups(), " "),
+		BuildHost: p.BuildHost(),
+		SourceRPM: p.SourceRPM(),
+		Provides:  mdEntries(p.Provides(), nil),
+		Conflicts: mdEntries(p.Conflicts(), nil),
+		Obsoletes: mdEntries(p.Obsoletes(), nil),
+
+		Recommends: mdEntries(p.Recommends(), nil),
+		Suggests:   mdEntries(p.Suggests(), nil),
+	}
+	pkg.Format.HeaderRange.Start, pkg.Format.HeaderRange.End = start, end
+
+	// Requires on rpm features, or on the package itself, are left out
+	pkg.Format.Requires = mdEntries(p.Requires(), func(dep rpm.Dependency) bool {
+		return !strings.HasPrefix(dep.Name(), "rpmlib(") && !provided(p, dep)
+	})
+
+	for _, f := range p.Files() {
+		file := mdFile{Path: f.Name()}
+		switch {
+		case f.Flags()&rpm.FileFlagGhost != 0:
+			file.Type = "ghost"
+		case f.Mode().IsDir():
+			file.Type = "dir"
+		}
+		pkg.files = append(pkg.files, file)
+
+		// As createrepo, list in primary the files likely to be required
+		if strings.HasPrefix(file.Path, "/etc/") || strings.Contains(file.Path, "bin/") || file.Path == "/usr/lib/sendmail" {
+			pkg.Format.Files = append(pkg.Format.Files, file)
+		}
+	}
+
+	times := p.Header.GetTag(tagChangelogTime).Int64Slice()
+	names := p.Header.GetTag(tagChangelogName).StringSlice()
+	texts := p.Header.GetTag(tagChangelogText).StringSlice()
+	for i := 0; i < len(times) && i < len(names) && i < len(texts); i++ {
+		pkg.changelogs = append(pkg.changelogs, mdChangelog{Author: names[i], Date: times[i], Text: texts[i]})
+	}
+
+	return pkg, nil
+}
+
+// mdEntries returns the repodata entries of the dependencies
+// for which keep, if not nil, returns true
+func mdEntries(deps []rpm.Dependency, keep func(rpm.Dependency) bool) []mdEntry {
+	var entries []mdEntry
+	for _, dep := range deps {
+		if keep != nil && !keep(dep) {
+			continue
+		}
+
+		entry := mdEntry{Name: dep.Name(), Flags: mdFlags(dep.Flags())}
+		if dep.Version() != "" {
+			e := parseEVR(dep.Version())
+			entry.Epoch, entry.Ver, entry.Rel = strconv.Itoa(e.epoch), e.version, e.release
+		}
+		if dep.Flags()&(rpm.DepFlagPrereq|rpm.DepFlagScriptPre|rpm.DepFlagScriptPost) != 0 {
+			entry.Pre = "1"
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// mdFlags returns the repodata name of the comparison of the dependency flags
+func mdFlags(flags int) string {
+	switch flags & (rpm.DepFlagLesser | rpm.DepFlagGreater | rpm.DepFlagEqual) {
+	case rpm.DepFlagLesser:
+		return "LT"
+	case rpm.DepFlagGreater:
+		return "GT"
+	case rpm.DepFlagEqual:
+		return "EQ"
+	case rpm.DepFlagLesserOrEqual:
+		return "LE"
+	case rpm.DepFlagGreaterOrEqual:
+		return "GE"
+	}
+
+	return ""
+}
+
+// headerRange returns the byte offsets of the start and end of the main
+// header of the RPM file, which follows the lead and the signature header,
+// padded to 8 bytes
+func headerRange(r *RPM) (int64, int64, error) {
+	f, err := r.open()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	at, ok := f.(io.ReaderAt)
+	if !ok {
+		return 0, 0, fmt.Errorf("failed to read rpm header of %s (no random access)", r.Path)
+	}
+
+	return readHeaderRange(at, r.Path)
+}
+
+// readHeaderRange is headerRange, for the RPM file at path opened as f
+func readHeaderRange(f io.ReaderAt, path string) (int64, int64, error) {
+	// Each header is a 16 byte intro, 16 bytes per index entry and a store
+	headerSize := func(offset int64) (int64, error) {
+		intro := make([]byte, 16)
+		if _, err := f.ReadAt(intro, offset); err != nil {
+			return 0, fmt.Errorf("failed to read rpm header of %s (%w)", path, err)
+		}
+		entries, store := binary.BigEndian.Uint32(intro[8:12]), binary.BigEndian.Uint32(intro[12:16])
+		return 16 + 16*int64(entries) + int64(store), nil
+	}
+
+	sig, err := headerSize(leadSize)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	start := leadSize + (sig+7)/8*8
+	size, err := headerSize(start)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return start, start + size, nil
+}
@@ -0,0 +1,144 @@
+package rpm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateRepo(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"Packages/g", ".cache"} {
+		os.MkdirAll(filepath.Join(dir, sub), 0755)
+	}
+	writeRPM(t, filepath.Join(dir, "Packages"), "Athena_22.0.2_x86_64.rpm", fixtureRPM{
+		Name: "Athena", Version: "22.0.2", Release: "1", Arch: "x86_64",
+		Requires: []fixtureDep{{Name: "libGaudi.so"}, {Name: "rpmlib(PayloadIsXz)", Flags: 16777226, Version: "5.2-1"}},
+		Files:    []fixtureFile{{Path: "/opt/atlas/bin/athena.py", Mode: 0100755}, {Path: "/opt/atlas", Mode: 040755}},
+	})
+	writeRPM(t, filepath.Join(dir, "Packages", "g"), "Gaudi-1.0.rpm", fixtureRPM{
+		Name: "Gaudi", Version: "1.0", Release: "1", Arch: "x86_64",
+		Provides: []fixtureDep{{Name: "libGaudi.so"}},
+		Requires: []fixtureDep{{Name: "tbb", Flags: 12, Version: "1:2020-1"}},
+	})
+	writeRPM(t, dir, "tbb-2020.rpm", fixtureRPM{Name: "tbb", Version: "2020", Release: "1", Epoch: 1, Arch: "x86_64"})
+	writeRPM(t, filepath.Join(dir, ".cache"), "stale.rpm", fixtureRPM{Name: "stale", Version: "1", Release: "1"})
+
+	// A previous run leaves repodata, which is replaced
+	os.MkdirAll(filepath.Join(dir, "repodata"), 0755)
+	os.WriteFile(filepath.Join(dir, "repodata", "old-primary.xml.gz"), nil, 0644)
+
+	if err := CreateRepo(dir); err != nil {
+		t.Fatalf("CreateRepo failed (%v)", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "repodata", "old-primary.xml.gz")); !os.IsNotExist(err) {
+		t.Errorf("CreateRepo should replace the old repodata, got %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "repodata", "repomd.xml"))
+	if err != nil {
+		t.Fatalf("CreateRepo should write repomd.xml (%v)", err)
+	}
+
+	var md mdRepomd
+	if err := xml.Unmarshal(content, &md); err != nil || len(md.Data) != 3 {
+		t.Fatalf("repomd.xml should list 3 metadata files, got %+v (%v)", md, err)
+	}
+
+	docs := map[string]string{}
+	for _, data := range md.Data {
+		gz, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(data.Location.Href)))
+		if err != nil {
+			t.Fatalf("CreateRepo should write %s (%v)", data.Location.Href, err)
+		}
+
+		if sum := sha256.Sum256(gz); hex.EncodeToString(sum[:]) != data.Checksum.Value || int64(len(gz)) != data.Size {
+			t.Errorf("repomd.xml has a bad checksum or size for %s", data.Type)
+		}
+
+		zr, err := gzip.NewReader(bytes.NewReader(gz))
+		if err != nil {
+			t.Fatalf("%s should be gzipped (%v)", data.Location.Href, err)
+		}
+		doc, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("failed to decompress %s (%v)", data.Location.Href, err)
+		}
+		docs[data.Type] = string(doc)
+	}
+
+	primary := docs["primary"]
+	for _, expect := range []string{
+		`packages="3"`,
+		`<location href="Packages/g/Gaudi-1.0.rpm"></location>`,
+		`<rpm:entry name="tbb" flags="GE" epoch="1" ver="2020" rel="1"></rpm:entry>`,
+		`<version epoch="1" ver="2020" rel="1"></version>`,
+		`<file>/opt/atlas/bin/athena.py</file>`,
+	} {
+		if !strings.Contains(primary, expect) {
+			t.Errorf("primary.xml should hold %s, got\n%s", expect, primary)
+		}
+	}
+
+	if strings.Contains(primary, "rpmlib(") || strings.Contains(primary, "stale") {
+		t.Errorf("primary.xml should leave out rpmlib requires and hidden directories, got\n%s", primary)
+	}
+
+	if !strings.Contains(docs["filelists"], `<file type="dir">/opt/atlas</file>`) {
+		t.Errorf("filelists.xml should list the directories, got\n%s", docs["filelists"])
+	}
+
+	if !strings.Contains(docs["other"], `name="tbb"`) {
+		t.Errorf("other.xml should list the packages, got\n%s", docs["other"])
+	}
+
+	// The repo is usable by a RemoteFinder
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer srv.Close()
+
+	rpms, err := NewRemoteFinder(Repo{URL: srv.URL}, t.TempDir(), WithTransitive(), WithChecksumCheck()).Find("Athena", "x86_64")
+	if err != nil {
+		t.Fatalf("Find failed (%v)", err)
+	}
+
+	if got := strings.Join(rpms.Names(), ","); got != "Athena_22.0.2_x86_64.rpm,Gaudi-1.0.rpm,tbb-2020.rpm" {
+		t.Errorf("Find should return the closure of Athena, got %s", got)
+	}
+}
+
+func TestHeaderRange(t *testing.T) {
+	path := writeRPM(t, t.TempDir(), "Athena.rpm", fixtureRPM{
+		Name: "Athena", Version: "1", Release: "1",
+		Compression: "gzip",
+		Payload:     cpioPayload(t, "gzip", installerEntries),
+	})
+
+	start, end, err := headerRange(&RPM{Path: path})
+	if err != nil {
+		t.Fatalf("headerRange failed (%v)", err)
+	}
+
+	content, _ := os.ReadFile(path)
+	if start <= leadSize || start%8 != 0 || end <= start || end >= int64(len(content)) {
+		t.Fatalf("headerRange returned a bad range %d-%d of %d bytes", start, end, len(content))
+	}
+
+	// The payload starts right after the header
+	if !strings.HasPrefix(string(content[end:]), "\x1f\x8b") {
+		t.Errorf("headerRange should end at the gzip payload, got %x", content[end:end+2])
+	}
+
+	if magic := content[start : start+3]; string(magic) != "\x8e\xad\xe8" {
+		t.Errorf("headerRange should start at the header magic, got %x", magic)
+	}
+}
@@ -0,0 +1,98 @@
+package rpm
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/cavaliergopher/rpm"
+)
+
+// evr is an epoch:version-release triplet as found in dependency constraints
+type evr struct {
+	epoch   int
+	version string
+	release string
+}
+
+func (e evr) Epoch() int      { return e.epoch }
+func (e evr) Version() string { return e.version }
+func (e evr) Release() string { return e.release }
+
+// parseEVR splits a constraint version of the form [epoch:]version[-release]
+func parseEVR(s string) evr {
+	var e evr
+	if i := strings.Index(s, ":"); i >= 0 {
+		e.epoch, _ = strconv.Atoi(s[:i])
+		s = s[i+1:]
+	}
+
+	if i := strings.LastIndex(s, "-"); i >= 0 {
+		e.version, e.release = s[:i], s[i+1:]
+	} else {
+		e.version = s
+	}
+
+	return e
+}
+
+// compareEVR compares two constraint versions. As with rpm itself, the
+// release is only taken into account if both sides specify one.
+func compareEVR(a, b evr) int {
+	if a.release == "" || b.release == "" {
+		a.release, b.release = "", ""
+	}
+
+	return rpm.Compare(a, b)
+}
+
+const senseMask = rpm.DepFlagLesser | rpm.DepFlagGreater | rpm.DepFlagEqual
+
+// overlaps indicates if the version ranges of two dependencies on the same
+// capability have at least one version in common. An unversioned
+// dependency overlaps with everything.
+func overlaps(a, b rpm.Dependency) bool {
+	af, bf := a.Flags()&senseMask, b.Flags()&senseMask
+	if af == 0 || bf == 0 || a.Version() == "" || b.Version() == "" {
+		return true
+	}
+
+	has := func(flags, sense int) bool { return flags&sense != 0 }
+
+	switch c := compareEVR(parseEVR(a.Version()), parseEVR(b.Version())); {
+	case c < 0:
+		return has(af, rpm.DepFlagGreater) || has(bf, rpm.DepFlagLesser)
+	case c > 0:
+		return has(af, rpm.DepFlagLesser) || has(bf, rpm.DepFlagGreater)
+	default:
+		return (has(af, rpm.DepFlagEqual) && has(bf, rpm.DepFlagEqual)) ||
+			(has(af, rpm.DepFlagLesser) && has(bf, rpm.DepFlagLesser)) ||
+			(has(af, rpm.DepFlagGreater) && has(bf, rpm.DepFlagGreater))
+	}
+}
+
+// constraint is a bare dependency on a capability, such as a provide
+// recorded in the capability index
+type constraint struct {
+	name    string
+	flags   int
+	version string
+}
+
+func (c constraint) Name() string    { return c.name }
+func (c constraint) Flags() int      { return c.flags }
+func (c constraint) Epoch() int      { return 0 }
+func (c constraint) Version() string { return c.version }
+func (c constraint) Release() string { return "" }
+
+// satisfies indicates if the provide is in the version range
+// of every given requirement on the same capability
+func (p Provider) satisfies(name string, reqs []rpm.Dependency) bool {
+	provided := constraint{name: name, flags: p.Flags, version: p.Version}
+	for _, req := range reqs {
+		if !overlaps(req, provided) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,71 @@
+package rpm
+
+import (
+	"sort"
+)
+
+// DiffDirs compares the RPM files of two directories, e.g. two snapshots
+// of a mirror. It returns the RPMs only present in newDir (added), those
+// only present in oldDir (removed) and the basenames present in both
+// whose checksums differ (changed).
+func DiffDirs(oldDir, newDir string) (added, removed RPMs, changed []string, err error) {
+	oldRPMs, err := listRPMs(osFileSystem{}, oldDir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	newRPMs, err := listRPMs(osFileSystem{}, newDir)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	oldByName := map[string]*RPM{}
+	for _, rr := range oldRPMs {
+		oldByName[rr.Name()] = rr
+	}
+
+	for _, rr := range newRPMs {
+		old, keyExists := oldByName[rr.Name()]
+		if !keyExists {
+			added = append(added, rr)
+			continue
+		}
+		delete(oldByName, rr.Name())
+
+		same, err := sameContent(old, rr)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if !same {
+			changed = append(changed, rr.Name())
+		}
+	}
+
+	for _, rr := range oldRPMs {
+		if _, keyExists := oldByName[rr.Name()]; keyExists {
+			removed = append(removed, rr)
+		}
+	}
+
+	sort.Strings(changed)
+	return added, removed, changed, nil
+}
+
+// sameContent indicates if two RPM files have identical content
+func sameContent(a, b *RPM) (bool, error) {
+	if a.Size != b.Size {
+		return false, nil
+	}
+
+	sumA, err := a.Checksum("sha256")
+	if err != nil {
+		return false, err
+	}
+
+	sumB, err := b.Checksum("sha256")
+	if err != nil {
+		return false, err
+	}
+
+	return sumA == sumB, nil
+}
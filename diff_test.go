@@ -0,0 +1,45 @@
+package rpm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiffDirs(t *testing.T) {
+	oldDir, newDir := t.TempDir(), t.TempDir()
+	write := func(dir, name, content string) {
+		os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+	}
+
+	write(oldDir, "same.rpm", "same")
+	write(newDir, "same.rpm", "same")
+	write(oldDir, "changed.rpm", "old")
+	write(newDir, "changed.rpm", "new")
+	write(oldDir, "gone.rpm", "gone")
+	write(newDir, "fresh.rpm", "fresh")
+	write(newDir, "notes.txt", "ignored")
+
+	added, removed, changed, err := DiffDirs(oldDir, newDir)
+	if err != nil {
+		t.Fatalf("DiffDirs failed (%v)", err)
+	}
+
+	if names := added.Names(); len(names) != 1 || names[0] != "fresh.rpm" {
+		t.Errorf("DiffDirs should report fresh.rpm as added, got %v", names)
+	}
+
+	if names := removed.Names(); len(names) != 1 || names[0] != "gone.rpm" {
+		t.Errorf("DiffDirs should report gone.rpm as removed, got %v", names)
+	}
+
+	if len(changed) != 1 || changed[0] != "changed.rpm" {
+		t.Errorf("DiffDirs should report changed.rpm as changed, got %v", changed)
+	}
+}
+
+func TestDiffDirsInexistant(t *testing.T) {
+	if _, _, _, err := DiffDirs("/blip/blop", t.TempDir()); err == nil {
+		t.Errorf("DiffDirs should fail for an inexistant directory, got nil")
+	}
+}
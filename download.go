@@ -0,0 +1,117 @@
+package rpm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// packageURL composes the URL of a file of the repo, given by its
+// path relative to the repo, below the repo prefix if there is one
+func (r Repo) packageURL(rel string) string {
+	parts := []string{strings.TrimRight(r.URL, "/")}
+	if prefix := strings.Trim(r.Prefix, "/"); prefix != "" {
+		parts = append(parts, prefix)
+	}
+
+	return strings.Join(append(parts, strings.TrimLeft(rel, "/")), "/")
+}
+
+// Download fetches the given package file from the repo into destDir and
+// returns the corresponding RPM. The transfer is aborted when ctx is done.
+// A response other than 200 OK is an error, and no partial file is left
+// behind on failure. On a network or server error, the next of the repo
+// BaseURLs is tried.
+func (r Repo) Download(ctx context.Context, pkgFilename, destDir string) (*RPM, error) {
+	if pkgFilename == "" || filepath.Base(pkgFilename) != pkgFilename {
+		return nil, fmt.Errorf("invalid package file name %q", pkgFilename)
+	}
+
+	bases, err := r.BaseURLs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(destDir, pkgFilename)
+	_, err = eachMirror(ctx, bases, func(base string) error {
+		return r.at(base).fetch(ctx, pkgFilename, path)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return New(path)
+}
+
+// get opens the file at the given path relative to the repo URL, failing
+// on any response other than 200 OK. The caller closes the body.
+func (r Repo) get(ctx context.Context, rel string) (io.ReadCloser, error) {
+	return getURL(ctx, r.packageURL(rel))
+}
+
+// getURL opens the given URL, failing on any response
+// other than 200 OK. The caller closes the body.
+func getURL(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s (%w)", url, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, &downloadError{url: url, status: resp.Status, code: resp.StatusCode}
+	}
+
+	return resp.Body, nil
+}
+
+// fetch downloads the file at the given path relative to the repo URL
+// to the local path
+func (r Repo) fetch(ctx context.Context, rel, path string) error {
+	body, err := r.get(ctx, rel)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if err := writeStream(path, body); err != nil {
+		return fmt.Errorf("failed to download %s (%w)", r.packageURL(rel), err)
+	}
+
+	return nil
+}
+
+// writeStream writes the content of src to a temporary file next to path,
+// then renames it into place once complete
+func writeStream(path string, src io.Reader) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.part")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,76 @@
+package rpm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestRepoPackageURL(t *testing.T) {
+	repo := Repo{URL: "https://example.repo/"}
+	if got := repo.packageURL("foo.rpm"); got != "https://example.repo/foo.rpm" {
+		t.Errorf("packageURL should return https://example.repo/foo.rpm, got %s", got)
+	}
+
+	repo.Prefix = "/nightly/"
+	if got := repo.packageURL("foo.rpm"); got != "https://example.repo/nightly/foo.rpm" {
+		t.Errorf("packageURL should return https://example.repo/nightly/foo.rpm, got %s", got)
+	}
+}
+
+func TestRepoDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/blah/foo.rpm" {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write([]byte("rpm content"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	repo := Repo{URL: srv.URL, Prefix: "blah"}
+
+	r, err := repo.Download(context.Background(), "foo.rpm", dir)
+	if err != nil {
+		t.Fatalf("Download failed (%v)", err)
+	}
+
+	if r.Size != 11 || r.Name() != "foo.rpm" {
+		t.Errorf("Download returned a bad RPM %s of size %d", r.Path, r.Size)
+	}
+
+	if _, err := repo.Download(context.Background(), "bar.rpm", dir); err == nil {
+		t.Errorf("Download should fail on a 404, got nil")
+	}
+
+	if _, err := repo.Download(context.Background(), "../foo.rpm", dir); err == nil {
+		t.Errorf("Download should reject a file name with a path, got nil")
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Download should leave no partial files behind, got %d entries", len(entries))
+	}
+}
+
+func TestRepoDownloadInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write(make([]byte, 1024))
+		w.(http.Flusher).Flush()
+		cancel()
+		<-req.Context().Done()
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	if _, err := (Repo{URL: srv.URL}).Download(ctx, "foo.rpm", dir); err == nil {
+		t.Errorf("Download should fail when interrupted, got nil")
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Download should remove the partial file, got %d entries", len(entries))
+	}
+}
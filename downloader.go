@@ -0,0 +1,377 @@
+package rpm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultRetries is the number of times a Downloader
+	// retries a failed transfer, unless configured
+	DefaultRetries = 3
+
+	// DefaultBackoff is the wait of a Downloader before the
+	// first retry of a transfer, unless configured
+	DefaultBackoff = time.Second
+
+	// DefaultMaxBackoff caps the wait between retries of a Downloader,
+	// unless configured
+	DefaultMaxBackoff = time.Minute
+)
+
+// Downloader fetches files over HTTP(S), several in parallel, retrying
+// failed transfers with exponential backoff. Interrupted transfers are
+// kept as path.part files and resumed with a Range request, by a retry
+// or a later run, so that large files need not be downloaded anew.
+// The zero value is ready to use.
+type Downloader struct {
+	// Client sends the requests, http.DefaultClient if nil
+	Client *http.Client
+
+	// Concurrency is the number of files fetched in parallel
+	// by FetchAll, DefaultConcurrency if below 1
+	Concurrency int
+
+	// Retries is the number of times a failed transfer is retried,
+	// DefaultRetries if 0. A negative value disables retries.
+	Retries int
+
+	// Backoff is the wait before the first retry, DefaultBackoff if 0,
+	// doubled for each further retry up to MaxBackoff
+	Backoff time.Duration
+
+	// MaxBackoff caps the wait between retries, DefaultMaxBackoff if 0
+	MaxBackoff time.Duration
+
+	// Progress, if set, receives the bytes downloaded per file,
+	// the completed files and every failed attempt
+	Progress Progress
+
+	// Logger, if set, receives a record of each transfer and failed attempt
+	Logger *slog.Logger
+}
+
+// Download is a file for a Downloader to fetch
+type Download struct {
+	URL  string
+	Path string
+
+	// Mirrors are other URLs of the same file, tried in turn when
+	// a transfer fails with an error worth retrying
+	Mirrors []string
+
+	// Size is the expected size in bytes, unknown if 0. It is checked
+	// once the file is complete, unless a Checksum is given.
+	Size int64
+
+	// Checksum, if set, is the expected hex encoded digest of the file,
+	// computed with ChecksumType (one of md5, sha1, sha256 or sha512)
+	Checksum     string
+	ChecksumType string
+}
+
+// WithDownloader sets the Downloader with which a RemoteFinder fetches the
+// RPMs, by default one with the Finder's concurrency. It has no effect
+// on a Finder of a local directory.
+func WithDownloader(d *Downloader) FinderOption {
+	return func(f *Finder) {
+		f.downloader = d
+	}
+}
+
+// downloadError is a response to a download request other than the
+// expected 200 OK or 206 Partial Content
+type downloadError struct {
+	url    string
+	status string
+	code   int
+}
+
+func (e *downloadError) Error() string {
+	return fmt.Sprintf("failed to download %s (%s)", e.url, e.status)
+}
+
+// corruptError is a complete download of the wrong size or checksum. It
+// is only worth retrying if the download resumed a partial file, which
+// may have been left by a transfer of another version of the file.
+type corruptError struct {
+	err     error
+	resumed bool
+}
+
+func (e *corruptError) Error() string { return e.err.Error() }
+func (e *corruptError) Unwrap() error { return e.err }
+
+// temporary indicates if the request may succeed when retried
+func (e *downloadError) temporary() bool {
+	switch e.code {
+	case http.StatusRequestTimeout, http.StatusRequestedRangeNotSatisfiable, http.StatusTooManyRequests:
+		return true
+	}
+
+	return e.code >= 500
+}
+
+// FetchAll fetches the files, up to Concurrency in parallel. The first
+// failure, once retries are exhausted, stops the remaining transfers and
+// is returned.
+func (d *Downloader) FetchAll(ctx context.Context, downloads []Download) error {
+	return forEach(ctx, len(downloads), d.Concurrency, func(i int) error {
+		return d.Fetch(ctx, downloads[i])
+	})
+}
+
+// Fetch downloads the file at dl.URL to dl.Path, retrying on network
+// errors, on server errors and on a resumed file of the wrong size or
+// checksum. Each retry first tries the Mirrors in turn, without waiting.
+// Other responses than 200 OK or 206 Partial Content, such as 404 Not
+// Found, fail at once, as do local errors such as a destination that
+// cannot be written. A checksum mismatch is reported wrapping
+// ErrChecksumMismatch. The file only appears at dl.Path once complete
+// and verified.
+func (d *Downloader) Fetch(ctx context.Context, dl Download) error {
+	if dl.Checksum != "" {
+		if _, err := newHash(dl.ChecksumType); err != nil {
+			return fmt.Errorf("%s: %w", dl.URL, err)
+		}
+	}
+
+	log := orDiscard(d.Logger)
+	urls := append([]string{dl.URL}, dl.Mirrors...)
+	for attempt := 0; ; attempt++ {
+		var err error
+		for _, url := range urls {
+			start := time.Now()
+			err = d.attempt(ctx, url, dl)
+			if err == nil {
+				log.Info("downloaded", "url", url, "path", dl.Path, "elapsed", time.Since(start))
+				report(d.Progress, Event{Kind: EventDownloaded, Path: dl.Path, URL: url})
+				return nil
+			}
+
+			log.Warn("download attempt failed", "url", url, "attempt", attempt+1, "error", err)
+			report(d.Progress, Event{Kind: EventError, Path: dl.Path, URL: url, Err: err})
+			if !d.retryable(ctx, err) {
+				return err
+			}
+		}
+
+		if attempt >= d.retries() {
+			return err
+		}
+
+		select {
+		case <-time.After(d.backoff(attempt)):
+		case <-ctx.Done():
+			return interrupted(ctx)
+		}
+	}
+}
+
+// attempt fetches the file once from url,
+// resuming from the partial file if any
+func (d *Downloader) attempt(ctx context.Context, url string, dl Download) error {
+	part := dl.Path + ".part"
+	f, err := os.OpenFile(part, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	if dl.Size > 0 && offset > dl.Size {
+		if offset, err = restart(f); err != nil {
+			return err
+		}
+	}
+
+	resumed := offset > 0
+	if resumed {
+		orDiscard(d.Logger).Debug("resuming download", "url", url, "offset", offset)
+	}
+	if dl.Size == 0 || offset < dl.Size {
+		if resumed, err = d.transfer(ctx, url, dl, f, offset); err != nil {
+			return err
+		}
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := verifyDownload(part, url, dl); err != nil {
+		os.Remove(part)
+		return &corruptError{err: err, resumed: resumed}
+	}
+
+	return os.Rename(part, dl.Path)
+}
+
+// transfer appends the content of url from offset to f, and indicates if
+// it resumed the partial file. If the server ignores the range and sends
+// the whole file, f is rewritten from the start.
+func (d *Downloader) transfer(ctx context.Context, url string, dl Download, f *os.File, offset int64) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := d.client().Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to download %s (%w)", url, err)
+	}
+	defer resp.Body.Close()
+
+	failure := &downloadError{url: url, status: resp.Status, code: resp.StatusCode}
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if offset == 0 {
+			return false, failure
+		}
+	case http.StatusOK:
+		if offset, err = restart(f); err != nil {
+			return false, err
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file does not match the remote one, start afresh
+		if _, err := restart(f); err != nil {
+			return false, err
+		}
+		return false, failure
+	default:
+		return false, failure
+	}
+
+	var dst io.Writer = f
+	if d.Progress != nil {
+		total := dl.Size
+		if total == 0 && resp.ContentLength > 0 {
+			total = offset + resp.ContentLength
+		}
+		event := Event{Kind: EventDownloading, Path: dl.Path, URL: url, Bytes: offset, Total: total}
+		dst = io.MultiWriter(f, &progressWriter{progress: d.Progress, event: event})
+	}
+
+	if _, err := io.Copy(dst, resp.Body); err != nil {
+		return false, fmt.Errorf("failed to download %s (%w)", url, err)
+	}
+
+	return offset > 0, nil
+}
+
+// restart truncates the partial file to write it from the start
+func restart(f *os.File) (int64, error) {
+	if err := f.Truncate(0); err != nil {
+		return 0, err
+	}
+
+	return f.Seek(0, io.SeekStart)
+}
+
+// verifyDownload checks the complete file at path
+// against the expected size and checksum
+func verifyDownload(path, url string, dl Download) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if dl.Checksum == "" {
+		if dl.Size > 0 && fi.Size() != dl.Size {
+			return fmt.Errorf("failed to download %s (got %d bytes, expected %d)", url, fi.Size(), dl.Size)
+		}
+		return nil
+	}
+
+	got, err := (&RPM{Path: path}).Checksum(dl.ChecksumType)
+	if err != nil {
+		return err
+	}
+
+	if want := strings.TrimSpace(dl.Checksum); got != want {
+		return fmt.Errorf("%s: %w (%s %s, expected %s)", url, ErrChecksumMismatch, dl.ChecksumType, got, want)
+	}
+
+	return nil
+}
+
+// retryable indicates if a failed attempt is worth retrying: on network
+// errors, truncated transfers, server errors and corrupt resumed files.
+// Local errors, such as failing to create or write the partial file, are
+// not retried, nor are errors not known to be transient.
+func (d *Downloader) retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var de *downloadError
+	if errors.As(err, &de) {
+		return de.temporary()
+	}
+
+	var ce *corruptError
+	if errors.As(err, &ce) {
+		return ce.resumed
+	}
+
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		return false
+	}
+
+	var ne net.Error
+	return errors.As(err, &ne) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET)
+}
+
+func (d *Downloader) client() *http.Client {
+	if d.Client == nil {
+		return http.DefaultClient
+	}
+
+	return d.Client
+}
+
+func (d *Downloader) retries() int {
+	switch {
+	case d.Retries < 0:
+		return 0
+	case d.Retries == 0:
+		return DefaultRetries
+	}
+
+	return d.Retries
+}
+
+// backoff returns the wait before the retry following the given attempt
+func (d *Downloader) backoff(attempt int) time.Duration {
+	wait, limit := d.Backoff, d.MaxBackoff
+	if wait <= 0 {
+		wait = DefaultBackoff
+	}
+	if limit <= 0 {
+		limit = DefaultMaxBackoff
+	}
+
+	for i := 0; i < attempt && wait < limit; i++ {
+		wait *= 2
+	}
+
+	return min(wait, limit)
+}
@@ -0,0 +1,227 @@
+package rpm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyServer serves content at every path, failing as told by the
+// successive entries of script: "503" answers 503, "cut" sends half of
+// the requested range then drops the connection, "full" ignores any
+// range. Once the script is exhausted, ranges are honoured.
+type flakyServer struct {
+	content []byte
+
+	mu       sync.Mutex
+	script   []string
+	requests []string
+}
+
+func (s *flakyServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	step := ""
+	if len(s.script) > 0 {
+		step, s.script = s.script[0], s.script[1:]
+	}
+	s.requests = append(s.requests, req.Header.Get("Range"))
+	s.mu.Unlock()
+
+	var start int
+	if step != "full" {
+		fmt.Sscanf(req.Header.Get("Range"), "bytes=%d-", &start)
+	}
+	body := s.content[start:]
+
+	switch step {
+	case "503":
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	case "cut":
+		w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(body[:len(body)/2])
+		return
+	}
+
+	w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+	if start > 0 {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(s.content)-1, len(s.content)))
+		w.WriteHeader(http.StatusPartialContent)
+	}
+	w.Write(body)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDownloaderRetryAndResume(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 100))
+	flaky := &flakyServer{content: content, script: []string{"503", "cut"}}
+	srv := httptest.NewServer(flaky)
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "Athena.rpm")
+	d := &Downloader{Backoff: time.Millisecond}
+	err := d.Fetch(context.Background(), Download{
+		URL:          srv.URL + "/Athena.rpm",
+		Path:         path,
+		Checksum:     sha256Hex(content),
+		ChecksumType: "sha256",
+	})
+	if err != nil {
+		t.Fatalf("Fetch should succeed after retries, got %v", err)
+	}
+
+	if got, _ := os.ReadFile(path); string(got) != string(content) {
+		t.Errorf("Fetch should write the whole file, got %d bytes", len(got))
+	}
+
+	if len(flaky.requests) != 3 || flaky.requests[2] != "bytes=500-" {
+		t.Errorf("Fetch should resume from the partial file, got requests %q", flaky.requests)
+	}
+
+	if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+		t.Errorf("Fetch should not leave the partial file behind, got %v", err)
+	}
+}
+
+func TestDownloaderRangeIgnored(t *testing.T) {
+	content := []byte("the whole rpm")
+	srv := httptest.NewServer(&flakyServer{content: content, script: []string{"full"}})
+	defer srv.Close()
+
+	// A partial file left by an earlier run
+	path := filepath.Join(t.TempDir(), "Athena.rpm")
+	os.WriteFile(path+".part", []byte("the"), 0644)
+
+	if err := (&Downloader{}).Fetch(context.Background(), Download{URL: srv.URL, Path: path, Size: int64(len(content))}); err != nil {
+		t.Fatalf("Fetch failed (%v)", err)
+	}
+
+	if got, _ := os.ReadFile(path); string(got) != string(content) {
+		t.Errorf("Fetch should rewrite the file when the range is ignored, got %q", got)
+	}
+}
+
+func TestDownloaderFailures(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		if strings.HasSuffix(req.URL.Path, "missing.rpm") {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write([]byte("corrupt"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	d := &Downloader{Backoff: time.Millisecond}
+
+	var de *downloadError
+	err := d.Fetch(context.Background(), Download{URL: srv.URL + "/missing.rpm", Path: filepath.Join(dir, "missing.rpm")})
+	if !errors.As(err, &de) || de.code != http.StatusNotFound || calls != 1 {
+		t.Errorf("Fetch should fail at once on 404, got %v after %d calls", err, calls)
+	}
+
+	calls = 0
+	path := filepath.Join(dir, "corrupt.rpm")
+	err = d.Fetch(context.Background(), Download{URL: srv.URL + "/corrupt.rpm", Path: path, Checksum: sha256Hex([]byte("intact")), ChecksumType: "sha256"})
+	if !errors.Is(err, ErrChecksumMismatch) || calls != 1 {
+		t.Errorf("Fetch should fail with ErrChecksumMismatch without retrying, got %v after %d calls", err, calls)
+	}
+
+	for _, p := range []string{path, path + ".part"} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("Fetch should leave no %s behind, got %v", filepath.Base(p), err)
+		}
+	}
+
+	if err := d.Fetch(context.Background(), Download{URL: srv.URL, Path: path, Checksum: "x", ChecksumType: "crc32"}); err == nil {
+		t.Errorf("Fetch should reject an unsupported checksum type, got nil")
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := d.FetchAll(cancelled, []Download{{URL: srv.URL, Path: path}}); !errors.Is(err, context.Canceled) {
+		t.Errorf("FetchAll should return context.Canceled, got %v", err)
+	}
+}
+
+func TestDownloaderLocalFailure(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.Write([]byte("content"))
+	}))
+	defer srv.Close()
+
+	// The destination directory is a file, which cannot be written to
+	notDir := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(notDir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var attempts int
+	d := &Downloader{Backoff: time.Second, Progress: ProgressFunc(func(e Event) {
+		if e.Kind == EventError {
+			attempts++
+		}
+	})}
+	dl := Download{URL: srv.URL + "/a.rpm", Mirrors: []string{srv.URL + "/mirror/a.rpm"}, Path: filepath.Join(notDir, "a.rpm")}
+
+	var pe *fs.PathError
+	if err := d.Fetch(context.Background(), dl); !errors.As(err, &pe) || attempts != 1 || calls != 0 {
+		t.Errorf("Fetch should fail at once on a local error, got %v after %d attempts", err, attempts)
+	}
+}
+
+func TestDownloaderFetchAll(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(req.URL.Path))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	var downloads []Download
+	for _, name := range []string{"a.rpm", "b.rpm", "c.rpm"} {
+		downloads = append(downloads, Download{URL: srv.URL + "/" + name, Path: filepath.Join(dir, name)})
+	}
+
+	if err := (&Downloader{Concurrency: 2}).FetchAll(context.Background(), downloads); err != nil {
+		t.Fatalf("FetchAll failed (%v)", err)
+	}
+
+	for _, dl := range downloads {
+		if got, _ := os.ReadFile(dl.Path); string(got) != "/"+filepath.Base(dl.Path) {
+			t.Errorf("FetchAll should download %s, got %q", dl.URL, got)
+		}
+	}
+}
+
+func TestDownloaderBackoff(t *testing.T) {
+	d := &Downloader{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	for attempt, expect := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if got := d.backoff(attempt); got != expect {
+			t.Errorf("backoff after attempt %d should be %v, got %v", attempt, expect, got)
+		}
+	}
+
+	if got := (&Downloader{Retries: -1}).retries(); got != 0 {
+		t.Errorf("negative Retries should disable retries, got %d", got)
+	}
+}
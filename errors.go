@@ -0,0 +1,61 @@
+package rpm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoTopRPM is returned, wrapped, when no top RPM
+// matches the requested project and platform
+var ErrNoTopRPM = errors.New("no top RPM found")
+
+// ErrZeroSizeRPM is returned when some of the RPMs found are empty files
+type ErrZeroSizeRPM struct {
+	Paths []string
+}
+
+func (e *ErrZeroSizeRPM) Error() string {
+	if len(e.Paths) == 1 {
+		return fmt.Sprintf("%s: RPM has zero size", e.Paths[0])
+	}
+
+	return fmt.Sprintf("%d RPMs have zero size:\n%s", len(e.Paths), strings.Join(e.Paths, "\n"))
+}
+
+// ErrMissingDependency is returned in strict mode, once per dependency
+// that is neither found nor assumed present, see WithStrict. Its message
+// is the bare capability name, one per line under the strict mode error.
+type ErrMissingDependency struct {
+	Name string
+}
+
+func (e *ErrMissingDependency) Error() string {
+	return e.Name
+}
+
+// zeroSize returns an *ErrZeroSizeRPM for the empty RPMs, if any
+func (r *RPMs) zeroSize() error {
+	var paths []string
+	for _, rr := range *r {
+		if rr.Size == 0 {
+			paths = append(paths, rr.Path)
+		}
+	}
+
+	if len(paths) == 0 {
+		return nil
+	}
+
+	return &ErrZeroSizeRPM{Paths: paths}
+}
+
+// missingDependencies joins an *ErrMissingDependency for each name
+func missingDependencies(names []string) error {
+	errs := make([]error, len(names))
+	for i, name := range names {
+		errs[i] = &ErrMissingDependency{Name: name}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,60 @@
+package rpm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestErrNoTopRPM(t *testing.T) {
+	dir := t.TempDir()
+	writeRPM(t, dir, "Athena_22.0.1_x86_64.rpm", fixtureRPM{Name: "Athena", Version: "22.0.1", Release: "1"})
+
+	if _, err := NewFinder(dir).Find("AthSimulation", "x86_64"); !errors.Is(err, ErrNoTopRPM) {
+		t.Errorf("Find should return ErrNoTopRPM, got %v", err)
+	}
+
+	if _, err := NewFinder(dir, WithSelector(SelectVersion("21.0"))).Find("Athena", "x86_64"); !errors.Is(err, ErrNoTopRPM) {
+		t.Errorf("Find should return ErrNoTopRPM for a version not found, got %v", err)
+	}
+
+	if _, err := NewFinder(dir).FindFallback("Athena", []string{"aarch64", "ppc64le"}); !errors.Is(err, ErrNoTopRPM) {
+		t.Errorf("FindFallback should return ErrNoTopRPM, got %v", err)
+	}
+}
+
+func TestErrZeroSizeRPM(t *testing.T) {
+	dir := t.TempDir()
+	writeRPM(t, dir, "Athena_22.0.1_x86_64.rpm", fixtureRPM{
+		Name: "Athena", Version: "22.0.1", Release: "1",
+		Requires: []fixtureDep{{Name: "empty.rpm"}},
+	})
+	os.WriteFile(filepath.Join(dir, "empty.rpm"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, "Empty_1.0_x86_64.rpm"), nil, 0644)
+
+	var zero *ErrZeroSizeRPM
+	_, err := NewFinder(dir).Find("Athena", "x86_64")
+	if !errors.As(err, &zero) || len(zero.Paths) != 1 || zero.Paths[0] != filepath.Join(dir, "empty.rpm") {
+		t.Errorf("Find should return an ErrZeroSizeRPM for empty.rpm, got %v", err)
+	}
+
+	_, err = NewFinder(dir).Find("Empty", "x86_64")
+	if !errors.As(err, &zero) || len(zero.Paths) != 1 || zero.Paths[0] != filepath.Join(dir, "Empty_1.0_x86_64.rpm") {
+		t.Errorf("Find should return an ErrZeroSizeRPM for the top RPM, got %v", err)
+	}
+}
+
+func TestErrMissingDependency(t *testing.T) {
+	dir := t.TempDir()
+	writeRPM(t, dir, "Athena_22.0.1_x86_64.rpm", fixtureRPM{
+		Name: "Athena", Version: "22.0.1", Release: "1",
+		Requires: []fixtureDep{{Name: "libGaudi.so"}, {Name: "libc.so.6"}},
+	})
+
+	var missing *ErrMissingDependency
+	_, err := NewFinder(dir, WithStrict()).Find("Athena", "x86_64")
+	if !errors.As(err, &missing) || missing.Name != "libGaudi.so" {
+		t.Errorf("Find should return an ErrMissingDependency for libGaudi.so, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package rpm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// FallbackResult is the outcome of a FindFallback resolution
+type FallbackResult struct {
+	RPMs     *RPMs
+	Platform string
+
+	// Fallback indicates that Platform is not the preferred
+	// (i.e. first) platform that was requested
+	Fallback bool
+}
+
+// FindFallback finds RPMs for the first of the given platforms, in order
+// of preference, for which a top RPM exists. The result records which
+// platform was actually used.
+func (f *Finder) FindFallback(project string, platforms []string) (*FallbackResult, error) {
+	return f.FindFallbackContext(context.Background(), project, platforms)
+}
+
+// FindFallbackContext is like FindFallback, but gives up as soon as ctx is done
+func (f *Finder) FindFallbackContext(ctx context.Context, project string, platforms []string) (*FallbackResult, error) {
+	result, err := f.findFallback(ctx, project, platforms)
+	if err != nil {
+		_, err = f.reportFound(nil, err)
+		return nil, err
+	}
+
+	f.reportFound(result.RPMs, nil)
+	return result, nil
+}
+
+func (f *Finder) findFallback(ctx context.Context, project string, platforms []string) (*FallbackResult, error) {
+	if len(platforms) == 0 {
+		return nil, fmt.Errorf("no candidate platforms given for project %s", project)
+	}
+
+	var misses []error
+	for i, platform := range platforms {
+		if err := interrupted(ctx); err != nil {
+			return nil, err
+		}
+
+		path, err := f.findTopRPM(f.files().Glob, project, platform)
+		if err != nil {
+			misses = append(misses, err)
+			continue
+		}
+
+		rpms, err := f.resolve(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+
+		return &FallbackResult{RPMs: rpms, Platform: platform, Fallback: i > 0}, nil
+	}
+
+	return nil, fmt.Errorf(
+		"no top RPM found for any of %d platforms:\n%w",
+		len(platforms),
+		errors.Join(misses...),
+	)
+}
@@ -0,0 +1,48 @@
+package rpm
+
+import (
+	"testing"
+)
+
+func TestFindFallback(t *testing.T) {
+	dir := t.TempDir()
+	writeRPM(t, dir, "project_1.0_el8.rpm", fixtureRPM{Name: "project", Version: "1.0", Release: "1"})
+
+	res, err := NewFinder(dir).FindFallback("project", []string{"el9", "el8"})
+	if err != nil {
+		t.Fatalf("FindFallback failed (%v)", err)
+	}
+
+	if res.Platform != "el8" || !res.Fallback {
+		t.Errorf("FindFallback should have fallen back to el8, got %s (fallback=%t)", res.Platform, res.Fallback)
+	}
+
+	if len(*res.RPMs) != 1 {
+		t.Errorf("FindFallback should return 1 RPM, got %d", len(*res.RPMs))
+	}
+}
+
+func TestFindFallbackPreferred(t *testing.T) {
+	dir := t.TempDir()
+	writeRPM(t, dir, "project_1.0_el8.rpm", fixtureRPM{Name: "project", Version: "1.0", Release: "1"})
+	writeRPM(t, dir, "project_1.0_el9.rpm", fixtureRPM{Name: "project", Version: "1.0", Release: "1"})
+
+	res, err := NewFinder(dir).FindFallback("project", []string{"el9", "el8"})
+	if err != nil {
+		t.Fatalf("FindFallback failed (%v)", err)
+	}
+
+	if res.Platform != "el9" || res.Fallback {
+		t.Errorf("FindFallback should have used el9, got %s (fallback=%t)", res.Platform, res.Fallback)
+	}
+}
+
+func TestFindFallbackNoMatch(t *testing.T) {
+	if _, err := NewFinder(t.TempDir()).FindFallback("project", []string{"el9", "el8"}); err == nil {
+		t.Errorf("FindFallback should fail when no platform matches, got nil")
+	}
+
+	if _, err := NewFinder(t.TempDir()).FindFallback("project", nil); err == nil {
+		t.Errorf("FindFallback should fail without platforms, got nil")
+	}
+}
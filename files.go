@@ -0,0 +1,166 @@
+package rpm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/cavaliergopher/cpio"
+	"github.com/cavaliergopher/rpm"
+)
+
+// tagFileDigestAlgo is the header tag of the algorithm of the file digests
+const tagFileDigestAlgo = 5011
+
+// errExtracted stops the payload walk of ExtractFile
+var errExtracted = errors.New("file extracted")
+
+// FileFlags are the rpmspec attributes of a packaged file
+type FileFlags int64
+
+// Config indicates a %config file
+func (f FileFlags) Config() bool { return f&rpm.FileFlagConfig != 0 }
+
+// Doc indicates a %doc file
+func (f FileFlags) Doc() bool { return f&rpm.FileFlagDoc != 0 }
+
+// Ghost indicates a %ghost file, owned but not shipped by the package
+func (f FileFlags) Ghost() bool { return f&rpm.FileFlagGhost != 0 }
+
+// FileInfo describes the ownership and permissions
+// that the RPM sets on one of the files it installs
+type FileInfo struct {
+	Path  string
+	Mode  os.FileMode
+	User  string
+	Group string
+	Flags FileFlags
+
+	// Size is the size of the file in bytes, and Digest the hex encoded
+	// digest of its content, computed with DigestAlgo. Digest is empty
+	// for anything but regular files.
+	Size       int64
+	Digest     string
+	DigestAlgo string
+}
+
+// FileInfos returns the ownership and permissions of each file
+// installed by the RPM, as read from the header file index
+func (r *RPM) FileInfos() ([]FileInfo, error) {
+	p, err := r.header()
+	if err != nil {
+		return nil, err
+	}
+
+	algo := "md5"
+	if id := p.Header.GetTag(tagFileDigestAlgo).Int64(); id != 0 {
+		if algo = payloadDigestAlgos[id]; algo == "" {
+			algo = fmt.Sprintf("unknown(%d)", id)
+		}
+	}
+
+	files := p.Files()
+	infos := make([]FileInfo, 0, len(files))
+	for _, f := range files {
+		infos = append(infos, FileInfo{
+			Path:       f.Name(),
+			Mode:       f.Mode(),
+			User:       f.Owner(),
+			Group:      f.Group(),
+			Flags:      FileFlags(f.Flags()),
+			Size:       f.Size(),
+			Digest:     f.Digest(),
+			DigestAlgo: algo,
+		})
+	}
+
+	return infos, nil
+}
+
+// Files returns the files shipped in the payload of the RPM, that is its
+// FileInfos but for the %ghost files, which the package owns only
+func (r *RPM) Files() ([]FileInfo, error) {
+	infos, err := r.FileInfos()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []FileInfo
+	for _, info := range infos {
+		if !info.Flags.Ghost() {
+			files = append(files, info)
+		}
+	}
+
+	return files, nil
+}
+
+// ExtractFile writes the content of the regular file of the given
+// packaged path, e.g. /opt/atlas/setup.sh, from the payload of the RPM
+// to w. It fails with an error wrapping fs.ErrNotExist if the payload
+// holds no regular file of that path.
+func (r *RPM) ExtractFile(name string, w io.Writer) error {
+	name = payloadPath(name)
+
+	// Hard linked files carry their content on the last of their entries
+	inode := int64(-1)
+	err := r.walkPayload(func(p *rpm.Package, hdr *cpio.Header, body io.Reader) error {
+		if payloadPath(hdr.Name) != name && (hdr.Inode != inode || hdr.Size == 0) {
+			return nil
+		}
+
+		if !hdr.FileInfo().Mode().IsRegular() {
+			return fmt.Errorf("%s: %s is not a regular file", r.Name(), name)
+		}
+
+		if hdr.Links > 1 && hdr.Size == 0 {
+			inode = hdr.Inode
+			return nil
+		}
+
+		if _, err := io.Copy(w, body); err != nil {
+			return fmt.Errorf("failed to extract %s from %s (%w)", name, r.Name(), err)
+		}
+		return errExtracted
+	})
+	switch {
+	case errors.Is(err, errExtracted):
+		return nil
+	case err != nil:
+		return err
+	case inode >= 0:
+		// An empty hard linked file
+		return nil
+	}
+
+	return fmt.Errorf("%s: %s not in payload (%w)", r.Name(), name, fs.ErrNotExist)
+}
+
+// ExtractTo extracts the payload entries of the RPM matching any of the
+// patterns below dir, at their packaged path, as an Installer with these
+// Include patterns does. With no patterns, the whole payload is extracted.
+// It fails with an error wrapping fs.ErrNotExist if nothing matches.
+func (r *RPM) ExtractTo(dir string, patterns ...string) error {
+	var written bytes.Buffer
+	in := &Installer{Include: patterns, Manifest: &written}
+	if err := in.Install(context.Background(), &RPMs{r}, dir); err != nil {
+		return err
+	}
+
+	if written.Len() == 0 {
+		return fmt.Errorf("%s: no payload entry matches %q (%w)", r.Name(), patterns, fs.ErrNotExist)
+	}
+
+	return nil
+}
+
+// payloadPath returns the name of a payload entry as an absolute, clean path
+func payloadPath(name string) string {
+	return path.Clean("/" + strings.TrimPrefix(name, "."))
+}
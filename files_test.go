@@ -0,0 +1,117 @@
+package rpm
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cavaliergopher/rpm"
+)
+
+func TestRPMFileInfos(t *testing.T) {
+	path := writeRPM(t, t.TempDir(), "foo.rpm", fixtureRPM{
+		Name: "foo", Version: "1", Release: "1",
+		Files: []fixtureFile{
+			{Path: "/usr/bin/foo", Mode: 0104755, User: "root", Group: "wheel", Size: 42, Digest: "d41d8c"},
+			{Path: "/etc/foo.conf", Mode: 0100666, User: "foo", Group: "foo", Flags: rpm.FileFlagConfig},
+			{Path: "/var/log/foo.log", Mode: 0100644, Flags: rpm.FileFlagGhost},
+		},
+	})
+
+	infos, err := (&RPM{Path: path}).FileInfos()
+	if err != nil {
+		t.Fatalf("FileInfos failed (%v)", err)
+	}
+
+	if len(infos) != 3 {
+		t.Fatalf("FileInfos should return 3 files, got %d", len(infos))
+	}
+
+	foo := infos[0]
+	if foo.Path != "/usr/bin/foo" || foo.Mode&os.ModeSetuid == 0 || foo.User != "root" || foo.Group != "wheel" {
+		t.Errorf("FileInfos returned a bad setuid entry %+v", foo)
+	}
+
+	if foo.Size != 42 || foo.Digest != "d41d8c" || foo.DigestAlgo != "md5" {
+		t.Errorf("FileInfos returned a bad size or digest %+v", foo)
+	}
+
+	conf := infos[1]
+	if !conf.Flags.Config() || conf.Flags.Doc() || conf.Mode.Perm() != 0666 {
+		t.Errorf("FileInfos returned a bad config entry %+v", conf)
+	}
+
+	if !infos[2].Flags.Ghost() {
+		t.Errorf("FileInfos should flag %s as ghost", infos[2].Path)
+	}
+
+	files, err := (&RPM{Path: path}).Files()
+	if err != nil || len(files) != 2 || files[1].Path != "/etc/foo.conf" {
+		t.Errorf("Files should return all but the ghost file, got %+v (%v)", files, err)
+	}
+}
+
+func TestRPMExtractFile(t *testing.T) {
+	r := &RPM{Path: writeRPM(t, t.TempDir(), "Athena.rpm", fixtureRPM{
+		Name: "Athena", Version: "1", Release: "1",
+		Compression: "gzip",
+		Payload:     cpioPayload(t, "gzip", installerEntries),
+	})}
+
+	for name, expect := range map[string]string{
+		"/opt/atlas/setup.sh":  "export ATHENA=1\n",
+		"./opt/atlas/lib/a.so": "ELF",
+		"opt/atlas/lib/b.so":   "ELF",
+	} {
+		var buf bytes.Buffer
+		if err := r.ExtractFile(name, &buf); err != nil || buf.String() != expect {
+			t.Errorf("ExtractFile(%s) should write %q, got %q (%v)", name, expect, buf.String(), err)
+		}
+	}
+
+	if err := r.ExtractFile("/opt/atlas/missing.sh", &bytes.Buffer{}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ExtractFile should fail with fs.ErrNotExist for a missing file, got %v", err)
+	}
+
+	if err := r.ExtractFile("/opt/atlas/setup-link.sh", &bytes.Buffer{}); err == nil {
+		t.Errorf("ExtractFile should fail for a symlink")
+	}
+}
+
+func TestRPMExtractTo(t *testing.T) {
+	r := &RPM{Path: writeRPM(t, t.TempDir(), "Athena.rpm", fixtureRPM{
+		Name: "Athena", Version: "1", Release: "1",
+		Compression: "gzip",
+		Payload:     cpioPayload(t, "gzip", installerEntries),
+	})}
+
+	dir := t.TempDir()
+	if err := r.ExtractTo(dir, "setup.sh", "/opt/atlas/lib/a.so"); err != nil {
+		t.Fatalf("ExtractTo failed (%v)", err)
+	}
+
+	if content, err := os.ReadFile(filepath.Join(dir, "opt/atlas/setup.sh")); err != nil || string(content) != "export ATHENA=1\n" {
+		t.Errorf("ExtractTo should extract setup.sh, got %q (%v)", content, err)
+	}
+
+	if content, err := os.ReadFile(filepath.Join(dir, "opt/atlas/lib/a.so")); err != nil || string(content) != "ELF" {
+		t.Errorf("ExtractTo should extract the hard linked a.so, got %q (%v)", content, err)
+	}
+
+	for _, name := range []string{"opt/atlas/lib/b.so", "opt/atlas/bin/athena.py", "opt/atlas/setup-link.sh"} {
+		if _, err := os.Lstat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("ExtractTo should not extract %s, got %v", name, err)
+		}
+	}
+
+	if err := r.ExtractTo(t.TempDir(), "*.txt~"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ExtractTo should fail with fs.ErrNotExist when nothing matches, got %v", err)
+	}
+
+	if err := r.ExtractTo(t.TempDir(), "[setup"); err == nil {
+		t.Errorf("ExtractTo should fail on a bad pattern")
+	}
+}
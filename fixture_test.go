@@ -0,0 +1,307 @@
+package rpm
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// Header tag data types, as defined by the rpm file format
+const (
+	fixtureInt32       = 4
+	fixtureString      = 6
+	fixtureBinary      = 7
+	fixtureStringArray = 8
+)
+
+// fixtureDep is a dependency entry (requires, provides...) of a fixture RPM
+type fixtureDep struct {
+	Name    string
+	Flags   int
+	Version string
+}
+
+// fixtureRPM describes the content of a synthetic RPM file written by writeRPM
+type fixtureRPM struct {
+	Name      string
+	Version   string
+	Release   string
+	Epoch     int
+	Arch      string
+	Summary   string
+	License   string
+	Installed int
+	Requires  []fixtureDep
+	Provides  []fixtureDep
+	Conflicts []fixtureDep
+	Obsoletes []fixtureDep
+	Files     []fixtureFile
+
+	// Recommends and Suggests are the weak dependencies
+	Recommends []fixtureDep
+	Suggests   []fixtureDep
+
+	Payload []byte
+
+	// Compression and Prefixes, if set, are recorded in the header
+	Compression string
+	Prefixes    []string
+
+	// Signer, if set, signs the header and payload
+	Signer *openpgp.Entity
+
+	// BadDigest corrupts the payload digest recorded in the header
+	BadDigest bool
+}
+
+// fixtureFile is a file entry of a fixture RPM
+type fixtureFile struct {
+	Path   string
+	Mode   int
+	Size   int
+	Flags  int
+	User   string
+	Group  string
+	Digest string
+}
+
+type fixtureTag struct {
+	id    int
+	typ   int
+	value interface{}
+}
+
+// writeRPM writes a minimal, but valid, RPM file named filename into dir
+// and returns its path. Only the tags needed by this package are emitted.
+func writeRPM(t testing.TB, dir, filename string, spec fixtureRPM) string {
+	t.Helper()
+
+	path := filepath.Join(dir, filename)
+	if err := os.WriteFile(path, spec.bytes(), 0644); err != nil {
+		t.Fatalf("failed to write fixture RPM %s (%v)", path, err)
+	}
+
+	return path
+}
+
+func (s fixtureRPM) payload() []byte {
+	if s.Payload == nil {
+		return []byte(s.Name + "-payload")
+	}
+
+	return s.Payload
+}
+
+func (s fixtureRPM) bytes() []byte {
+	hdr := encodeHeader(s.tags())
+	payload := s.payload()
+	signed := append(append([]byte{}, hdr...), payload...)
+	md5sum := md5.Sum(signed)
+
+	sigTags := []fixtureTag{
+		{1000, fixtureInt32, []int32{int32(len(signed))}},
+		{1004, fixtureBinary, md5sum[:]},
+	}
+	if s.Signer != nil {
+		var sigBuf bytes.Buffer
+		if err := openpgp.DetachSign(&sigBuf, s.Signer, bytes.NewReader(signed), nil); err != nil {
+			panic(err)
+		}
+		sigTags = append(sigTags, fixtureTag{1002, fixtureBinary, sigBuf.Bytes()})
+	}
+
+	sig := encodeHeader(sigTags)
+	if pad := len(sig) % 8; pad != 0 {
+		sig = append(sig, make([]byte, 8-pad)...)
+	}
+
+	var buf bytes.Buffer
+	buf.Write(encodeLead(s.Name))
+	buf.Write(sig)
+	buf.Write(hdr)
+	buf.Write(payload)
+	return buf.Bytes()
+}
+
+func (s fixtureRPM) tags() []fixtureTag {
+	arch := s.Arch
+	if arch == "" {
+		arch = "x86_64"
+	}
+
+	tags := []fixtureTag{
+		{1000, fixtureString, s.Name},
+		{1001, fixtureString, s.Version},
+		{1002, fixtureString, s.Release},
+		{1022, fixtureString, arch},
+	}
+	if s.Epoch != 0 {
+		tags = append(tags, fixtureTag{1003, fixtureInt32, []int32{int32(s.Epoch)}})
+	}
+	if s.Summary != "" {
+		tags = append(tags, fixtureTag{1004, fixtureString, s.Summary})
+	}
+	if s.Installed != 0 {
+		tags = append(tags, fixtureTag{1009, fixtureInt32, []int32{int32(s.Installed)}})
+	}
+	if s.License != "" {
+		tags = append(tags, fixtureTag{1014, fixtureString, s.License})
+	}
+
+	tags = append(tags, depTags(s.Provides, 1047, 1112, 1113)...)
+	tags = append(tags, depTags(s.Requires, 1049, 1048, 1050)...)
+	tags = append(tags, depTags(s.Conflicts, 1054, 1053, 1055)...)
+	tags = append(tags, depTags(s.Obsoletes, 1090, 1114, 1115)...)
+	tags = append(tags, depTags(s.Recommends, 5046, 5048, 5047)...)
+	tags = append(tags, depTags(s.Suggests, 5049, 5051, 5050)...)
+	tags = append(tags, fileTags(s.Files)...)
+	if len(s.Prefixes) > 0 {
+		tags = append(tags, fixtureTag{1098, fixtureStringArray, s.Prefixes})
+	}
+	if s.Compression != "" {
+		tags = append(tags,
+			fixtureTag{1124, fixtureString, "cpio"},
+			fixtureTag{1125, fixtureString, s.Compression},
+		)
+	}
+
+	digest := sha256.Sum256(s.payload())
+	if s.BadDigest {
+		digest[0]++
+	}
+	tags = append(tags,
+		fixtureTag{5092, fixtureStringArray, []string{hex.EncodeToString(digest[:])}},
+		fixtureTag{5093, fixtureInt32, []int32{8}},
+	)
+	return tags
+}
+
+func fileTags(files []fixtureFile) []fixtureTag {
+	if len(files) == 0 {
+		return nil
+	}
+
+	var (
+		dirs     []string
+		dirIndex = map[string]int32{}
+		n        = len(files)
+		indexes  = make([]int32, n)
+		names    = make([]string, n)
+		modes    = make([]int32, n)
+		sizes    = make([]int32, n)
+		mtimes   = make([]int32, n)
+		flags    = make([]int32, n)
+		users    = make([]string, n)
+		groups   = make([]string, n)
+		digests  = make([]string, n)
+		links    = make([]string, n)
+	)
+	for i, f := range files {
+		dir, name := filepath.Split(f.Path)
+		if _, ok := dirIndex[dir]; !ok {
+			dirIndex[dir] = int32(len(dirs))
+			dirs = append(dirs, dir)
+		}
+
+		indexes[i] = dirIndex[dir]
+		names[i] = name
+		modes[i] = int32(f.Mode)
+		sizes[i] = int32(f.Size)
+		flags[i] = int32(f.Flags)
+		users[i] = f.User
+		groups[i] = f.Group
+		digests[i] = f.Digest
+	}
+
+	return []fixtureTag{
+		{1028, fixtureInt32, sizes},
+		{1030, fixtureInt32, modes},
+		{1034, fixtureInt32, mtimes},
+		{1035, fixtureStringArray, digests},
+		{1036, fixtureStringArray, links},
+		{1037, fixtureInt32, flags},
+		{1039, fixtureStringArray, users},
+		{1040, fixtureStringArray, groups},
+		{1116, fixtureInt32, indexes},
+		{1117, fixtureStringArray, names},
+		{1118, fixtureStringArray, dirs},
+	}
+}
+
+func depTags(deps []fixtureDep, namesID, flagsID, versionsID int) []fixtureTag {
+	if len(deps) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(deps))
+	flags := make([]int32, len(deps))
+	versions := make([]string, len(deps))
+	for i, dep := range deps {
+		names[i] = dep.Name
+		flags[i] = int32(dep.Flags)
+		versions[i] = dep.Version
+	}
+
+	return []fixtureTag{
+		{namesID, fixtureStringArray, names},
+		{flagsID, fixtureInt32, flags},
+		{versionsID, fixtureStringArray, versions},
+	}
+}
+
+func encodeLead(name string) []byte {
+	lead := make([]byte, 96)
+	copy(lead, []byte{0xED, 0xAB, 0xEE, 0xDB, 3, 0})
+	copy(lead[10:76], name)
+	binary.BigEndian.PutUint16(lead[78:80], 5)
+	return lead
+}
+
+func encodeHeader(tags []fixtureTag) []byte {
+	var index, store bytes.Buffer
+	for _, tag := range tags {
+		count := 1
+		if tag.typ == fixtureInt32 {
+			for store.Len()%4 != 0 {
+				store.WriteByte(0)
+			}
+		}
+		offset := store.Len()
+
+		switch v := tag.value.(type) {
+		case string:
+			store.WriteString(v)
+			store.WriteByte(0)
+		case []string:
+			count = len(v)
+			for _, s := range v {
+				store.WriteString(s)
+				store.WriteByte(0)
+			}
+		case []int32:
+			count = len(v)
+			binary.Write(&store, binary.BigEndian, v)
+		case []byte:
+			count = len(v)
+			store.Write(v)
+		}
+
+		binary.Write(&index, binary.BigEndian, []int32{
+			int32(tag.id), int32(tag.typ), int32(offset), int32(count),
+		})
+	}
+
+	var buf bytes.Buffer
+	buf.Write([]byte{0x8E, 0xAD, 0xE8, 0x01, 0, 0, 0, 0})
+	binary.Write(&buf, binary.BigEndian, []int32{int32(len(tags)), int32(store.Len())})
+	buf.Write(index.Bytes())
+	buf.Write(store.Bytes())
+	return buf.Bytes()
+}
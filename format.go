@@ -0,0 +1,45 @@
+package rpm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// humanSize formats a number of bytes using decimal units, e.g. 12.3 MB
+func humanSize(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
+
+// PrettyString returns a human readable description of the RPM, such as
+// name-version-release.arch (12.3 MB). The file name is used in place
+// of the package NEVRA if the header cannot be read.
+func (r *RPM) PrettyString() string {
+	name := r.Name()
+	if p, err := r.header(); err == nil {
+		name = p.String()
+	}
+
+	return fmt.Sprintf("%s (%s)", name, humanSize(r.Size))
+}
+
+// PrettyString returns the human readable description
+// of each of the RPM instances, one per line
+func (r *RPMs) PrettyString() string {
+	var lines []string
+	for _, rr := range *r {
+		lines = append(lines, rr.PrettyString())
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,43 @@
+package rpm
+
+import (
+	"testing"
+)
+
+func TestHumanSize(t *testing.T) {
+	tests := map[int64]string{
+		0:          "0 B",
+		999:        "999 B",
+		1000:       "1.0 kB",
+		12345678:   "12.3 MB",
+		5000000000: "5.0 GB",
+	}
+
+	for n, expect := range tests {
+		if got := humanSize(n); got != expect {
+			t.Errorf("humanSize(%d) should return %s, got %s", n, expect, got)
+		}
+	}
+}
+
+func TestRPMPrettyString(t *testing.T) {
+	path := writeRPM(t, t.TempDir(), "foo.rpm", fixtureRPM{Name: "foo", Version: "1.2", Release: "3", Arch: "noarch"})
+	r, err := New(path)
+	if err != nil {
+		t.Fatalf("New failed (%v)", err)
+	}
+
+	r.Size = 12345678
+	expect := "foo-1.2-3.noarch (12.3 MB)"
+	if got := r.PrettyString(); got != expect {
+		t.Errorf("RPM PrettyString should return %s, got %s", expect, got)
+	}
+}
+
+func TestRPMsPrettyString(t *testing.T) {
+	// Inexistant paths, so expect the filename fallback
+	expect := "blop (12.3 kB)\nblop2 (0 B)"
+	if got := createRPMs().PrettyString(); got != expect {
+		t.Errorf("RPMs PrettyString should return %q, got %q", expect, got)
+	}
+}
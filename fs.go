@@ -0,0 +1,104 @@
+package rpm
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// fileSystem is the file system on which RPMs are looked up: that of the
+// OS, with OS paths, or an fs.FS, with slash separated paths
+type fileSystem interface {
+	Open(name string) (fs.File, error)
+	Stat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Glob(pattern string) ([]string, error)
+	WalkDir(root string, fn fs.WalkDirFunc) error
+	Join(elem ...string) string
+	Dir(name string) string
+}
+
+// WithFS makes the Finder look up RPMs in fsys rather than on the OS file
+// system, e.g. in an fstest.MapFS or an archive exposed as an fs.FS. The
+// Finder path is then a slash separated path within fsys, such as ".".
+// The RPMs found read their content from fsys too. This is not meant for
+// a RemoteFinder, which downloads to the OS file system.
+func WithFS(fsys fs.FS) FinderOption {
+	return func(f *Finder) {
+		f.fsys = ioFileSystem{fsys}
+	}
+}
+
+// osFileSystem is the file system of the OS
+type osFileSystem struct{}
+
+func (osFileSystem) Open(name string) (fs.File, error)          { return os.Open(name) }
+func (osFileSystem) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (osFileSystem) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+func (osFileSystem) Glob(pattern string) ([]string, error)      { return filepath.Glob(pattern) }
+func (osFileSystem) Join(elem ...string) string                 { return filepath.Join(elem...) }
+func (osFileSystem) Dir(name string) string                     { return filepath.Dir(name) }
+
+func (osFileSystem) WalkDir(root string, fn fs.WalkDirFunc) error {
+	return filepath.WalkDir(root, fn)
+}
+
+// ioFileSystem adapts an fs.FS
+type ioFileSystem struct {
+	fsys fs.FS
+}
+
+func (i ioFileSystem) Open(name string) (fs.File, error)          { return i.fsys.Open(name) }
+func (i ioFileSystem) Stat(name string) (fs.FileInfo, error)      { return fs.Stat(i.fsys, name) }
+func (i ioFileSystem) ReadDir(name string) ([]fs.DirEntry, error) { return fs.ReadDir(i.fsys, name) }
+func (i ioFileSystem) Glob(pattern string) ([]string, error)      { return fs.Glob(i.fsys, pattern) }
+func (i ioFileSystem) Join(elem ...string) string                 { return path.Join(elem...) }
+func (i ioFileSystem) Dir(name string) string                     { return path.Dir(name) }
+
+func (i ioFileSystem) WalkDir(root string, fn fs.WalkDirFunc) error {
+	return fs.WalkDir(i.fsys, root, fn)
+}
+
+// files returns the file system of the Finder, that of the OS by default
+func (f *Finder) files() fileSystem {
+	if f.fsys == nil {
+		return osFileSystem{}
+	}
+
+	return f.fsys
+}
+
+// files returns the file system holding the RPM, that of the OS by default
+func (r *RPM) files() fileSystem {
+	if r.fsys == nil {
+		return osFileSystem{}
+	}
+
+	return r.fsys
+}
+
+// open opens the RPM file for reading
+func (r *RPM) open() (fs.File, error) {
+	return r.files().Open(r.Path)
+}
+
+// ownFS returns the file system to record in an RPM, nil for that of the OS
+func ownFS(fsys fileSystem) fileSystem {
+	if _, isOS := fsys.(osFileSystem); isOS {
+		return nil
+	}
+
+	return fsys
+}
+
+// newRPM is like New, for an RPM file of the given file system
+func newRPM(fsys fileSystem, path string) (*RPM, error) {
+	fi, err := fsys.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rpm file size (%w)", err)
+	}
+
+	return &RPM{Path: path, Size: fi.Size(), fsys: ownFS(fsys)}, nil
+}
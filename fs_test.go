@@ -0,0 +1,81 @@
+package rpm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func createMapFS() fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for filename, spec := range map[string]fixtureRPM{
+		"Athena_22.0.1_x86_64.rpm": {Name: "Athena", Version: "22.0.1", Release: "1", Requires: []fixtureDep{{Name: "libGaudi.so"}}},
+		"Athena_22.0.2_x86_64.rpm": {Name: "Athena", Version: "22.0.2", Release: "1", Requires: []fixtureDep{{Name: "libGaudi.so"}, {Name: "tbb.rpm"}}},
+		"Gaudi-1.0.rpm":            {Name: "Gaudi", Version: "1.0", Release: "1", Provides: []fixtureDep{{Name: "libGaudi.so"}}},
+		"tbb.rpm":                  {Name: "tbb-legacy", Version: "2020", Release: "1"},
+	} {
+		fsys["nightly/"+filename] = &fstest.MapFile{Data: spec.bytes(), Mode: 0644}
+	}
+
+	return fsys
+}
+
+func TestFinderWithFS(t *testing.T) {
+	fsys := createMapFS()
+	f := NewFinder("nightly", WithFS(fsys))
+
+	rpms, err := f.Find("Athena", "x86_64")
+	if err != nil {
+		t.Fatalf("Find failed (%v)", err)
+	}
+
+	// Gaudi is matched by capability, tbb by filename
+	if got := strings.Join(rpms.Paths(), ","); got != "nightly/Athena_22.0.2_x86_64.rpm,nightly/Gaudi-1.0.rpm,nightly/tbb.rpm" {
+		t.Errorf("Find should return the paths within the FS, got %s", got)
+	}
+
+	if size := int64(len(fsys["nightly/Gaudi-1.0.rpm"].Data)); (*rpms)[1].Size != size {
+		t.Errorf("Find should set the size from the FS, got %d instead of %d", (*rpms)[1].Size, size)
+	}
+
+	if (*rpms)[1].PackageName() != "Gaudi" {
+		t.Errorf("RPMs found WithFS should read their header from the FS, got %q", (*rpms)[1].PackageName())
+	}
+
+	if _, err := (*rpms)[1].Checksum("sha256"); err != nil {
+		t.Errorf("RPMs found WithFS should read their content from the FS, got %v", err)
+	}
+
+	deps, err := (*rpms)[0].LocalDependencies()
+	if err != nil || len(*deps) != 2 {
+		t.Errorf("LocalDependencies should resolve within the FS, got %v (%v)", deps, err)
+	}
+
+	tops, err := f.FindAll("Athena", "x86_64")
+	if err != nil || len(tops) != 2 {
+		t.Errorf("FindAll should find both Athena builds, got %v (%v)", tops, err)
+	}
+
+	if _, err := NewFinder("nowhere", WithFS(fsys)).Find("Athena", "x86_64"); err == nil {
+		t.Errorf("Find should fail for a directory missing from the FS, got nil")
+	}
+}
+
+func TestRPMsCopyToFromFS(t *testing.T) {
+	rpms, err := NewFinder("nightly", WithFS(createMapFS())).Find("Athena", "x86_64")
+	if err != nil {
+		t.Fatalf("Find failed (%v)", err)
+	}
+
+	dir := t.TempDir()
+	results, err := rpms.CopyTo(dir, CopyOptions{UseHardlinks: true})
+	if err != nil || len(results) != 3 || results[0].Linked {
+		t.Fatalf("CopyTo should copy all RPMs out of the FS, got %v (%v)", results, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "Gaudi-1.0.rpm")); err != nil {
+		t.Errorf("CopyTo should write Gaudi-1.0.rpm (%v)", err)
+	}
+}
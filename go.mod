@@ -4,4 +4,10 @@ go 1.21.1
 
 require github.com/cavaliergopher/rpm v1.2.0
 
-require golang.org/x/crypto v0.0.0-20210921155107-089bfa567519 // indirect
+require (
+	github.com/cavaliergopher/cpio v1.0.1
+	github.com/klauspost/compress v1.17.11
+	github.com/ulikunitz/xz v0.5.17
+	golang.org/x/crypto v0.0.0-20210921155107-089bfa567519
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,62 @@
+package rpm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// gpgChecked indicates if the signatures of the
+// RPMs of the repo must be verified
+func (r Repo) gpgChecked() bool {
+	return r.GPGCheck != nil && *r.GPGCheck
+}
+
+// Keyring reads the armored public keys listed in the GPGKey field of the
+// repo, which holds file:// or http(s):// URLs separated by spaces or commas
+func (r Repo) Keyring(ctx context.Context) (openpgp.EntityList, error) {
+	urls := strings.Fields(strings.ReplaceAll(r.GPGKey, ",", " "))
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("no gpgkey set for repo %s", r.Label)
+	}
+
+	var keyring openpgp.EntityList
+	for _, keyURL := range urls {
+		keys, err := readKeys(ctx, keyURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gpgkey %s of repo %s (%w)", keyURL, r.Label, err)
+		}
+		keyring = append(keyring, keys...)
+	}
+
+	return keyring, nil
+}
+
+// readKeys reads the armored public keys at the given URL
+func readKeys(ctx context.Context, keyURL string) (openpgp.EntityList, error) {
+	u, err := url.Parse(keyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var src io.ReadCloser
+	switch u.Scheme {
+	case "file":
+		src, err = os.Open(u.Path)
+	case "http", "https":
+		src, err = getURL(ctx, keyURL)
+	default:
+		err = fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	return openpgp.ReadArmoredKeyRing(src)
+}
@@ -0,0 +1,92 @@
+package rpm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// writePublicKey writes the armored public key of e to a file in dir
+func writePublicKey(t *testing.T, dir string, e *openpgp.Entity) string {
+	t.Helper()
+
+	path := filepath.Join(dir, "RPM-GPG-KEY-"+e.PrimaryKey.KeyIdShortString())
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w, err := armor.Encode(f, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	return path
+}
+
+func TestRepoKeyring(t *testing.T) {
+	dir := t.TempDir()
+	a, b := newTestEntity(t, "a"), newTestEntity(t, "b")
+	pathA, pathB := writePublicKey(t, dir, a), writePublicKey(t, dir, b)
+
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer srv.Close()
+
+	repo := Repo{Label: "atlas", GPGKey: "file://" + pathA + ", " + srv.URL + "/" + filepath.Base(pathB)}
+	keyring, err := repo.Keyring(context.Background())
+	if err != nil {
+		t.Fatalf("Keyring failed (%v)", err)
+	}
+
+	if len(keyring) != 2 || len(keyring.KeysById(a.PrimaryKey.KeyId)) != 1 || len(keyring.KeysById(b.PrimaryKey.KeyId)) != 1 {
+		t.Errorf("Keyring should hold both keys, got %d entities", len(keyring))
+	}
+
+	for _, gpgkey := range []string{"", "ftp://keys/key", "file:///blip/blop"} {
+		if _, err := (Repo{Label: "atlas", GPGKey: gpgkey}).Keyring(context.Background()); err == nil {
+			t.Errorf("Keyring should fail for gpgkey %q, got nil", gpgkey)
+		}
+	}
+}
+
+func TestRemoteFinderGPGCheck(t *testing.T) {
+	signer := newTestEntity(t, "signer")
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "Packages"), 0755)
+	writeRepodata(t, dir, map[string]fixtureRPM{
+		"Athena_22.0.1_x86_64.rpm":        {Name: "Athena", Version: "22.0.1", Release: "1", Signer: signer, Requires: []fixtureDep{{Name: "Gaudi"}}},
+		"Gaudi-1.0.rpm":                   {Name: "Gaudi", Version: "1.0", Release: "1"},
+		"AthSimulation_22.0.1_x86_64.rpm": {Name: "AthSimulation", Version: "22.0.1", Release: "1", Signer: signer},
+	})
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer srv.Close()
+
+	check := true
+	repo := Repo{Label: "atlas", URL: srv.URL, GPGCheck: &check, GPGKey: "file://" + writePublicKey(t, t.TempDir(), signer)}
+
+	if _, err := NewRemoteFinder(repo, t.TempDir()).Find("AthSimulation", "x86_64"); err != nil {
+		t.Errorf("Find should accept signed RPMs, got %v", err)
+	}
+
+	_, err := NewRemoteFinder(repo, t.TempDir()).Find("Athena", "x86_64")
+	if err == nil || !strings.Contains(err.Error(), "Gaudi-1.0.rpm") {
+		t.Errorf("Find should reject the unsigned Gaudi RPM, got %v", err)
+	}
+
+	check = false
+	if _, err := NewRemoteFinder(repo, t.TempDir()).Find("Athena", "x86_64"); err != nil {
+		t.Errorf("Find should not verify signatures with gpgcheck disabled, got %v", err)
+	}
+}
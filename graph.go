@@ -0,0 +1,169 @@
+package rpm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DepGraph is the dependency graph of a set of RPMs, with an edge from
+// each RPM to each of the other RPMs of the set that provide its requires
+type DepGraph struct {
+	nodes RPMs
+	edges [][]int
+}
+
+// Edge is a dependency of one RPM on another of the graph
+type Edge struct {
+	From *RPM
+	To   *RPM
+}
+
+// BuildGraph reads the header of each of the RPMs
+// and builds their dependency graph
+func BuildGraph(rpms *RPMs) (*DepGraph, error) {
+	edges, err := rpms.requireEdges()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DepGraph{nodes: *rpms, edges: edges}, nil
+}
+
+// Nodes returns the RPMs of the graph
+func (g *DepGraph) Nodes() RPMs {
+	return g.nodes
+}
+
+// Edges returns the dependencies between the RPMs of the graph
+func (g *DepGraph) Edges() []Edge {
+	var edges []Edge
+	for i, deps := range g.edges {
+		for _, j := range deps {
+			edges = append(edges, Edge{From: g.nodes[i], To: g.nodes[j]})
+		}
+	}
+
+	return edges
+}
+
+// Cycles returns the groups of RPMs that depend on each other, directly or
+// not, each of which must be installed in a single transaction. It uses
+// Tarjan's algorithm for strongly connected components.
+func (g *DepGraph) Cycles() []RPMs {
+	var (
+		cycles  []RPMs
+		counter int
+		index   = make([]int, len(g.nodes))
+		low     = make([]int, len(g.nodes))
+		onStack = make([]bool, len(g.nodes))
+		stack   []int
+		connect func(i int)
+	)
+
+	connect = func(i int) {
+		counter++
+		index[i], low[i] = counter, counter
+		stack = append(stack, i)
+		onStack[i] = true
+
+		for _, j := range g.edges[i] {
+			if index[j] == 0 {
+				connect(j)
+				low[i] = min(low[i], low[j])
+			} else if onStack[j] {
+				low[i] = min(low[i], index[j])
+			}
+		}
+
+		if low[i] != index[i] {
+			return
+		}
+
+		var component RPMs
+		for {
+			j := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[j] = false
+			component = append(component, g.nodes[j])
+			if j == i {
+				break
+			}
+		}
+
+		if len(component) > 1 {
+			cycles = append(cycles, component)
+		}
+	}
+
+	for i := range g.nodes {
+		if index[i] == 0 {
+			connect(i)
+		}
+	}
+
+	return cycles
+}
+
+// InstallOrder returns the RPMs of the graph sorted such that every RPM
+// comes after the RPMs that it depends on, see RPMs.InstallOrder
+func (g *DepGraph) InstallOrder() (RPMs, error) {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+
+	var (
+		ordered RPMs
+		state   = make([]int, len(g.nodes))
+		stack   []int
+		visit   func(i int) error
+	)
+
+	visit = func(i int) error {
+		switch state[i] {
+		case done:
+			return nil
+		case visiting:
+			return g.cycleError(stack, i)
+		}
+
+		state[i] = visiting
+		stack = append(stack, i)
+		for _, dep := range g.edges[i] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = done
+
+		ordered = append(ordered, g.nodes[i])
+		return nil
+	}
+
+	// The first RPM, i.e. the top RPM, is visited last
+	for i := 1; i <= len(g.nodes); i++ {
+		if err := visit(i % len(g.nodes)); err != nil {
+			return nil, err
+		}
+	}
+
+	return ordered, nil
+}
+
+// cycleError describes the dependency cycle closed by reaching
+// RPM i again from the given stack of RPMs being visited
+func (g *DepGraph) cycleError(stack []int, i int) error {
+	start := len(stack) - 1
+	for stack[start] != i {
+		start--
+	}
+
+	var names []string
+	for _, k := range append(stack[start:], i) {
+		names = append(names, g.nodes[k].Name())
+	}
+
+	return fmt.Errorf("dependency cycle between %s", strings.Join(names, " -> "))
+}
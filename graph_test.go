@@ -0,0 +1,95 @@
+package rpm
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// createGraphRPMs writes one fixture RPM per spec and returns them, in order
+func createGraphRPMs(t *testing.T, specs ...fixtureRPM) *RPMs {
+	t.Helper()
+
+	dir := t.TempDir()
+	var rpms RPMs
+	for _, spec := range specs {
+		r, err := New(writeRPM(t, dir, spec.Name+".rpm", spec))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rpms = append(rpms, r)
+	}
+
+	return &rpms
+}
+
+func TestBuildGraph(t *testing.T) {
+	rpms := createGraphRPMs(t,
+		fixtureRPM{Name: "top", Requires: []fixtureDep{{Name: "libA.so"}, {Name: "b"}, {Name: "/bin/sh"}}},
+		fixtureRPM{Name: "a", Provides: []fixtureDep{{Name: "libA.so"}}, Requires: []fixtureDep{{Name: "b"}}},
+		fixtureRPM{Name: "b"},
+	)
+
+	g, err := BuildGraph(rpms)
+	if err != nil {
+		t.Fatalf("BuildGraph failed (%v)", err)
+	}
+
+	if len(g.Nodes()) != 3 {
+		t.Errorf("BuildGraph should have 3 nodes, got %d", len(g.Nodes()))
+	}
+
+	var edges []string
+	for _, e := range g.Edges() {
+		edges = append(edges, e.From.Name()+">"+e.To.Name())
+	}
+	sort.Strings(edges)
+	if got := strings.Join(edges, ","); got != "a.rpm>b.rpm,top.rpm>a.rpm,top.rpm>b.rpm" {
+		t.Errorf("BuildGraph should have 3 edges, got %s", got)
+	}
+
+	if cycles := g.Cycles(); len(cycles) != 0 {
+		t.Errorf("Cycles should find none, got %v", cycles)
+	}
+
+	ordered, err := g.InstallOrder()
+	if got := strings.Join(ordered.Names(), ","); err != nil || got != "b.rpm,a.rpm,top.rpm" {
+		t.Errorf("InstallOrder should return b.rpm,a.rpm,top.rpm, got %s (%v)", got, err)
+	}
+
+	if _, err := BuildGraph(&RPMs{{Path: "/blip/blop.rpm"}}); err == nil {
+		t.Errorf("BuildGraph should fail for an unreadable RPM, got nil")
+	}
+}
+
+func TestDepGraphCycles(t *testing.T) {
+	rpms := createGraphRPMs(t,
+		fixtureRPM{Name: "top", Requires: []fixtureDep{{Name: "a"}, {Name: "x"}}},
+		fixtureRPM{Name: "a", Requires: []fixtureDep{{Name: "b"}}},
+		fixtureRPM{Name: "b", Requires: []fixtureDep{{Name: "c"}}},
+		fixtureRPM{Name: "c", Requires: []fixtureDep{{Name: "a"}}},
+		fixtureRPM{Name: "x", Requires: []fixtureDep{{Name: "y"}}},
+		fixtureRPM{Name: "y", Requires: []fixtureDep{{Name: "x"}}},
+	)
+
+	g, err := BuildGraph(rpms)
+	if err != nil {
+		t.Fatalf("BuildGraph failed (%v)", err)
+	}
+
+	var cycles []string
+	for _, cycle := range g.Cycles() {
+		names := cycle.Names()
+		sort.Strings(names)
+		cycles = append(cycles, strings.Join(names, ","))
+	}
+	sort.Strings(cycles)
+
+	if got := strings.Join(cycles, " "); got != "a.rpm,b.rpm,c.rpm x.rpm,y.rpm" {
+		t.Errorf("Cycles should find a/b/c and x/y, got %s", got)
+	}
+
+	if _, err := g.InstallOrder(); err == nil {
+		t.Errorf("InstallOrder should fail on a cycle, got nil")
+	}
+}
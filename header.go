@@ -0,0 +1,186 @@
+package rpm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/cavaliergopher/rpm"
+)
+
+// header reads and parses the package header of the RPM. The file is only
+// read on the first call, the header (or error) being cached thereafter,
+// and not at all if DefaultHeaderCache holds it.
+func (r *RPM) header() (*rpm.Package, error) {
+	r.hdrOnce.Do(func() {
+		r.hdr, r.hdrErr = r.readHeader()
+		if r.hdrErr != nil {
+			r.hdrErr = fmt.Errorf("failed to read rpm header of %s (%w)", r.Path, r.hdrErr)
+		}
+	})
+
+	return r.hdr, r.hdrErr
+}
+
+func (r *RPM) readHeader() (*rpm.Package, error) {
+	if DefaultHeaderCache != nil && r.fsys == nil {
+		return DefaultHeaderCache.read(r.Path)
+	}
+
+	f, err := r.open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return rpm.Read(bufio.NewReader(f))
+}
+
+// Metadata is the identity of a package, as read from its header
+type Metadata struct {
+	Name    string
+	Epoch   int
+	Version string
+	Release string
+	Arch    string
+	Summary string
+
+	// InstalledSize is the total size in bytes of the files of the package,
+	// once installed, and not the size of the RPM file
+	InstalledSize int64
+}
+
+// Metadata returns the package identity read from the RPM header
+func (r *RPM) Metadata() (*Metadata, error) {
+	p, err := r.header()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Metadata{
+		Name:    p.Name(),
+		Epoch:   p.Epoch(),
+		Version: p.Version(),
+		Release: p.Release(),
+		Arch:    p.Architecture(),
+		Summary: p.Summary(),
+
+		InstalledSize: installedSize(p),
+	}, nil
+}
+
+// installedSize returns the installed size of the package, read from the
+// 64 bit size tag of packages above 4GB if present
+func installedSize(p *rpm.Package) int64 {
+	if size := p.Header.GetTag(tagLongSize).Int64(); size > 0 {
+		return size
+	}
+
+	return int64(p.Size())
+}
+
+// tagLongSize is the header tag of the installed size of large packages
+const tagLongSize = 5009
+
+// PackageName returns the package name from the RPM header, which unlike
+// Name is not the filename. It is empty if the header cannot be read.
+func (r *RPM) PackageName() string {
+	return r.metadata().Name
+}
+
+// Epoch returns the package epoch, 0 if the header cannot be read
+func (r *RPM) Epoch() int {
+	return r.metadata().Epoch
+}
+
+// Version returns the package version, empty if the header cannot be read
+func (r *RPM) Version() string {
+	return r.metadata().Version
+}
+
+// Release returns the package release, empty if the header cannot be read
+func (r *RPM) Release() string {
+	return r.metadata().Release
+}
+
+// Arch returns the package architecture, empty if the header cannot be read
+func (r *RPM) Arch() string {
+	return r.metadata().Arch
+}
+
+// Summary returns the one line package description,
+// empty if the header cannot be read
+func (r *RPM) Summary() string {
+	return r.metadata().Summary
+}
+
+// InstalledSize returns the size in bytes of the package once installed,
+// 0 if the header cannot be read. See Size for the size of the file.
+func (r *RPM) InstalledSize() int64 {
+	return r.metadata().InstalledSize
+}
+
+// NEVRA returns the package identity as name-[epoch:]version-release.arch,
+// empty if the header cannot be read
+func (r *RPM) NEVRA() string {
+	p, err := r.header()
+	if err != nil {
+		return ""
+	}
+
+	return nevra(p)
+}
+
+// metadata returns the package identity, zero valued if the header cannot be read
+func (r *RPM) metadata() Metadata {
+	if m, err := r.Metadata(); err == nil {
+		return *m
+	}
+
+	return Metadata{}
+}
+
+// nevra formats the package identity as name-[epoch:]version-release.arch
+func nevra(p *rpm.Package) string {
+	return fmt.Sprintf(
+		"%s-%s.%s",
+		p.Name(),
+		formatEVR(p.Epoch(), p.Version(), p.Release()),
+		p.Architecture(),
+	)
+}
+
+// leadSize is the size in bytes of the legacy lead that starts every RPM file
+const leadSize = 96
+
+// ValidateLead cheaply checks that the file is an RPM by reading only
+// its lead: the magic number, the format version and the package type
+// (binary or source) must be valid. The header itself is not parsed.
+func (r *RPM) ValidateLead() error {
+	f, err := r.open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	lead := make([]byte, leadSize)
+	if _, err := io.ReadFull(f, lead); err != nil {
+		return fmt.Errorf("%s: not an rpm, file is too short for an rpm lead (%w)", r.Path, err)
+	}
+
+	if !bytes.Equal(lead[:4], []byte{0xED, 0xAB, 0xEE, 0xDB}) {
+		return fmt.Errorf("%s: not an rpm, bad lead magic %x", r.Path, lead[:4])
+	}
+
+	if major := lead[4]; major < 3 || major > 4 {
+		return fmt.Errorf("%s: unsupported rpm format version %d", r.Path, major)
+	}
+
+	if typ := binary.BigEndian.Uint16(lead[6:8]); typ > 1 {
+		return fmt.Errorf("%s: unknown rpm package type %d", r.Path, typ)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package rpm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRPMValidateLead(t *testing.T) {
+	dir := t.TempDir()
+	good := writeRPM(t, dir, "good.rpm", fixtureRPM{Name: "good", Version: "1", Release: "1"})
+	if err := (&RPM{Path: good}).ValidateLead(); err != nil {
+		t.Errorf("ValidateLead should accept a valid RPM, got %v", err)
+	}
+
+	lead := fixtureRPM{Name: "src"}.bytes()[:leadSize]
+	lead[7] = 1
+	bad := map[string][]byte{
+		"short.rpm":   []byte("not an rpm"),
+		"text.rpm":    make([]byte, 200),
+		"version.rpm": append([]byte{0xED, 0xAB, 0xEE, 0xDB, 9}, make([]byte, 100)...),
+	}
+	for name, data := range bad {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, data, 0644)
+		if err := (&RPM{Path: path}).ValidateLead(); err == nil {
+			t.Errorf("ValidateLead should reject %s, got nil", name)
+		}
+	}
+
+	source := filepath.Join(dir, "source.rpm")
+	os.WriteFile(source, lead, 0644)
+	if err := (&RPM{Path: source}).ValidateLead(); err != nil {
+		t.Errorf("ValidateLead should accept a source RPM lead, got %v", err)
+	}
+}
+
+func TestRPMMetadata(t *testing.T) {
+	path := writeRPM(t, t.TempDir(), "AthenaExternals_22.0.1_x86_64.rpm", fixtureRPM{
+		Name: "AthenaExternals", Version: "22.0.1", Release: "3", Epoch: 1, Arch: "noarch",
+		Summary: "ATLAS externals", Installed: 123456,
+	})
+	r := &RPM{Path: path}
+
+	m, err := r.Metadata()
+	if err != nil {
+		t.Fatalf("Metadata failed (%v)", err)
+	}
+
+	expect := Metadata{
+		Name: "AthenaExternals", Epoch: 1, Version: "22.0.1", Release: "3", Arch: "noarch",
+		Summary: "ATLAS externals", InstalledSize: 123456,
+	}
+	if *m != expect {
+		t.Errorf("Metadata should return %+v, got %+v", expect, *m)
+	}
+
+	// The header is cached, so the file is not needed anymore
+	os.Remove(path)
+	if r.PackageName() != "AthenaExternals" || r.Version() != "22.0.1" || r.Release() != "3" ||
+		r.Epoch() != 1 || r.Arch() != "noarch" {
+		t.Errorf("RPM accessors should use the cached header, got %s %d %s %s %s",
+			r.PackageName(), r.Epoch(), r.Version(), r.Release(), r.Arch())
+	}
+
+	if r.NEVRA() != "AthenaExternals-1:22.0.1-3.noarch" || r.Summary() != "ATLAS externals" ||
+		r.InstalledSize() != 123456 {
+		t.Errorf("RPM accessors should use the cached header, got %s %q %d", r.NEVRA(), r.Summary(), r.InstalledSize())
+	}
+
+	if r.Name() != "AthenaExternals_22.0.1_x86_64.rpm" {
+		t.Errorf("RPM Name should still return the filename, got %s", r.Name())
+	}
+}
+
+func TestRPMMetadataUnreadable(t *testing.T) {
+	r := &RPM{Path: "/blip/blop.rpm"}
+	if _, err := r.Metadata(); err == nil {
+		t.Errorf("Metadata should fail for an inexistant RPM, got nil")
+	}
+
+	if r.PackageName() != "" || r.Version() != "" || r.NEVRA() != "" || r.InstalledSize() != 0 {
+		t.Errorf("RPM accessors should return zero values for an unreadable header")
+	}
+}
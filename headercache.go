@@ -0,0 +1,181 @@
+package rpm
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cavaliergopher/rpm"
+)
+
+// headerCacheVersion is the version of the file format written by HeaderCache.Save
+const headerCacheVersion = 1
+
+// DefaultHeaderCache, if set, caches the headers of all the RPM files of
+// the OS file system read by the package, across RPM values, Finders and
+// runs if saved. It is nil by default, so that caching must be explicitly
+// opted into, e.g. with LoadHeaderCache.
+var DefaultHeaderCache *HeaderCache
+
+// HeaderCache caches the headers of RPM files, keyed by absolute path and
+// checked against the modification time and size of the file, so that
+// repeated lookups over the same directory need not read the files again.
+// It is safe for concurrent use.
+type HeaderCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedHeader
+}
+
+// cachedHeader is the raw header of an RPM file, from the start of the
+// file to the end of the main header, the parsed one being kept in memory
+type cachedHeader struct {
+	ModTime int64
+	Size    int64
+	Raw     []byte
+
+	pkg *rpm.Package
+}
+
+// headerCacheFile is the persisted form of a HeaderCache
+type headerCacheFile struct {
+	Version int
+	Entries map[string]*cachedHeader
+}
+
+// NewHeaderCache creates an empty HeaderCache
+func NewHeaderCache() *HeaderCache {
+	return &HeaderCache{entries: map[string]*cachedHeader{}}
+}
+
+// LoadHeaderCache reads the HeaderCache saved in the file at path, see
+// Save. If there is no such file, an empty cache is returned.
+func LoadHeaderCache(path string) (*HeaderCache, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewHeaderCache(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var saved headerCacheFile
+	if err := gob.NewDecoder(f).Decode(&saved); err != nil {
+		return nil, fmt.Errorf("failed to decode header cache %s (%w)", path, err)
+	}
+
+	if saved.Version != headerCacheVersion {
+		return nil, fmt.Errorf("unsupported header cache version %d in %s", saved.Version, path)
+	}
+
+	c := NewHeaderCache()
+	for key, entry := range saved.Entries {
+		if entry != nil {
+			c.entries[key] = entry
+		}
+	}
+
+	return c, nil
+}
+
+// Save writes the cache to the file at path, atomically replacing any
+// previous one. Entries of files that were removed or changed since they
+// were cached are dropped.
+func (c *HeaderCache) Save(path string) error {
+	c.mu.Lock()
+	saved := headerCacheFile{Version: headerCacheVersion, Entries: map[string]*cachedHeader{}}
+	for key, entry := range c.entries {
+		saved.Entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	for key, entry := range saved.Entries {
+		if fi, err := os.Stat(key); err != nil || !entry.matches(fi) {
+			delete(saved.Entries, key)
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(saved); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to encode header cache (%w)", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Len returns the number of headers in the cache
+func (c *HeaderCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// read returns the parsed header of the RPM file at path, from the cache
+// if the file has not changed since, else from the file, then cached
+func (c *HeaderCache) read(path string) (*rpm.Package, error) {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	entry := c.entries[key]
+	c.mu.Unlock()
+
+	if entry == nil || !entry.matches(fi) {
+		_, end, err := readHeaderRange(f, path)
+		if err != nil {
+			return nil, err
+		}
+
+		raw := make([]byte, end)
+		if _, err := f.ReadAt(raw, 0); err != nil && err != io.EOF {
+			return nil, err
+		}
+		entry = &cachedHeader{ModTime: fi.ModTime().UnixNano(), Size: fi.Size(), Raw: raw}
+	}
+
+	pkg := entry.pkg
+	if pkg == nil {
+		if pkg, err = rpm.Read(bytes.NewReader(entry.Raw)); err != nil {
+			return nil, err
+		}
+	}
+
+	c.mu.Lock()
+	c.entries[key] = &cachedHeader{ModTime: entry.ModTime, Size: entry.Size, Raw: entry.Raw, pkg: pkg}
+	c.mu.Unlock()
+
+	return pkg, nil
+}
+
+// matches indicates if the entry was cached for the file as it is now
+func (e *cachedHeader) matches(fi os.FileInfo) bool {
+	return e.ModTime == fi.ModTime().UnixNano() && e.Size == fi.Size()
+}
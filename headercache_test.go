@@ -0,0 +1,96 @@
+package rpm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHeaderCache(t *testing.T) {
+	DefaultHeaderCache = NewHeaderCache()
+	t.Cleanup(func() { DefaultHeaderCache = nil })
+
+	dir := t.TempDir()
+	path := writeRPM(t, dir, "Athena.rpm", fixtureRPM{
+		Name: "Athena", Version: "22.0.1", Release: "1",
+		Compression: "gzip",
+		Payload:     cpioPayload(t, "gzip", installerEntries),
+	})
+
+	if got := (&RPM{Path: path}).PackageName(); got != "Athena" || DefaultHeaderCache.Len() != 1 {
+		t.Fatalf("the header of Athena should be cached, got %q and %d entries", got, DefaultHeaderCache.Len())
+	}
+
+	// Corrupt the file, keeping its size and modification time
+	fi, _ := os.Stat(path)
+	os.WriteFile(path, make([]byte, fi.Size()), 0644)
+	os.Chtimes(path, fi.ModTime(), fi.ModTime())
+
+	if got := (&RPM{Path: path}).PackageName(); got != "Athena" {
+		t.Errorf("the header should be read from the cache, got %q", got)
+	}
+
+	// Persist the cache and load it back
+	cachePath := filepath.Join(t.TempDir(), "headers.gob")
+	if err := DefaultHeaderCache.Save(cachePath); err != nil {
+		t.Fatalf("Save failed (%v)", err)
+	}
+
+	loaded, err := LoadHeaderCache(cachePath)
+	if err != nil || loaded.Len() != 1 {
+		t.Fatalf("LoadHeaderCache should load 1 header, got %v (%v)", loaded, err)
+	}
+	DefaultHeaderCache = loaded
+
+	if got := (&RPM{Path: path}).Version(); got != "22.0.1" {
+		t.Errorf("the header should be read from the loaded cache, got %q", got)
+	}
+
+	// A changed file is read again
+	os.Chtimes(path, fi.ModTime(), fi.ModTime().Add(time.Second))
+	if _, err := (&RPM{Path: path}).Metadata(); err == nil {
+		t.Errorf("the header of a changed file should be read from the file")
+	}
+
+	if err := DefaultHeaderCache.Save(cachePath); err != nil {
+		t.Fatalf("Save failed (%v)", err)
+	}
+	if loaded, err := LoadHeaderCache(cachePath); err != nil || loaded.Len() != 0 {
+		t.Errorf("Save should drop the entries of changed files, got %v (%v)", loaded, err)
+	}
+}
+
+func TestLoadHeaderCache(t *testing.T) {
+	dir := t.TempDir()
+	if c, err := LoadHeaderCache(filepath.Join(dir, "missing.gob")); err != nil || c.Len() != 0 {
+		t.Errorf("LoadHeaderCache should return an empty cache for a missing file, got %v (%v)", c, err)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.gob")
+	os.WriteFile(corrupt, []byte("not gob"), 0644)
+	if _, err := LoadHeaderCache(corrupt); err == nil {
+		t.Errorf("LoadHeaderCache should fail on a corrupt file")
+	}
+}
+
+func TestHeaderCacheFind(t *testing.T) {
+	DefaultHeaderCache = NewHeaderCache()
+	t.Cleanup(func() { DefaultHeaderCache = nil })
+
+	dir := t.TempDir()
+	writeRPM(t, dir, "project_1.0_el9.rpm", fixtureRPM{
+		Name: "project", Version: "1.0", Release: "1",
+		Requires: []fixtureDep{{Name: "a"}, {Name: "b"}},
+	})
+	writeRPM(t, dir, "a.rpm", fixtureRPM{Name: "a", Version: "1", Release: "1"})
+	writeRPM(t, dir, "b.rpm", fixtureRPM{Name: "b", Version: "1", Release: "1"})
+
+	if rpms, err := NewFinder(dir).Find("project", "el9"); err != nil || len(*rpms) != 3 {
+		t.Fatalf("Find should return 3 RPMs, got %v (%v)", rpms, err)
+	}
+
+	if n := DefaultHeaderCache.Len(); n != 3 {
+		t.Errorf("Find should cache the headers of the 3 RPMs, got %d entries", n)
+	}
+}
@@ -0,0 +1,194 @@
+package rpm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cavaliergopher/rpm"
+)
+
+// ErrStaleIndex is returned by Finder.LoadIndex when the saved index
+// no longer reflects the content of the Finder's directory
+var ErrStaleIndex = errors.New("capability index is stale")
+
+// Provider is an RPM file that provides a given capability
+type Provider struct {
+	File    string `json:"file"`
+	Flags   int    `json:"flags,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// capIndex maps each capability provided by the RPMs of a directory
+// to the files that provide it
+type capIndex struct {
+	Dir      string                `json:"dir"`
+	ModTime  time.Time             `json:"mtime"`
+	Provides map[string][]Provider `json:"provides"`
+
+	// Unreadable lists the RPM files whose header could not be read
+	Unreadable []string `json:"unreadable,omitempty"`
+}
+
+// formatEVR renders an epoch, version and release as [epoch:]version-release
+func formatEVR(epoch int, version, release string) string {
+	s := version
+	if release != "" {
+		s = fmt.Sprintf("%s-%s", s, release)
+	}
+	if epoch > 0 {
+		s = fmt.Sprintf("%d:%s", epoch, s)
+	}
+
+	return s
+}
+
+// buildIndex reads the header of every RPM in dir and indexes its
+// Provides, including the implicit provide of the package name itself.
+// Files with an unreadable header are recorded but not indexed. Up to
+// concurrency headers are parsed in parallel (DefaultConcurrency if below
+// 1), and building stops with a wrapped ctx error as soon as ctx is done.
+func buildIndex(ctx context.Context, fsys fileSystem, dir string, concurrency int) (*capIndex, error) {
+	fi, err := fsys.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".rpm") {
+			names = append(names, entry.Name())
+		}
+	}
+
+	headers := make([]*rpm.Package, len(names))
+	err = forEach(ctx, len(names), concurrency, func(i int) error {
+		// Unreadable headers are left nil
+		headers[i], _ = (&RPM{Path: fsys.Join(dir, names[i]), fsys: ownFS(fsys)}).header()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &capIndex{Dir: dir, ModTime: fi.ModTime(), Provides: map[string][]Provider{}}
+	for i, p := range headers {
+		if p == nil {
+			idx.Unreadable = append(idx.Unreadable, names[i])
+			continue
+		}
+
+		self := Provider{
+			File:    names[i],
+			Flags:   rpm.DepFlagEqual,
+			Version: formatEVR(p.Epoch(), p.Version(), p.Release()),
+		}
+		idx.add(p.Name(), self)
+		for _, prov := range p.Provides() {
+			if prov.Name() == p.Name() && prov.Version() == self.Version {
+				continue
+			}
+			idx.add(prov.Name(), Provider{File: names[i], Flags: prov.Flags(), Version: prov.Version()})
+		}
+	}
+
+	return idx, nil
+}
+
+func (idx *capIndex) add(capability string, p Provider) {
+	idx.Provides[capability] = append(idx.Provides[capability], p)
+}
+
+// valid indicates if the index still reflects the content of dir
+func (idx *capIndex) valid(fsys fileSystem, dir string) bool {
+	if idx == nil || idx.Dir != dir {
+		return false
+	}
+
+	fi, err := fsys.Stat(dir)
+	return err == nil && fi.ModTime().Equal(idx.ModTime)
+}
+
+// capabilities returns the capability index of the Finder's directory,
+// (re)building it if there is none or it became stale. Concurrent lookups
+// wait for the index being built rather than building it again.
+func (f *Finder) capabilities(ctx context.Context) (*capIndex, error) {
+	f.indexMu.Lock()
+	defer f.indexMu.Unlock()
+
+	if f.index.valid(f.files(), f.basedir) {
+		return f.index, nil
+	}
+
+	start := time.Now()
+	idx, err := buildIndex(ctx, f.files(), f.basedir, f.concurrency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to index capabilities of %s (%w)", f.basedir, err)
+	}
+	f.log().Info("indexed capabilities", "dir", f.basedir, "capabilities", len(idx.Provides), "elapsed", time.Since(start))
+
+	f.index = idx
+	return idx, nil
+}
+
+// WhatProvides returns the RPM files in the Finder's directory
+// that provide the given capability
+func (f *Finder) WhatProvides(capability string) ([]Provider, error) {
+	idx, err := f.capabilities(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return idx.Provides[capability], nil
+}
+
+// SaveIndex writes the capability index of the Finder's directory
+// as JSON to the file at path, building the index first if needed
+func (f *Finder) SaveIndex(path string) error {
+	idx, err := f.capabilities(context.Background())
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// LoadIndex reads a capability index previously written by SaveIndex.
+// The index is only used if it was built for the Finder's directory and
+// the directory modification time is unchanged since, otherwise an error
+// wrapping ErrStaleIndex is returned. Note that a file rewritten in place
+// does not change the directory modification time.
+func (f *Finder) LoadIndex(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var idx capIndex
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return fmt.Errorf("failed to decode capability index %s (%w)", path, err)
+	}
+
+	if !idx.valid(f.files(), f.basedir) {
+		return fmt.Errorf("%s: %w", path, ErrStaleIndex)
+	}
+
+	f.indexMu.Lock()
+	f.index = &idx
+	f.indexMu.Unlock()
+	return nil
+}
@@ -0,0 +1,117 @@
+package rpm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func createIndexDir(t *testing.T) string {
+	dir := t.TempDir()
+	writeRPM(t, dir, "libfoo-1.0-1.x86_64.rpm", fixtureRPM{
+		Name:     "libfoo",
+		Version:  "1.0",
+		Release:  "1",
+		Provides: []fixtureDep{{Name: "libfoo.so.1"}},
+	})
+
+	return dir
+}
+
+func TestWhatProvides(t *testing.T) {
+	f := NewFinder(createIndexDir(t))
+
+	for _, capability := range []string{"libfoo", "libfoo.so.1"} {
+		got, err := f.WhatProvides(capability)
+		if err != nil {
+			t.Fatalf("WhatProvides failed (%v)", err)
+		}
+
+		if len(got) != 1 || got[0].File != "libfoo-1.0-1.x86_64.rpm" {
+			t.Errorf("WhatProvides(%s) should return libfoo-1.0-1.x86_64.rpm, got %v", capability, got)
+		}
+	}
+
+	got, _ := f.WhatProvides("libfoo")
+	if got[0].Version != "1.0-1" {
+		t.Errorf("WhatProvides should record version 1.0-1, got %s", got[0].Version)
+	}
+}
+
+func TestSaveLoadIndex(t *testing.T) {
+	dir := createIndexDir(t)
+	cache := filepath.Join(t.TempDir(), "index.json")
+
+	if err := NewFinder(dir).SaveIndex(cache); err != nil {
+		t.Fatalf("SaveIndex failed (%v)", err)
+	}
+
+	f := NewFinder(dir)
+	if err := f.LoadIndex(cache); err != nil {
+		t.Fatalf("LoadIndex failed (%v)", err)
+	}
+
+	// Remove the RPM behind the Finder's back whilst keeping the directory
+	// mtime: the loaded index must be used rather than the directory
+	fi, _ := os.Stat(dir)
+	os.Remove(filepath.Join(dir, "libfoo-1.0-1.x86_64.rpm"))
+	os.Chtimes(dir, fi.ModTime(), fi.ModTime())
+
+	got, err := f.WhatProvides("libfoo.so.1")
+	if err != nil || len(got) != 1 {
+		t.Errorf("WhatProvides should use the loaded index, got %v (%v)", got, err)
+	}
+}
+
+func TestLoadIndexStale(t *testing.T) {
+	dir := createIndexDir(t)
+	cache := filepath.Join(t.TempDir(), "index.json")
+
+	if err := NewFinder(dir).SaveIndex(cache); err != nil {
+		t.Fatalf("SaveIndex failed (%v)", err)
+	}
+
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(dir, later, later)
+
+	err := NewFinder(dir).LoadIndex(cache)
+	if !errors.Is(err, ErrStaleIndex) {
+		t.Errorf("LoadIndex should return ErrStaleIndex, got %v", err)
+	}
+
+	if err := NewFinder(t.TempDir()).LoadIndex(cache); !errors.Is(err, ErrStaleIndex) {
+		t.Errorf("LoadIndex for another directory should return ErrStaleIndex, got %v", err)
+	}
+}
+
+func TestFinderConcurrentFind(t *testing.T) {
+	f := NewFinder(createChainDir(t), WithTransitive())
+
+	// Lookups sharing the Finder share its capability index,
+	// which is built once; run with -race
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rpms, err := f.Find("a", "el9")
+			if err == nil && len(*rpms) != 4 {
+				err = fmt.Errorf("got %v", rpms.Names())
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent Find should return the 4 RPMs of the chain (%v)", err)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package rpm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// RPMCommand is the rpm executable that TestInstall shells out to.
+// It is empty by default, so that running external commands must be
+// explicitly opted into, typically by setting it to "rpm".
+var RPMCommand = ""
+
+// ErrExecDisabled is returned by operations that need to run RPMCommand
+// whilst it is not set
+var ErrExecDisabled = errors.New("running rpm is disabled (RPMCommand is not set)")
+
+// TestInstall dry-runs the installation of the RPMs into the given root
+// directory with `rpm --test -Uvh`, catching the conflicts and unmet
+// dependencies that static checks miss. If root is empty, a throwaway root
+// with a fresh rpm database is used. On failure, the error holds the
+// rpm output.
+func (r *RPMs) TestInstall(ctx context.Context, root string) error {
+	if RPMCommand == "" {
+		return ErrExecDisabled
+	}
+
+	if root == "" {
+		dir, err := os.MkdirTemp("", "atlas-rpm-testinstall")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		if err := runRPM(ctx, "--initdb", "--root", dir); err != nil {
+			return err
+		}
+		root = dir
+	}
+
+	args := append([]string{"--test", "-Uvh", "--root", root}, r.Paths()...)
+	return runRPM(ctx, args...)
+}
+
+func runRPM(ctx context.Context, args ...string) error {
+	out, err := exec.CommandContext(ctx, RPMCommand, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf(
+			"%s %s failed (%w):\n%s",
+			RPMCommand,
+			strings.Join(args[:2], " "),
+			err,
+			out,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package rpm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeRPMCommand installs a shell script as RPMCommand for the test duration
+func fakeRPMCommand(t *testing.T, script string) {
+	path := filepath.Join(t.TempDir(), "rpm")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatalf("failed to write fake rpm command (%v)", err)
+	}
+
+	RPMCommand = path
+	t.Cleanup(func() { RPMCommand = "" })
+}
+
+func TestTestInstallDisabled(t *testing.T) {
+	err := createRPMs().TestInstall(context.Background(), "")
+	if !errors.Is(err, ErrExecDisabled) {
+		t.Errorf("TestInstall should return ErrExecDisabled, got %v", err)
+	}
+}
+
+func TestTestInstall(t *testing.T) {
+	fakeRPMCommand(t, "exit 0")
+
+	if err := createRPMs().TestInstall(context.Background(), ""); err != nil {
+		t.Errorf("TestInstall should succeed, got %v", err)
+	}
+}
+
+func TestTestInstallFailure(t *testing.T) {
+	fakeRPMCommand(t, `[ "$1" = "--initdb" ] && exit 0; echo "file /blip conflicts"; exit 1`)
+
+	err := createRPMs().TestInstall(context.Background(), "")
+	if err == nil {
+		t.Fatalf("TestInstall should fail, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "file /blip conflicts") {
+		t.Errorf("TestInstall error should contain the rpm output, got %v", err)
+	}
+}
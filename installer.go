@@ -0,0 +1,351 @@
+package rpm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cavaliergopher/cpio"
+	"github.com/cavaliergopher/rpm"
+)
+
+// tagPrefixes is the header tag of the relocatable prefixes of a package
+const tagPrefixes = 1098
+
+// Installer extracts the payload of RPMs below a destination directory,
+// as rpm2cpio would. It needs neither root privileges nor an rpm database,
+// and runs no scriptlets. Ownership of the files is not preserved.
+type Installer struct {
+	// Relocate installs the files below the relocatable prefix of a
+	// package (its RPM Prefix) directly in the destination directory,
+	// rather than below the prefix within it
+	Relocate bool
+
+	// Manifest, if set, receives the path of each file written by Install,
+	// relative to the destination directory, one per line
+	Manifest io.Writer
+
+	// Progress, if set, receives each RPM installed and the failure, if any
+	Progress Progress
+
+	// Logger, if set, receives a record of each RPM installed
+	// and of the payload entries skipped
+	Logger *slog.Logger
+
+	// Include, if set, restricts the installation to the payload entries
+	// matching one of these path.Match patterns. A pattern without a slash
+	// matches the base name of an entry, any other its full packaged path,
+	// e.g. /opt/atlas/*/setup.sh. Parent directories are created as needed.
+	Include []string
+}
+
+// Install extracts the RPMs, in order, below destDir, which is created
+// if needed. Existing files are overwritten. It stops as soon as ctx is
+// done, leaving the files extracted so far in place.
+func (in *Installer) Install(ctx context.Context, rpms *RPMs, destDir string) error {
+	for _, pattern := range in.Include {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad include pattern %q (%w)", pattern, err)
+		}
+	}
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return err
+	}
+
+	for _, r := range *rpms {
+		if err := interrupted(ctx); err != nil {
+			return err
+		}
+
+		start := time.Now()
+		if err := in.install(ctx, r, destDir); err != nil {
+			err = fmt.Errorf("failed to install %s (%w)", r.Name(), err)
+			report(in.Progress, Event{Kind: EventError, Path: r.Path, Err: err})
+			return err
+		}
+		orDiscard(in.Logger).Info("installed", "rpm", r.Name(), "dest", destDir, "elapsed", time.Since(start))
+		report(in.Progress, Event{Kind: EventInstalled, Path: r.Path})
+	}
+
+	return nil
+}
+
+// install extracts the payload of a single RPM
+func (in *Installer) install(ctx context.Context, r *RPM, destDir string) error {
+	// Hard linked files carry their content on the last of their entries
+	pending := map[int64][]string{}
+
+	err := r.walkPayload(func(p *rpm.Package, hdr *cpio.Header, body io.Reader) error {
+		if err := interrupted(ctx); err != nil {
+			return err
+		}
+
+		rel := in.relocate(p, hdr.Name)
+		mode := hdr.FileInfo().Mode()
+		included := in.includes(hdr.Name)
+
+		switch {
+		case mode.IsRegular() && hdr.Links > 1 && hdr.Size == 0:
+			if included {
+				pending[hdr.Inode] = append(pending[hdr.Inode], rel)
+			}
+			return nil
+
+		case mode.IsRegular() && !included:
+			// Write the content for the included hard links, if any
+			links := pending[hdr.Inode]
+			delete(pending, hdr.Inode)
+			if len(links) == 0 {
+				return nil
+			}
+
+			if err := in.writeFile(destDir, links[0], mode.Perm(), body); err != nil {
+				return err
+			}
+			for i, link := range links {
+				if i > 0 {
+					if err := in.link(destDir, links[0], link); err != nil {
+						return err
+					}
+				}
+				if err := in.record(link); err != nil {
+					return err
+				}
+			}
+			return nil
+
+		case !included:
+			return nil
+
+		case mode.IsDir():
+			return mkdirInside(destDir, rel, mode.Perm()|0700)
+
+		case mode&os.ModeSymlink != 0:
+			if linkEscapes(rel, hdr.Linkname) {
+				return fmt.Errorf("symlink %s points outside the destination (%s)", rel, hdr.Linkname)
+			}
+			target, err := in.prepare(destDir, rel)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+
+		case mode.IsRegular():
+			if err := in.writeFile(destDir, rel, mode.Perm(), body); err != nil {
+				return err
+			}
+			for _, link := range pending[hdr.Inode] {
+				if err := in.link(destDir, rel, link); err != nil {
+					return err
+				}
+				if err := in.record(link); err != nil {
+					return err
+				}
+			}
+			delete(pending, hdr.Inode)
+
+		default:
+			// Devices, fifos and sockets cannot be created rootless
+			orDiscard(in.Logger).Debug("skipped payload entry", "rpm", r.Name(), "name", hdr.Name, "mode", mode)
+			return nil
+		}
+
+		return in.record(rel)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Empty hard linked files have no entry with content
+	for _, links := range pending {
+		for _, link := range links {
+			if err := in.writeFile(destDir, link, 0644, strings.NewReader("")); err != nil {
+				return err
+			}
+			if err := in.record(link); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// includes indicates if the payload entry of the given name is installed
+func (in *Installer) includes(name string) bool {
+	if len(in.Include) == 0 {
+		return true
+	}
+
+	abs := payloadPath(name)
+	for _, pattern := range in.Include {
+		target := abs
+		if !strings.Contains(pattern, "/") {
+			target = path.Base(abs)
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// relocate returns the path, relative to the destination directory, at
+// which the payload entry of the given name is installed. Cleaning it as
+// an absolute path first ensures that it cannot escape the directory.
+func (in *Installer) relocate(p *rpm.Package, name string) string {
+	abs := payloadPath(name)
+	if in.Relocate {
+		for _, prefix := range p.Header.GetTag(tagPrefixes).StringSlice() {
+			prefix = path.Clean("/" + prefix)
+			if prefix == "/" {
+				continue
+			}
+			if abs == prefix || strings.HasPrefix(abs, prefix+"/") {
+				abs = "/" + strings.TrimPrefix(abs, prefix)
+				break
+			}
+		}
+	}
+
+	return strings.TrimPrefix(path.Clean(abs), "/")
+}
+
+// prepare creates the parent directories of the file at the relative path
+// rel below destDir, and removes any file already there, which an extracted
+// file is about to replace. It returns the path of the file.
+func (in *Installer) prepare(destDir, rel string) (string, error) {
+	if err := mkdirInside(destDir, path.Dir(rel), 0755); err != nil {
+		return "", err
+	}
+
+	target := filepath.Join(destDir, filepath.FromSlash(rel))
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	return target, nil
+}
+
+// writeFile writes body to the file at the relative path rel below
+// destDir, with the given permissions regardless of the umask
+func (in *Installer) writeFile(destDir, rel string, perm os.FileMode, body io.Reader) error {
+	target, err := in.prepare(destDir, rel)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// link hard links the file at the relative path rel below destDir to
+// that at src, also relative to destDir
+func (in *Installer) link(destDir, src, rel string) error {
+	target, err := in.prepare(destDir, rel)
+	if err != nil {
+		return err
+	}
+
+	return os.Link(filepath.Join(destDir, filepath.FromSlash(src)), target)
+}
+
+// mkdirInside creates the directory at the relative path rel below
+// destDir, and its missing parents, with the given permissions regardless
+// of the umask. Existing directories are left as they are. It refuses to
+// go through a symlink, which could lead outside of destDir.
+func mkdirInside(destDir, rel string, perm os.FileMode) error {
+	dir := destDir
+	for _, name := range strings.Split(rel, "/") {
+		if name == "" || name == "." {
+			continue
+		}
+		dir = filepath.Join(dir, name)
+
+		fi, err := os.Lstat(dir)
+		switch {
+		case os.IsNotExist(err):
+			if err := os.Mkdir(dir, perm); err != nil {
+				return err
+			}
+			if err := os.Chmod(dir, perm); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case fi.Mode()&os.ModeSymlink != 0:
+			return fmt.Errorf("refusing to write through symlink %s", dir)
+		case !fi.IsDir():
+			return fmt.Errorf("%s is not a directory", dir)
+		}
+	}
+
+	return nil
+}
+
+// linkEscapes indicates if the target of the symlink at the relative path
+// rel is absolute or resolves outside of the destination directory
+func linkEscapes(rel, target string) bool {
+	if path.IsAbs(target) {
+		return true
+	}
+
+	var parts []string
+	for _, name := range strings.Split(path.Dir(rel), "/") {
+		if name != "" && name != "." {
+			parts = append(parts, name)
+		}
+	}
+
+	for _, name := range strings.Split(target, "/") {
+		switch name {
+		case "", ".":
+		case "..":
+			if len(parts) == 0 {
+				return true
+			}
+			parts = parts[:len(parts)-1]
+		default:
+			parts = append(parts, name)
+		}
+	}
+
+	return false
+}
+
+// record adds a written file to the manifest, if any
+func (in *Installer) record(rel string) error {
+	if in.Manifest == nil {
+		return nil
+	}
+
+	if _, err := fmt.Fprintln(in.Manifest, rel); err != nil {
+		return fmt.Errorf("failed to write install manifest (%w)", err)
+	}
+
+	return nil
+}
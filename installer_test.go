@@ -0,0 +1,225 @@
+package rpm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cavaliergopher/cpio"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// payloadEntry is an entry of a fixture cpio payload
+type payloadEntry struct {
+	Name  string
+	Mode  cpio.FileMode
+	Body  string
+	Link  string
+	Links int
+	Inode int64
+}
+
+// cpioPayload builds a cpio archive of the entries, compressed as given
+func cpioPayload(t testing.TB, compression string, entries []payloadEntry) []byte {
+	t.Helper()
+
+	var archive bytes.Buffer
+	w := cpio.NewWriter(&archive)
+	for _, e := range entries {
+		hdr := &cpio.Header{Name: e.Name, Mode: e.Mode, Size: int64(len(e.Body)), Links: e.Links, Inode: e.Inode}
+		if e.Link != "" {
+			hdr.Mode = cpio.TypeSymlink | 0777
+			hdr.Size = int64(len(e.Link))
+		}
+		if err := w.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(e.Body + e.Link))
+	}
+	w.Close()
+
+	var buf bytes.Buffer
+	var cw interface {
+		Write([]byte) (int, error)
+		Close() error
+	}
+	switch compression {
+	case "gzip":
+		cw = gzip.NewWriter(&buf)
+	case "xz":
+		cw, _ = xz.NewWriter(&buf)
+	case "zstd":
+		cw, _ = zstd.NewWriter(&buf)
+	default:
+		return archive.Bytes()
+	}
+	cw.Write(archive.Bytes())
+	cw.Close()
+
+	return buf.Bytes()
+}
+
+var installerEntries = []payloadEntry{
+	{Name: "./opt/atlas", Mode: cpio.TypeDir | 0755},
+	{Name: "./opt/atlas/bin/athena.py", Mode: cpio.TypeReg | 0755, Body: "#!/usr/bin/env python\n"},
+	{Name: "./opt/atlas/setup.sh", Mode: cpio.TypeReg | 0644, Body: "export ATHENA=1\n"},
+	{Name: "./opt/atlas/setup-link.sh", Link: "setup.sh"},
+	{Name: "./opt/atlas/lib/a.so", Mode: cpio.TypeReg | 0644, Links: 2, Inode: 42},
+	{Name: "./opt/atlas/lib/b.so", Mode: cpio.TypeReg | 0644, Links: 2, Inode: 42, Body: "ELF"},
+	{Name: "./etc/../../escape.txt", Mode: cpio.TypeReg | 0644, Body: "contained"},
+}
+
+func TestInstallerInstall(t *testing.T) {
+	for _, compression := range []string{"gzip", "xz", "zstd", "none"} {
+		t.Run(compression, func(t *testing.T) {
+			path := writeRPM(t, t.TempDir(), "Athena.rpm", fixtureRPM{
+				Name: "Athena", Version: "1", Release: "1",
+				Compression: compression,
+				Payload:     cpioPayload(t, compression, installerEntries),
+			})
+
+			dest := filepath.Join(t.TempDir(), "install")
+			var manifest bytes.Buffer
+			in := &Installer{Manifest: &manifest}
+			if err := in.Install(context.Background(), &RPMs{{Path: path}}, dest); err != nil {
+				t.Fatalf("Install failed (%v)", err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(dest, "opt/atlas/setup-link.sh"))
+			if err != nil || string(content) != "export ATHENA=1\n" {
+				t.Errorf("Install should write setup.sh and its symlink, got %q (%v)", content, err)
+			}
+
+			fi, err := os.Stat(filepath.Join(dest, "opt/atlas/bin/athena.py"))
+			if err != nil || fi.Mode().Perm() != 0755 {
+				t.Errorf("Install should preserve the file mode, got %v (%v)", fi, err)
+			}
+
+			a, _ := os.Stat(filepath.Join(dest, "opt/atlas/lib/a.so"))
+			b, _ := os.Stat(filepath.Join(dest, "opt/atlas/lib/b.so"))
+			if a == nil || b == nil || !os.SameFile(a, b) || a.Size() != 3 {
+				t.Errorf("Install should hard link a.so to b.so")
+			}
+
+			if content, _ := os.ReadFile(filepath.Join(dest, "escape.txt")); string(content) != "contained" {
+				t.Errorf("Install should keep escaping paths within the destination, got %q", content)
+			}
+
+			expect := "opt/atlas/bin/athena.py\nopt/atlas/setup.sh\nopt/atlas/setup-link.sh\n" +
+				"opt/atlas/lib/a.so\nopt/atlas/lib/b.so\nescape.txt\n"
+			if manifest.String() != expect {
+				t.Errorf("Install manifest should be\n%s\ngot\n%s", expect, manifest.String())
+			}
+		})
+	}
+}
+
+func TestInstallerRelocate(t *testing.T) {
+	path := writeRPM(t, t.TempDir(), "Athena.rpm", fixtureRPM{
+		Name: "Athena", Version: "1", Release: "1",
+		Compression: "gzip",
+		Prefixes:    []string{"/opt/atlas"},
+		Payload:     cpioPayload(t, "gzip", installerEntries),
+	})
+
+	dest := t.TempDir()
+	in := &Installer{Relocate: true}
+	if err := in.Install(context.Background(), &RPMs{{Path: path}}, dest); err != nil {
+		t.Fatalf("Install failed (%v)", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dest, "setup.sh")); err != nil {
+		t.Errorf("Install should relocate setup.sh to the destination root (%v)", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dest, "escape.txt")); err != nil {
+		t.Errorf("Install should leave files outside the prefix in place (%v)", err)
+	}
+}
+
+func TestInstallerErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := writeRPM(t, dir, "Athena.rpm", fixtureRPM{
+		Name: "Athena", Version: "1", Release: "1",
+		Compression: "lzip",
+		Payload:     cpioPayload(t, "none", installerEntries),
+	})
+
+	err := (&Installer{}).Install(context.Background(), &RPMs{{Path: path}}, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "unsupported payload compression") {
+		t.Errorf("Install should fail on an unknown compression, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := (&Installer{}).Install(ctx, &RPMs{{Path: path}}, t.TempDir()); err == nil {
+		t.Errorf("Install should fail when interrupted, got nil")
+	}
+}
+
+func TestInstallerStaysInsideDest(t *testing.T) {
+	for name, entries := range map[string][]payloadEntry{
+		"absolute symlink": {
+			{Name: "./opt/evil", Link: "/outside"},
+			{Name: "./opt/evil/pwned.txt", Mode: cpio.TypeReg | 0644, Body: "pwned"},
+		},
+		"escaping symlink": {
+			{Name: "./opt/evil", Link: "../../outside"},
+			{Name: "./opt/evil/pwned.txt", Mode: cpio.TypeReg | 0644, Body: "pwned"},
+		},
+		"file through a symlink": {
+			{Name: "./opt/atlas", Mode: cpio.TypeDir | 0755},
+			{Name: "./opt/lib", Link: "atlas"},
+			{Name: "./opt/lib/pwned.txt", Mode: cpio.TypeReg | 0644, Body: "pwned"},
+		},
+		"directory through a symlink": {
+			{Name: "./opt/atlas", Mode: cpio.TypeDir | 0755},
+			{Name: "./opt/lib", Link: "atlas"},
+			{Name: "./opt/lib/sub", Mode: cpio.TypeDir | 0755},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := &RPM{Path: writeRPM(t, t.TempDir(), "evil.rpm", fixtureRPM{
+				Name: "evil", Version: "1", Release: "1",
+				Compression: "gzip",
+				Payload:     cpioPayload(t, "gzip", entries),
+			})}
+
+			dest := t.TempDir()
+			if err := (&Installer{}).Install(context.Background(), &RPMs{r}, dest); err == nil {
+				t.Errorf("Install should refuse the archive")
+			}
+
+			if err := r.ExtractTo(t.TempDir(), "*"); err == nil {
+				t.Errorf("ExtractTo should refuse the archive")
+			}
+
+			if _, err := os.Stat(filepath.Join(dest, "opt/atlas/pwned.txt")); !os.IsNotExist(err) {
+				t.Errorf("Install should not write through the symlink, got %v", err)
+			}
+		})
+	}
+
+	// A symlink already in the destination is not followed either
+	outside := t.TempDir()
+	dest := t.TempDir()
+	os.MkdirAll(filepath.Join(dest, "opt"), 0755)
+	os.Symlink(outside, filepath.Join(dest, "opt/evil"))
+
+	r := &RPM{Path: writeRPM(t, t.TempDir(), "evil.rpm", fixtureRPM{
+		Name: "evil", Version: "1", Release: "1",
+		Compression: "gzip",
+		Payload:     cpioPayload(t, "gzip", []payloadEntry{{Name: "./opt/evil/pwned.txt", Mode: cpio.TypeReg | 0644, Body: "pwned"}}),
+	})}
+	if err := (&Installer{}).Install(context.Background(), &RPMs{r}, dest); err == nil {
+		t.Errorf("Install should refuse to write through an existing symlink")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "pwned.txt")); !os.IsNotExist(err) {
+		t.Errorf("Install should not write outside the destination, got %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package rpm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// lockfileVersion is the version of the lockfile format written by WriteLockfile
+const lockfileVersion = 1
+
+// lockfile pins the exact packages of a resolved closure
+type lockfile struct {
+	Version  int         `json:"version"`
+	Packages []lockEntry `json:"packages"`
+}
+
+// lockEntry pins a single package by file name, NEVRA and checksum
+type lockEntry struct {
+	File   string `json:"file"`
+	NEVRA  string `json:"nevra"`
+	SHA256 string `json:"sha256"`
+}
+
+// WriteLockfile writes a JSON lockfile to w, pinning the NEVRA
+// and SHA-256 checksum of each of the RPMs
+func (r *RPMs) WriteLockfile(w io.Writer) error {
+	lock := lockfile{Version: lockfileVersion, Packages: []lockEntry{}}
+	for _, rr := range *r {
+		p, err := rr.header()
+		if err != nil {
+			return err
+		}
+
+		sum, err := rr.Checksum("sha256")
+		if err != nil {
+			return err
+		}
+
+		lock.Packages = append(lock.Packages, lockEntry{
+			File:   rr.Name(),
+			NEVRA:  nevra(p),
+			SHA256: sum,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(lock)
+}
+
+// VerifyLockfile checks that dir holds exactly the packages pinned by the
+// lockfile read from r, with identical checksums. It returns one
+// description per package that is missing or differs, followed by one per
+// RPM file of dir that the lockfile does not pin. A lockfile entry that is
+// not the name of a file directly in dir is an error.
+func VerifyLockfile(dir string, r io.Reader) ([]string, error) {
+	lock, err := readLockfile(r)
+	if err != nil {
+		return nil, err
+	}
+
+	pinned := map[string]struct{}{}
+	for _, entry := range lock.Packages {
+		if err := entry.check(); err != nil {
+			return nil, err
+		}
+		pinned[entry.File] = struct{}{}
+	}
+
+	var problems []string
+	for _, entry := range lock.Packages {
+		rr := &RPM{Path: filepath.Join(dir, entry.File)}
+		sum, err := rr.Checksum("sha256")
+		switch {
+		case os.IsNotExist(err):
+			problems = append(problems, fmt.Sprintf("%s: missing (pinned %s)", entry.File, entry.NEVRA))
+		case err != nil:
+			return nil, err
+		case sum != entry.SHA256:
+			problems = append(problems, fmt.Sprintf("%s: checksum differs from pinned %s", entry.File, entry.NEVRA))
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if _, keyExists := pinned[e.Name()]; !keyExists && !e.IsDir() && strings.HasSuffix(e.Name(), ".rpm") {
+			problems = append(problems, fmt.Sprintf("%s: unexpected (not pinned)", e.Name()))
+		}
+	}
+
+	return problems, nil
+}
+
+// WriteLock finds the RPMs for the project and platform, see Find,
+// and writes the lockfile pinning them to w, see RPMs.WriteLockfile
+func (f *Finder) WriteLock(w io.Writer, project, platform string) error {
+	rpms, err := f.Find(project, platform)
+	if err != nil {
+		return err
+	}
+
+	return rpms.WriteLockfile(w)
+}
+
+// FindLocked returns the RPMs pinned by the lockfile read from r, in the
+// order of the lockfile, instead of resolving the dependencies afresh.
+// Each pinned file must be in the Finder's directory with the pinned
+// NEVRA and checksum. Otherwise FindLocked fails, joining an
+// *ErrMissingDependency for each missing package and an error wrapping
+// ErrChecksumMismatch for each package that differs. A lockfile entry that
+// is not the name of a file directly in the directory is an error.
+func (f *Finder) FindLocked(r io.Reader) (*RPMs, error) {
+	return f.reportFound(f.findLocked(r))
+}
+
+func (f *Finder) findLocked(r io.Reader) (*RPMs, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	lock, err := readLockfile(r)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range lock.Packages {
+		if err := entry.check(); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		rpms RPMs
+		errs []error
+	)
+	for _, entry := range lock.Packages {
+		rr, err := newRPM(f.files(), f.files().Join(f.basedir, entry.File))
+		if errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, &ErrMissingDependency{Name: entry.NEVRA})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		p, err := rr.header()
+		if err != nil {
+			return nil, err
+		}
+
+		sum, err := rr.Checksum("sha256")
+		if err != nil {
+			return nil, err
+		}
+
+		if got := nevra(p); got != entry.NEVRA || sum != entry.SHA256 {
+			errs = append(errs, fmt.Errorf("%s: %w (pinned %s, found %s)", rr.Path, ErrChecksumMismatch, entry.NEVRA, got))
+			continue
+		}
+		rpms = append(rpms, rr)
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf(
+			"%d of %d pinned packages are missing or differ in %s:\n%w",
+			len(errs),
+			len(lock.Packages),
+			f.basedir,
+			errors.Join(errs...),
+		)
+	}
+
+	return &rpms, nil
+}
+
+// check ensures that the entry pins a file by its base name, so that it
+// cannot designate a file outside of the directory of the lockfile
+func (e lockEntry) check() error {
+	if e.File == "" || e.File == "." || e.File == ".." || e.File != filepath.Base(e.File) {
+		return fmt.Errorf("bad lockfile entry %q of %s: not a file name", e.File, e.NEVRA)
+	}
+
+	return nil
+}
+
+// readLockfile decodes a lockfile written by WriteLockfile
+func readLockfile(r io.Reader) (*lockfile, error) {
+	var lock lockfile
+	if err := json.NewDecoder(r).Decode(&lock); err != nil {
+		return nil, fmt.Errorf("failed to decode lockfile (%w)", err)
+	}
+
+	if lock.Version != lockfileVersion {
+		return nil, fmt.Errorf("unsupported lockfile version %d", lock.Version)
+	}
+
+	return &lock, nil
+}
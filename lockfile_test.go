@@ -0,0 +1,136 @@
+package rpm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLockfileRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	foo := writeRPM(t, dir, "foo.rpm", fixtureRPM{Name: "foo", Version: "1.0", Release: "1", Epoch: 2})
+	bar := writeRPM(t, dir, "bar.rpm", fixtureRPM{Name: "bar", Version: "1.0", Release: "1"})
+	rpms := &RPMs{&RPM{Path: foo}, &RPM{Path: bar}}
+
+	var buf bytes.Buffer
+	if err := rpms.WriteLockfile(&buf); err != nil {
+		t.Fatalf("WriteLockfile failed (%v)", err)
+	}
+
+	if !strings.Contains(buf.String(), "foo-2:1.0-1.x86_64") {
+		t.Errorf("WriteLockfile should pin foo-2:1.0-1.x86_64, got %s", buf.String())
+	}
+
+	lock := buf.String()
+	problems, err := VerifyLockfile(dir, strings.NewReader(lock))
+	if err != nil || len(problems) != 0 {
+		t.Errorf("VerifyLockfile should find no problem, got %v (%v)", problems, err)
+	}
+
+	os.Remove(bar)
+	writeRPM(t, dir, "foo.rpm", fixtureRPM{Name: "foo", Version: "1.0", Release: "2", Epoch: 2})
+
+	problems, err = VerifyLockfile(dir, strings.NewReader(lock))
+	if err != nil || len(problems) != 2 {
+		t.Errorf("VerifyLockfile should find 2 problems, got %v (%v)", problems, err)
+	}
+}
+
+func TestVerifyLockfileUnexpected(t *testing.T) {
+	dir := t.TempDir()
+	foo := writeRPM(t, dir, "foo.rpm", fixtureRPM{Name: "foo", Version: "1.0", Release: "1"})
+
+	var buf bytes.Buffer
+	if err := (&RPMs{&RPM{Path: foo}}).WriteLockfile(&buf); err != nil {
+		t.Fatalf("WriteLockfile failed (%v)", err)
+	}
+
+	writeRPM(t, dir, "foo-newer.rpm", fixtureRPM{Name: "foo", Version: "2.0", Release: "1"})
+
+	problems, err := VerifyLockfile(dir, &buf)
+	if err != nil || len(problems) != 1 || problems[0] != "foo-newer.rpm: unexpected (not pinned)" {
+		t.Errorf("VerifyLockfile should report the RPM not pinned, got %v (%v)", problems, err)
+	}
+}
+
+func TestVerifyLockfileBadInput(t *testing.T) {
+	if _, err := VerifyLockfile(t.TempDir(), strings.NewReader("blah")); err == nil {
+		t.Errorf("VerifyLockfile should fail on a malformed lockfile, got nil")
+	}
+
+	if _, err := VerifyLockfile(t.TempDir(), strings.NewReader(`{"version": 99}`)); err == nil {
+		t.Errorf("VerifyLockfile should fail on an unknown lockfile version, got nil")
+	}
+
+	for _, file := range []string{"", ".", "..", "../../etc/shadow", "/etc/shadow", "sub/foo.rpm"} {
+		lock := fmt.Sprintf(`{"version": 1, "packages": [{"file": %q, "nevra": "foo-1.0-1.x86_64"}]}`, file)
+		if _, err := VerifyLockfile(t.TempDir(), strings.NewReader(lock)); err == nil {
+			t.Errorf("VerifyLockfile should fail on the lockfile entry %q, got nil", file)
+		}
+	}
+}
+
+func TestFinderFindLocked(t *testing.T) {
+	dir := t.TempDir()
+	writeRPM(t, dir, "Athena_22.0.1_x86_64.rpm", fixtureRPM{
+		Name: "Athena", Version: "22.0.1", Release: "1",
+		Requires: []fixtureDep{{Name: "Gaudi.rpm"}, {Name: "tbb.rpm"}},
+	})
+	writeRPM(t, dir, "Gaudi.rpm", fixtureRPM{Name: "Gaudi", Version: "1.0", Release: "1"})
+	tbb := writeRPM(t, dir, "tbb.rpm", fixtureRPM{Name: "tbb", Version: "2020", Release: "1"})
+
+	f := NewFinder(dir)
+	var buf bytes.Buffer
+	if err := f.WriteLock(&buf, "Athena", "x86_64"); err != nil {
+		t.Fatalf("WriteLock failed (%v)", err)
+	}
+	lock := buf.String()
+
+	// A newer build appearing later is not picked up
+	writeRPM(t, dir, "Athena_22.0.2_x86_64.rpm", fixtureRPM{Name: "Athena", Version: "22.0.2", Release: "1"})
+
+	rpms, err := f.FindLocked(strings.NewReader(lock))
+	if err != nil {
+		t.Fatalf("FindLocked failed (%v)", err)
+	}
+
+	if got := strings.Join(rpms.Names(), ","); got != "Athena_22.0.1_x86_64.rpm,Gaudi.rpm,tbb.rpm" {
+		t.Errorf("FindLocked should return the pinned RPMs, got %s", got)
+	}
+
+	writeRPM(t, dir, "Gaudi.rpm", fixtureRPM{Name: "Gaudi", Version: "1.0", Release: "2"})
+	os.Remove(tbb)
+
+	_, err = f.FindLocked(strings.NewReader(lock))
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("FindLocked should return ErrChecksumMismatch for Gaudi, got %v", err)
+	}
+
+	var missing *ErrMissingDependency
+	if !errors.As(err, &missing) || missing.Name != "tbb-2020-1.x86_64" {
+		t.Errorf("FindLocked should return an ErrMissingDependency for tbb, got %v", err)
+	}
+}
+
+func TestFinderFindLockedOutsideDir(t *testing.T) {
+	parent := t.TempDir()
+	outside := writeRPM(t, parent, "outside.rpm", fixtureRPM{Name: "outside", Version: "1", Release: "1"})
+	dir := filepath.Join(parent, "repo")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := (&RPMs{&RPM{Path: outside}}).WriteLockfile(&buf); err != nil {
+		t.Fatalf("WriteLockfile failed (%v)", err)
+	}
+	lock := strings.Replace(buf.String(), `"outside.rpm"`, `"../outside.rpm"`, 1)
+
+	if rpms, err := NewFinder(dir).FindLocked(strings.NewReader(lock)); err == nil {
+		t.Errorf("FindLocked should reject the ../ lockfile entry, got %v", rpms.Names())
+	}
+}
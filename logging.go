@@ -0,0 +1,42 @@
+package rpm
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithLogger makes the Finder log its work to l: the glob patterns tried,
+// the top RPM selected and how each dependency is resolved at debug level,
+// and the time taken by each phase of a lookup at info level. A RemoteFinder
+// also hands l to its Downloader, unless that has a Logger of its own.
+// Nothing is logged by default.
+func WithLogger(l *slog.Logger) FinderOption {
+	return func(f *Finder) {
+		f.logger = l
+	}
+}
+
+// discardHandler is a slog.Handler that drops all records
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// discard is the logger used when none is set
+var discard = slog.New(discardHandler{})
+
+// orDiscard returns l, or a logger that drops all records if l is nil
+func orDiscard(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return discard
+	}
+
+	return l
+}
+
+// log returns the Finder's logger
+func (f *Finder) log() *slog.Logger {
+	return orDiscard(f.logger)
+}
@@ -0,0 +1,55 @@
+package rpm
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFinderWithLogger(t *testing.T) {
+	dir := createChainDir(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	f := NewFinder(dir, WithLogger(logger))
+	if _, err := f.Find("missing", "el9"); err == nil {
+		t.Fatalf("Find should fail for a missing project")
+	}
+
+	pattern := filepath.Join(dir, "missing_*_el9.rpm")
+	if !strings.Contains(buf.String(), "globbed top RPMs") || !strings.Contains(buf.String(), pattern) {
+		t.Errorf("Find should log the glob pattern %s, got\n%s", pattern, buf.String())
+	}
+
+	buf.Reset()
+	if _, err := f.Find("a", "el9"); err != nil {
+		t.Fatalf("Find failed (%v)", err)
+	}
+
+	for _, msg := range []string{"selected top RPM", "found top RPM", "resolved dependency by capability", "resolved dependencies"} {
+		if !strings.Contains(buf.String(), msg) {
+			t.Errorf("Find should log %q, got\n%s", msg, buf.String())
+		}
+	}
+}
+
+func TestInstallerLogger(t *testing.T) {
+	path := writeRPM(t, t.TempDir(), "Athena.rpm", fixtureRPM{
+		Name: "Athena", Version: "1", Release: "1",
+		Compression: "gzip",
+		Payload:     cpioPayload(t, "gzip", installerEntries),
+	})
+
+	var buf bytes.Buffer
+	in := &Installer{Logger: slog.New(slog.NewTextHandler(&buf, nil))}
+	if err := in.Install(context.Background(), &RPMs{{Path: path}}, t.TempDir()); err != nil {
+		t.Fatalf("Install failed (%v)", err)
+	}
+
+	if !strings.Contains(buf.String(), "msg=installed rpm=Athena.rpm") {
+		t.Errorf("Install should log the installed RPM, got\n%s", buf.String())
+	}
+}
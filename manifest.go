@@ -0,0 +1,152 @@
+package rpm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// manifestVersion is the version of the manifest format written by Manifest
+const manifestVersion = 1
+
+// Manifest records exactly what a set of RPMs holds, e.g. the packages
+// installed for a release, so that the set can be archived and later
+// reconstructed with LoadManifest
+type Manifest struct {
+	Version  int               `json:"version" yaml:"version"`
+	Packages []ManifestPackage `json:"packages" yaml:"packages"`
+}
+
+// ManifestPackage describes one RPM of a Manifest. Requires lists the
+// NEVRAs of the other packages of the manifest that it directly requires.
+type ManifestPackage struct {
+	Name     string   `json:"name" yaml:"name"`
+	NEVRA    string   `json:"nevra" yaml:"nevra"`
+	Arch     string   `json:"arch" yaml:"arch"`
+	Size     int64    `json:"size" yaml:"size"`
+	SHA256   string   `json:"sha256" yaml:"sha256"`
+	Path     string   `json:"path" yaml:"path"`
+	Requires []string `json:"requires,omitempty" yaml:"requires,omitempty"`
+}
+
+// Manifest reads the header and computes the SHA-256 checksum of each of
+// the RPMs, and describes them in order in a Manifest
+func (r *RPMs) Manifest() (*Manifest, error) {
+	sums, err := r.Checksums("sha256")
+	if err != nil {
+		return nil, err
+	}
+
+	edges, err := r.requireEdges()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{Version: manifestVersion, Packages: make([]ManifestPackage, len(*r))}
+	for i, rr := range *r {
+		p, err := rr.header()
+		if err != nil {
+			return nil, err
+		}
+
+		m.Packages[i] = ManifestPackage{
+			Name:   p.Name(),
+			NEVRA:  nevra(p),
+			Arch:   p.Architecture(),
+			Size:   rr.Size,
+			SHA256: sums[rr.Path],
+			Path:   rr.Path,
+		}
+	}
+
+	for i, deps := range edges {
+		for _, j := range deps {
+			m.Packages[i].Requires = append(m.Packages[i].Requires, m.Packages[j].NEVRA)
+		}
+	}
+
+	return m, nil
+}
+
+// WriteJSON writes the manifest to w as indented JSON
+func (m *Manifest) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// WriteYAML writes the manifest to w as YAML
+func (m *Manifest) WriteYAML(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(m); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// LoadManifest reads a manifest written by WriteJSON or WriteYAML,
+// telling the format apart by its first character
+func LoadManifest(r io.Reader) (*Manifest, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(data, &m)
+	} else {
+		err = yaml.Unmarshal(data, &m)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode manifest (%w)", err)
+	}
+
+	if m.Version != manifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d", m.Version)
+	}
+
+	return &m, nil
+}
+
+// RPMs reconstructs the set of RPMs described by the manifest, in order,
+// from the recorded paths. It fails if a file is missing or if its size
+// differs from the recorded one; use Verify to compare checksums too.
+func (m *Manifest) RPMs() (*RPMs, error) {
+	rpms := make(RPMs, len(m.Packages))
+	for i, pkg := range m.Packages {
+		rr, err := New(pkg.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		if rr.Size != pkg.Size {
+			return nil, fmt.Errorf("%s: size %d differs from %d in the manifest", pkg.Path, rr.Size, pkg.Size)
+		}
+		rpms[i] = rr
+	}
+
+	return &rpms, nil
+}
+
+// Verify checks the recorded checksum of each package of the manifest,
+// returning an error wrapping ErrChecksumMismatch for the first that differs
+func (m *Manifest) Verify() error {
+	for _, pkg := range m.Packages {
+		sum, err := (&RPM{Path: pkg.Path}).Checksum("sha256")
+		if err != nil {
+			return err
+		}
+
+		if sum != pkg.SHA256 {
+			return fmt.Errorf("%s: %w (manifest has sha256 %s, got %s)", pkg.Path, ErrChecksumMismatch, pkg.SHA256, sum)
+		}
+	}
+
+	return nil
+}
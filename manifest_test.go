@@ -0,0 +1,108 @@
+package rpm
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func createManifestRPMs(t *testing.T) *RPMs {
+	dir := t.TempDir()
+	var rpms RPMs
+	for _, spec := range []fixtureRPM{
+		{Name: "Athena", Version: "22.0.1", Release: "1", Requires: []fixtureDep{{Name: "libGaudi.so"}, {Name: "tbb"}}},
+		{Name: "Gaudi", Version: "1.0", Release: "1", Provides: []fixtureDep{{Name: "libGaudi.so"}}},
+		{Name: "tbb", Version: "2020", Release: "1", Arch: "noarch"},
+	} {
+		r, err := New(writeRPM(t, dir, spec.Name+".rpm", spec))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rpms = append(rpms, r)
+	}
+
+	return &rpms
+}
+
+func TestRPMsManifest(t *testing.T) {
+	rpms := createManifestRPMs(t)
+	m, err := rpms.Manifest()
+	if err != nil {
+		t.Fatalf("Manifest failed (%v)", err)
+	}
+
+	if len(m.Packages) != 3 {
+		t.Fatalf("Manifest should describe 3 packages, got %d", len(m.Packages))
+	}
+
+	athena := m.Packages[0]
+	if athena.NEVRA != "Athena-22.0.1-1.x86_64" || athena.Path != (*rpms)[0].Path || athena.Size != (*rpms)[0].Size {
+		t.Errorf("Manifest should describe Athena, got %+v", athena)
+	}
+
+	if got := strings.Join(athena.Requires, ","); got != "Gaudi-1.0-1.x86_64,tbb-2020-1.noarch" {
+		t.Errorf("Manifest should record the direct deps of Athena, got %s", got)
+	}
+
+	if sum, _ := (*rpms)[2].Checksum("sha256"); m.Packages[2].SHA256 != sum || m.Packages[2].Arch != "noarch" {
+		t.Errorf("Manifest should record the checksum and arch of tbb, got %+v", m.Packages[2])
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	rpms := createManifestRPMs(t)
+	m, err := rpms.Manifest()
+	if err != nil {
+		t.Fatalf("Manifest failed (%v)", err)
+	}
+
+	for format, write := range map[string]func(*Manifest, *bytes.Buffer) error{
+		"json": func(m *Manifest, buf *bytes.Buffer) error { return m.WriteJSON(buf) },
+		"yaml": func(m *Manifest, buf *bytes.Buffer) error { return m.WriteYAML(buf) },
+	} {
+		var buf bytes.Buffer
+		if err := write(m, &buf); err != nil {
+			t.Fatalf("writing the %s manifest failed (%v)", format, err)
+		}
+
+		loaded, err := LoadManifest(&buf)
+		if err != nil {
+			t.Fatalf("LoadManifest of %s failed (%v)", format, err)
+		}
+
+		if !reflect.DeepEqual(loaded, m) {
+			t.Errorf("LoadManifest of %s should return the written manifest, got %+v", format, loaded)
+		}
+
+		got, err := loaded.RPMs()
+		if err != nil || !reflect.DeepEqual(got.Paths(), rpms.Paths()) {
+			t.Errorf("RPMs of the %s manifest should reconstruct the set, got %v (%v)", format, got, err)
+		}
+	}
+
+	if err := m.Verify(); err != nil {
+		t.Errorf("Verify should succeed on unchanged files, got %v", err)
+	}
+
+	// Same size, different content
+	path := (*rpms)[1].Path
+	data, _ := os.ReadFile(path)
+	data[len(data)-1]++
+	os.WriteFile(path, data, 0644)
+	if err := m.Verify(); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Verify should return ErrChecksumMismatch, got %v", err)
+	}
+
+	os.Remove(path)
+	if _, err := m.RPMs(); err == nil {
+		t.Errorf("RPMs should fail for a missing %s, got nil", filepath.Base(path))
+	}
+
+	if _, err := LoadManifest(strings.NewReader(`{"version": 2}`)); err == nil {
+		t.Errorf("LoadManifest should reject an unknown version, got nil")
+	}
+}
@@ -0,0 +1,49 @@
+package rpm
+
+import (
+	"strings"
+)
+
+// FilenameMatch is the way in which dependencies are matched to RPM filenames
+type FilenameMatch int
+
+const (
+	// MatchExactFilename matches a dependency to the RPM file of the same name
+	MatchExactFilename FilenameMatch = iota
+
+	// MatchPackageName matches a dependency to any RPM file whose package
+	// name, parsed from a name-version-release.arch.rpm filename, equals it
+	MatchPackageName
+)
+
+// key returns the dependency name that the given RPM filename satisfies
+func (m FilenameMatch) key(filename string) string {
+	if m == MatchPackageName {
+		if name, ok := parseFilename(filename); ok {
+			return name
+		}
+	}
+
+	return filename
+}
+
+// parseFilename extracts the package name from
+// a name-version-release.arch.rpm filename
+func parseFilename(filename string) (string, bool) {
+	base := strings.TrimSuffix(filename, ".rpm")
+	dot := strings.LastIndex(base, ".")
+	if base == filename || dot < 0 {
+		return "", false
+	}
+
+	nvr := base[:dot]
+	for i := 0; i < 2; i++ {
+		dash := strings.LastIndex(nvr, "-")
+		if dash <= 0 {
+			return "", false
+		}
+		nvr = nvr[:dash]
+	}
+
+	return nvr, true
+}
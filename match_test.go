@@ -0,0 +1,51 @@
+package rpm
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestParseFilename(t *testing.T) {
+	tests := map[string]string{
+		"libfoo-1.0-1.x86_64.rpm":             "libfoo",
+		"lcg-gcc-tools-11.2-3.el9.noarch.rpm": "lcg-gcc-tools",
+		"foo-1.0.x86_64.rpm":                  "",
+		"foo-1.0-1.x86_64":                    "",
+		"noarch.rpm":                          "",
+	}
+
+	for filename, expect := range tests {
+		got, ok := parseFilename(filename)
+		if got != expect || ok != (expect != "") {
+			t.Errorf("parseFilename(%s) should return %q, got %q (%t)", filename, expect, got, ok)
+		}
+	}
+}
+
+func TestFinderMatchPackageName(t *testing.T) {
+	dir := t.TempDir()
+	writeRPM(t, dir, "project_1.0_el9.rpm", fixtureRPM{
+		Name: "project", Version: "1.0", Release: "1",
+		Requires: []fixtureDep{{Name: "libfoo"}},
+	})
+	dep := writeRPM(t, dir, "libfoo-1.0-1.x86_64.rpm", fixtureRPM{Name: "libfoo", Version: "1.0", Release: "1"})
+
+	rpms, err := NewFinder(dir, WithMatchStrategy(FilenameOnly)).Find("project", "el9")
+	if err != nil {
+		t.Fatalf("Find failed (%v)", err)
+	}
+
+	if len(*rpms) != 1 {
+		t.Errorf("Find with exact filename matching should not match %s, got %v", filepath.Base(dep), rpms.Names())
+	}
+
+	f := NewFinder(dir, WithMatchStrategy(FilenameOnly), WithFilenameMatch(MatchPackageName))
+	rpms, err = f.Find("project", "el9")
+	if err != nil {
+		t.Fatalf("Find failed (%v)", err)
+	}
+
+	if got := rpms.Paths(); len(got) != 2 || got[1] != dep {
+		t.Errorf("Find with package name matching should return %s, got %v", dep, got)
+	}
+}
@@ -0,0 +1,218 @@
+package rpm
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WithFastestMirror makes a RemoteFinder probe the base URLs of its repo
+// and use them fastest first, instead of in the order of the repo. It has
+// no effect on a Finder of a local directory.
+func WithFastestMirror() FinderOption {
+	return func(f *Finder) {
+		f.fastestMirror = true
+	}
+}
+
+// BaseURLs returns the base URLs of the repo in order of preference: URL,
+// then Mirrors, then those listed by the MirrorList and Metalink files,
+// which are fetched on each call. Failing to fetch those is only an error
+// if the repo has no other base URL.
+func (r Repo) BaseURLs(ctx context.Context) ([]string, error) {
+	var bases []string
+	if r.URL != "" {
+		bases = append(bases, r.URL)
+	}
+	bases = append(bases, strings.Fields(r.Mirrors)...)
+
+	var errs []error
+	for _, src := range []struct {
+		url   string
+		parse func(io.Reader) ([]string, error)
+	}{
+		{r.MirrorList, parseMirrorList},
+		{r.Metalink, parseMetalink},
+	} {
+		if src.url == "" {
+			continue
+		}
+
+		listed, err := fetchMirrors(ctx, src.url, src.parse)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		bases = append(bases, listed...)
+	}
+
+	if bases = unique(bases); len(bases) == 0 {
+		if len(errs) > 0 {
+			return nil, errors.Join(errs...)
+		}
+		return nil, fmt.Errorf("repo %s has no baseurl, mirrorlist or metalink", r.Label)
+	}
+
+	return bases, nil
+}
+
+// at returns the repo with the given base URL as its only one
+func (r Repo) at(base string) Repo {
+	r.URL, r.Mirrors, r.MirrorList, r.Metalink = base, "", "", ""
+	return r
+}
+
+func fetchMirrors(ctx context.Context, url string, parse func(io.Reader) ([]string, error)) ([]string, error) {
+	body, err := getURL(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	bases, err := parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s (%w)", url, err)
+	}
+
+	return bases, nil
+}
+
+// parseMirrorList reads a yum mirrorlist file: one base URL per line,
+// blank lines and comments being ignored
+func parseMirrorList(r io.Reader) ([]string, error) {
+	var bases []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			bases = append(bases, line)
+		}
+	}
+
+	return bases, scanner.Err()
+}
+
+// metalink is a metalink file, listing the URLs of the repomd.xml of mirrors
+type metalink struct {
+	Files []struct {
+		Name string `xml:"name,attr"`
+		URLs []struct {
+			Protocol   string `xml:"protocol,attr"`
+			Preference int    `xml:"preference,attr"`
+			Location   string `xml:",chardata"`
+		} `xml:"resources>url"`
+	} `xml:"files>file"`
+}
+
+// parseMetalink reads the http and https mirrors of a metalink file,
+// most preferred first, as the base URLs of their repomd.xml
+func parseMetalink(r io.Reader) ([]string, error) {
+	var ml metalink
+	if err := xml.NewDecoder(r).Decode(&ml); err != nil {
+		return nil, err
+	}
+
+	for _, file := range ml.Files {
+		if file.Name != "repomd.xml" {
+			continue
+		}
+
+		urls := file.URLs
+		sort.SliceStable(urls, func(i, j int) bool {
+			return urls[i].Preference > urls[j].Preference
+		})
+
+		var bases []string
+		for _, u := range urls {
+			if u.Protocol != "http" && u.Protocol != "https" {
+				continue
+			}
+			bases = append(bases, strings.TrimSuffix(strings.TrimSpace(u.Location), repomdPath))
+		}
+		return bases, nil
+	}
+
+	return nil, fmt.Errorf("no repomd.xml listed")
+}
+
+// eachMirror calls fn with each base URL in turn, until one succeeds or
+// fails for another reason than a network error or a temporary server
+// error, see failover. It returns the index of the last base URL tried.
+func eachMirror(ctx context.Context, bases []string, fn func(base string) error) (int, error) {
+	var err error
+	for i, base := range bases {
+		if err = fn(base); err == nil || !failover(ctx, err) {
+			return i, err
+		}
+	}
+
+	return len(bases) - 1, err
+}
+
+// failover indicates if another mirror should be tried after the error
+func failover(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var de *downloadError
+	if errors.As(err, &de) {
+		return de.temporary()
+	}
+
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+// probeMirrors orders the base URLs by the time taken to fetch their
+// repomd.xml, fastest first, those that fail to answer coming last
+func probeMirrors(ctx context.Context, bases []string, concurrency int) []string {
+	latencies := make([]time.Duration, len(bases))
+	forEach(ctx, len(bases), concurrency, func(i int) error {
+		latencies[i] = probe(ctx, Repo{URL: bases[i]}.packageURL(repomdPath))
+		return nil
+	})
+
+	sorted := append([]string(nil), bases...)
+	order := make(map[string]time.Duration, len(bases))
+	for i, base := range bases {
+		order[base] = latencies[i]
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return order[sorted[i]] < order[sorted[j]]
+	})
+
+	return sorted
+}
+
+// unreachable is the latency of a mirror that fails to answer
+const unreachable = time.Duration(1<<63 - 1)
+
+// probe returns the time taken by a HEAD request to url
+func probe(ctx context.Context, url string) time.Duration {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return unreachable
+	}
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return unreachable
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return unreachable
+	}
+
+	return time.Since(start)
+}
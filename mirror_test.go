@@ -0,0 +1,162 @@
+package rpm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testMetalink = `<?xml version="1.0" encoding="utf-8"?>
+<metalink version="3.0" xmlns="http://www.metalinker.org/">
+ <files>
+  <file name="repomd.xml">
+   <resources maxconnections="1">
+    <url protocol="rsync" type="rsync" preference="100">rsync://rsync.mirror/atlas/repodata/repomd.xml</url>
+    <url protocol="https" type="https" preference="90">https://second.mirror/atlas/repodata/repomd.xml</url>
+    <url protocol="https" type="https" preference="99">https://first.mirror/atlas/repodata/repomd.xml</url>
+   </resources>
+  </file>
+ </files>
+</metalink>`
+
+func TestRepoBaseURLs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/mirrorlist":
+			fmt.Fprint(w, "# ATLAS mirrors\nhttps://listed.mirror/atlas/\n\nhttps://backup.repo\n")
+		case "/metalink":
+			fmt.Fprint(w, testMetalink)
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer srv.Close()
+
+	repo := Repo{
+		Label:      "atlas",
+		URL:        "https://atlas.repo",
+		Mirrors:    "https://backup.repo https://other.repo",
+		MirrorList: srv.URL + "/mirrorlist",
+		Metalink:   srv.URL + "/metalink",
+	}
+	got, err := repo.BaseURLs(context.Background())
+	expect := []string{
+		"https://atlas.repo",
+		"https://backup.repo",
+		"https://other.repo",
+		"https://listed.mirror/atlas/",
+		"https://first.mirror/atlas/",
+		"https://second.mirror/atlas/",
+	}
+	if err != nil || !reflect.DeepEqual(got, expect) {
+		t.Errorf("BaseURLs should return %v, got %v (%v)", expect, got, err)
+	}
+
+	// An unavailable mirrorlist is only an error without any other base URL
+	repo = Repo{Label: "atlas", URL: "https://atlas.repo", MirrorList: srv.URL + "/gone"}
+	if got, err := repo.BaseURLs(context.Background()); err != nil || len(got) != 1 {
+		t.Errorf("BaseURLs should fall back to the baseurl, got %v (%v)", got, err)
+	}
+
+	repo.URL = ""
+	if _, err := repo.BaseURLs(context.Background()); err == nil {
+		t.Errorf("BaseURLs should fail without any base URL, got nil")
+	}
+}
+
+func TestParseRepoMirrors(t *testing.T) {
+	input := "[atlas]\nname=ATLAS\nbaseurl=https://atlas.repo, https://backup.repo https://other.repo\n" +
+		"mirrorlist=https://atlas.repo/mirrorlist\nmetalink=https://atlas.repo/metalink\n"
+	got, err := ParseRepo(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseRepo failed (%v)", err)
+	}
+
+	if got.URL != "https://atlas.repo" || got.Mirrors != "https://backup.repo https://other.repo" ||
+		got.MirrorList != "https://atlas.repo/mirrorlist" || got.Metalink != "https://atlas.repo/metalink" {
+		t.Errorf("ParseRepo should parse the mirrors, got %+v", got)
+	}
+
+	if again, err := ParseRepo(strings.NewReader(got.String())); err != nil || again != got {
+		t.Errorf("ParseRepo should reproduce %+v, got %+v (%v)", got, again, err)
+	}
+}
+
+// countingServer serves handler, counting the requests other than HEAD
+func countingServer(t *testing.T, handler http.Handler) (*httptest.Server, *int32) {
+	var count int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodHead {
+			atomic.AddInt32(&count, 1)
+		}
+		handler.ServeHTTP(w, req)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &count
+}
+
+func TestRemoteFinderMirrorFailover(t *testing.T) {
+	good := createRemoteRepo(t)
+	down, _ := countingServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	// The first mirror serves the repodata, but none of the packages
+	dir := t.TempDir()
+	partial := serveRemoteRepo(t, dir)
+	flaky, _ := countingServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasPrefix(req.URL.Path, "/Packages/") {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		http.Redirect(w, req, partial.URL+req.URL.Path, http.StatusFound)
+	}))
+
+	repo := Repo{URL: down.URL, Mirrors: flaky.URL + " " + good.URL}
+	d := &Downloader{Retries: -1}
+	rpms, err := NewRemoteFinder(repo, t.TempDir(), WithDownloader(d)).Find("Athena", "x86_64")
+	if err != nil || len(*rpms) != 2 {
+		t.Fatalf("Find should fail over to the working mirrors, got %v (%v)", rpms, err)
+	}
+
+	cacheDir := t.TempDir()
+	if _, err := (Repo{URL: down.URL, Mirrors: good.URL, Prefix: "Packages"}).Download(context.Background(), "tbb-2020.rpm", cacheDir); err != nil {
+		t.Errorf("Download should fail over to the next mirror, got %v", err)
+	}
+
+	// A missing package is not looked for on other mirrors
+	if _, err := (Repo{URL: good.URL, Mirrors: down.URL}).Download(context.Background(), "none.rpm", cacheDir); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Download should fail on 404, got %v", err)
+	}
+}
+
+func TestRemoteFinderWithFastestMirror(t *testing.T) {
+	dir := t.TempDir()
+	serveRemoteRepo(t, dir)
+	files := http.FileServer(http.Dir(dir))
+	slow, slowCount := countingServer(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		files.ServeHTTP(w, req)
+	}))
+	fast, fastCount := countingServer(t, files)
+
+	repo := Repo{URL: slow.URL, Mirrors: fast.URL}
+	if _, err := NewRemoteFinder(repo, t.TempDir(), WithFastestMirror()).Find("Athena", "x86_64"); err != nil {
+		t.Fatalf("Find failed (%v)", err)
+	}
+
+	if atomic.LoadInt32(slowCount) != 0 || atomic.LoadInt32(fastCount) == 0 {
+		t.Errorf("Find should only use the fastest mirror, got %d requests to the slow one and %d to the fast one",
+			atomic.LoadInt32(slowCount), atomic.LoadInt32(fastCount))
+	}
+
+	if got := probeMirrors(context.Background(), []string{"http://127.0.0.1:1", fast.URL}, 2); got[0] != fast.URL {
+		t.Errorf("probeMirrors should put unreachable mirrors last, got %v", got)
+	}
+}
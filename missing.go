@@ -0,0 +1,88 @@
+package rpm
+
+import (
+	"path"
+)
+
+// DefaultAssumedPresent are the capabilities, as path.Match patterns,
+// that a Finder assumes to be provided by the base operating system
+var DefaultAssumedPresent = []string{
+	"/bin/*",
+	"/sbin/*",
+	"/usr/bin/*",
+	"/usr/sbin/*",
+	"config(*)",
+	"rpmlib(*)",
+	"rtld(*)",
+	"ld-linux*.so*",
+	"libc.so*",
+	"libdl.so*",
+	"libgcc_s.so*",
+	"libm.so*",
+	"libpthread.so*",
+	"libresolv.so*",
+	"librt.so*",
+	"libstdc++.so*",
+	"libutil.so*",
+	"libz.so*",
+}
+
+// WithStrict makes Find fail when a dependency of the top RPM can neither
+// be found locally nor is assumed to be present on the system
+func WithStrict() FinderOption {
+	return func(f *Finder) {
+		f.strict = true
+	}
+}
+
+// WithAssumedPresent replaces DefaultAssumedPresent as the capability
+// patterns that are never reported as missing dependencies
+func WithAssumedPresent(patterns ...string) FinderOption {
+	return func(f *Finder) {
+		f.assumed = patterns
+	}
+}
+
+// assumedPresent indicates if the capability matches
+// one of the patterns assumed to be present
+func (f *Finder) assumedPresent(capability string) bool {
+	for _, pattern := range f.assumed {
+		if ok, _ := path.Match(pattern, capability); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reportMissing drops the capabilities that are assumed present
+func (f *Finder) reportMissing(missing []string) []string {
+	var report []string
+	for _, capability := range missing {
+		if !f.assumedPresent(capability) {
+			f.log().Debug("dependency not found", "name", capability)
+			report = append(report, capability)
+		} else {
+			f.log().Debug("dependency assumed present", "name", capability)
+		}
+	}
+
+	return report
+}
+
+// unmatched returns the dependency names that none of the found filenames satisfies
+func unmatched(names, found []string, match FilenameMatch) []string {
+	keys := map[string]struct{}{}
+	for _, filename := range found {
+		keys[match.key(filename)] = struct{}{}
+	}
+
+	var missing []string
+	for _, name := range names {
+		if _, keyExists := keys[name]; !keyExists {
+			missing = append(missing, name)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,54 @@
+package rpm
+
+import (
+	"strings"
+	"testing"
+)
+
+func createMissingDir(t *testing.T) string {
+	dir := t.TempDir()
+	writeRPM(t, dir, "project_1.0_el9.rpm", fixtureRPM{
+		Name: "project", Version: "1.0", Release: "1",
+		Requires: []fixtureDep{
+			{Name: "dep.rpm"},
+			{Name: "/bin/sh"},
+			{Name: "rtld(GNU_HASH)"},
+			{Name: "libc.so.6(GLIBC_2.34)(64bit)"},
+			{Name: "libAthena.so"},
+		},
+	})
+	writeRPM(t, dir, "dep.rpm", fixtureRPM{Name: "dep", Version: "1", Release: "1"})
+
+	return dir
+}
+
+func TestFindStrict(t *testing.T) {
+	dir := createMissingDir(t)
+
+	if _, err := NewFinder(dir).Find("project", "el9"); err != nil {
+		t.Errorf("Find should not fail on missing dependencies by default, got %v", err)
+	}
+
+	_, err := NewFinder(dir, WithStrict()).Find("project", "el9")
+	if err == nil {
+		t.Fatalf("Find in strict mode should have failed, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "1 rpm dependencies") || !strings.HasSuffix(err.Error(), "\nlibAthena.so") {
+		t.Errorf("Find in strict mode should only report libAthena.so, got %v", err)
+	}
+}
+
+func TestFindStrictAssumedPresent(t *testing.T) {
+	dir := createMissingDir(t)
+
+	f := NewFinder(dir, WithStrict(), WithAssumedPresent("/bin/sh", "rtld(*)", "lib*"))
+	if _, err := f.Find("project", "el9"); err != nil {
+		t.Errorf("Find in strict mode should find all dependencies assumed present, got %v", err)
+	}
+
+	f = NewFinder(dir, WithStrict(), WithAssumedPresent())
+	if _, err := f.Find("project", "el9"); err == nil || !strings.Contains(err.Error(), "4 rpm dependencies") {
+		t.Errorf("Find in strict mode without assumed capabilities should report 4 missing, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package rpm
+
+// InstallOrder returns the RPMs sorted such that every package comes after
+// the packages of the collection that provide its requires. RPMs without an
+// ordering constraint between them keep their relative order, except the
+// first one, i.e. the top RPM in the output of Finder.Find, which comes
+// last unless another RPM requires it. A dependency cycle is an error.
+func (r RPMs) InstallOrder() (RPMs, error) {
+	g, err := BuildGraph(&r)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.InstallOrder()
+}
+
+// requireEdges lists, for each RPM of the collection, the indexes of
+// the other RPMs of the collection that provide one of its requires
+func (r RPMs) requireEdges() ([][]int, error) {
+	providers := map[string][]int{}
+	for i := range r {
+		p, err := r[i].header()
+		if err != nil {
+			return nil, err
+		}
+
+		providers[p.Name()] = append(providers[p.Name()], i)
+		for _, prov := range p.Provides() {
+			if prov.Name() != p.Name() {
+				providers[prov.Name()] = append(providers[prov.Name()], i)
+			}
+		}
+	}
+
+	edges := make([][]int, len(r))
+	for i := range r {
+		p, _ := r[i].header()
+		seen := map[int]struct{}{i: {}}
+		for _, req := range p.Requires() {
+			for _, j := range providers[req.Name()] {
+				if _, keyExists := seen[j]; !keyExists {
+					seen[j] = struct{}{}
+					edges[i] = append(edges[i], j)
+				}
+			}
+		}
+	}
+
+	return edges, nil
+}
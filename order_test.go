@@ -0,0 +1,64 @@
+package rpm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRPMsInstallOrder(t *testing.T) {
+	dir := t.TempDir()
+	paths := []string{
+		writeRPM(t, dir, "top.rpm", fixtureRPM{Name: "top", Requires: []fixtureDep{{Name: "libmid.so"}, {Name: "base"}}}),
+		writeRPM(t, dir, "base.rpm", fixtureRPM{Name: "base", Requires: []fixtureDep{{Name: "/bin/sh"}}}),
+		writeRPM(t, dir, "mid.rpm", fixtureRPM{
+			Name:     "mid",
+			Requires: []fixtureDep{{Name: "base"}},
+			Provides: []fixtureDep{{Name: "libmid.so"}},
+		}),
+		writeRPM(t, dir, "extra.rpm", fixtureRPM{Name: "extra"}),
+	}
+
+	var rpms RPMs
+	for _, path := range paths {
+		r, err := New(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rpms = append(rpms, r)
+	}
+
+	ordered, err := rpms.InstallOrder()
+	if err != nil {
+		t.Fatalf("InstallOrder failed (%v)", err)
+	}
+
+	got := strings.Join(ordered.Names(), ",")
+	if got != "base.rpm,mid.rpm,extra.rpm,top.rpm" {
+		t.Errorf("InstallOrder should return base.rpm,mid.rpm,extra.rpm,top.rpm, got %s", got)
+	}
+
+	if ordered, err := (RPMs{}).InstallOrder(); err != nil || len(ordered) != 0 {
+		t.Errorf("InstallOrder of nothing should return nothing, got %v (%v)", ordered, err)
+	}
+}
+
+func TestRPMsInstallOrderCycle(t *testing.T) {
+	dir := t.TempDir()
+	var rpms RPMs
+	for _, spec := range []fixtureRPM{
+		{Name: "top", Requires: []fixtureDep{{Name: "a"}}},
+		{Name: "a", Requires: []fixtureDep{{Name: "b"}}},
+		{Name: "b", Requires: []fixtureDep{{Name: "a"}}},
+	} {
+		r, err := New(writeRPM(t, dir, spec.Name+".rpm", spec))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rpms = append(rpms, r)
+	}
+
+	_, err := rpms.InstallOrder()
+	if err == nil || !strings.Contains(err.Error(), "a.rpm -> b.rpm -> a.rpm") {
+		t.Errorf("InstallOrder should report the a/b cycle, got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package rpm
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultPattern is the file name template of top RPMs, in which the
+// two %s placeholders stand for the project and the platform
+const DefaultPattern = "%s_*_%s.rpm"
+
+// WithPattern sets the file name template of top RPMs, DefaultPattern if
+// not set. The template must hold exactly two %s placeholders, for the
+// project and the platform, in that order, e.g. "%s-*-%s.rpm". An invalid
+// template makes every subsequent top RPM lookup fail.
+func WithPattern(pattern string) FinderOption {
+	return func(f *Finder) {
+		f.pattern = pattern
+		f.err = validatePattern(pattern)
+	}
+}
+
+// validatePattern checks that pattern holds the project and platform
+// placeholders, no other formatting verb, and is a valid glob once filled in
+func validatePattern(pattern string) error {
+	var verbs int
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' {
+			continue
+		}
+
+		if i+1 == len(pattern) {
+			return fmt.Errorf("invalid RPM pattern %q (dangling %%)", pattern)
+		}
+
+		i++
+		switch pattern[i] {
+		case '%':
+		case 's':
+			verbs++
+		default:
+			return fmt.Errorf("invalid RPM pattern %q (unsupported verb %%%c)", pattern, pattern[i])
+		}
+	}
+
+	if verbs != 2 {
+		return fmt.Errorf(
+			"invalid RPM pattern %q (expected 2 %%s placeholders for project and platform, got %d)",
+			pattern,
+			verbs,
+		)
+	}
+
+	if strings.ContainsRune(pattern, filepath.Separator) {
+		return fmt.Errorf("invalid RPM pattern %q (must not contain a path separator)", pattern)
+	}
+
+	if _, err := filepath.Match(fmt.Sprintf(pattern, "project", "platform"), ""); err != nil {
+		return fmt.Errorf("invalid RPM pattern %q (%w)", pattern, err)
+	}
+
+	return nil
+}
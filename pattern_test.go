@@ -0,0 +1,44 @@
+package rpm
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidatePattern(t *testing.T) {
+	valid := []string{DefaultPattern, "%s-*-%s.rpm", "%s_*_100%%_%s.rpm"}
+	for _, pattern := range valid {
+		if err := validatePattern(pattern); err != nil {
+			t.Errorf("validatePattern(%q) should succeed, got %v", pattern, err)
+		}
+	}
+
+	invalid := []string{"%s_*.rpm", "%s_%s_%s.rpm", "%s_%d_%s.rpm", "%s_*_%s.rpm%", "%s/*_%s.rpm", "%s_[_%s.rpm"}
+	for _, pattern := range invalid {
+		if err := validatePattern(pattern); err == nil {
+			t.Errorf("validatePattern(%q) should fail, got nil", pattern)
+		}
+	}
+}
+
+func TestFinderWithPattern(t *testing.T) {
+	dir := t.TempDir()
+	writeRPM(t, dir, "Athena_22.0.1_x86_64.rpm", fixtureRPM{Name: "Athena", Version: "22.0.1", Release: "1"})
+	writeRPM(t, dir, "Athena-22.0.2-x86_64.rpm", fixtureRPM{Name: "Athena", Version: "22.0.2", Release: "1"})
+
+	got, err := NewFinder(dir).TopRPM("Athena", "x86_64")
+	if err != nil || filepath.Base(got) != "Athena_22.0.1_x86_64.rpm" {
+		t.Errorf("TopRPM should use the default pattern, got %s (%v)", got, err)
+	}
+
+	got, err = NewFinder(dir, WithPattern("%s-*-%s.rpm")).TopRPM("Athena", "x86_64")
+	if err != nil || filepath.Base(got) != "Athena-22.0.2-x86_64.rpm" {
+		t.Errorf("TopRPM should use the custom pattern, got %s (%v)", got, err)
+	}
+
+	_, err = NewFinder(dir, WithPattern("%s-*.rpm")).Find("Athena", "x86_64")
+	if err == nil || !strings.Contains(err.Error(), "invalid RPM pattern") {
+		t.Errorf("Find should report the invalid pattern, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package rpm
+
+import (
+	"bufio"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/cavaliergopher/cpio"
+	"github.com/cavaliergopher/rpm"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// decompress wraps the payload stream in a reader of the
+// given compression, as recorded in the package header
+func decompress(compression string, r io.Reader) (io.ReadCloser, error) {
+	switch compression {
+	case "", "gzip":
+		return gzip.NewReader(r)
+	case "bzip2":
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	case "xz":
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(xr), nil
+	case "lzma":
+		lr, err := lzma.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(lr), nil
+	case "zstd":
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	case "none", "identity":
+		return io.NopCloser(r), nil
+	}
+
+	return nil, fmt.Errorf("unsupported payload compression %q", compression)
+}
+
+// walkPayload calls fn for each entry of the cpio payload of the RPM, with
+// a reader of the entry content. Iteration stops at the first error of fn.
+func (r *RPM) walkPayload(fn func(p *rpm.Package, hdr *cpio.Header, body io.Reader) error) error {
+	f, err := r.open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	p, err := rpm.Read(br)
+	if err != nil {
+		return fmt.Errorf("failed to read rpm header of %s (%w)", r.Path, err)
+	}
+
+	if format := p.PayloadFormat(); format != "" && format != "cpio" {
+		return fmt.Errorf("%s: unsupported payload format %q", r.Name(), format)
+	}
+
+	payload, err := decompress(p.PayloadCompression(), br)
+	if err != nil {
+		return fmt.Errorf("failed to read payload of %s (%w)", r.Path, err)
+	}
+	defer payload.Close()
+
+	archive := cpio.NewReader(payload)
+	for {
+		hdr, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read payload of %s (%w)", r.Path, err)
+		}
+
+		if err := fn(p, hdr, archive); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package rpm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Platform is an ATLAS platform, such as x86_64-centos7-gcc11-opt:
+// the architecture, operating system, compiler and build type of a build
+type Platform struct {
+	Arch      string
+	OS        string
+	Compiler  string
+	BuildType string
+}
+
+// buildTypes are the valid build types of a platform
+var buildTypes = map[string]struct{}{
+	"opt": {},
+	"dbg": {},
+}
+
+// rpmArchs maps the architecture aliases of platforms to RPM architectures
+var rpmArchs = map[string]string{
+	"amd64": "x86_64",
+	"arm64": "aarch64",
+	"i386":  "i686",
+}
+
+// elFamily are the names of the Enterprise Linux rebuilds, whose
+// releases of the same major version are binary compatible
+var elFamily = []string{"el", "centos", "alma", "rocky", "rhel"}
+
+// oldestEL is the oldest Enterprise Linux release of compatible platforms
+const oldestEL = 7
+
+// ParsePlatform parses a platform string of the form
+// arch-os-compiler-buildtype. The OS and compiler are a name followed by a
+// version, e.g. el9 or gcc13, and the build type is either opt or dbg.
+func ParsePlatform(s string) (Platform, error) {
+	fields := strings.Split(s, "-")
+	if len(fields) != 4 {
+		return Platform{}, fmt.Errorf("invalid platform %q (expected arch-os-compiler-buildtype)", s)
+	}
+
+	p := Platform{Arch: fields[0], OS: fields[1], Compiler: fields[2], BuildType: fields[3]}
+	if p.Arch == "" {
+		return Platform{}, fmt.Errorf("invalid platform %q (no architecture)", s)
+	}
+
+	for _, field := range []struct{ kind, value string }{{"OS", p.OS}, {"compiler", p.Compiler}} {
+		if name, version := splitVersioned(field.value); name == "" || version == "" {
+			return Platform{}, fmt.Errorf("invalid platform %q (bad %s %q)", s, field.kind, field.value)
+		}
+	}
+
+	if _, ok := buildTypes[p.BuildType]; !ok {
+		return Platform{}, fmt.Errorf("invalid platform %q (unknown build type %q)", s, p.BuildType)
+	}
+
+	return p, nil
+}
+
+// String returns the platform string, e.g. x86_64-el9-gcc13-opt
+func (p Platform) String() string {
+	return strings.Join([]string{p.Arch, p.OS, p.Compiler, p.BuildType}, "-")
+}
+
+// RPMArch returns the RPM architecture of the platform, e.g. aarch64 for arm64
+func (p Platform) RPMArch() string {
+	if arch, ok := rpmArchs[p.Arch]; ok {
+		return arch
+	}
+
+	return p.Arch
+}
+
+// Compatible returns the platforms whose builds run on p, in order of
+// preference: p itself, the same release of another Enterprise Linux
+// rebuild (e.g. alma9 for el9), then older releases, newest first, down to
+// el7 and centos7. All share the architecture, compiler and build type of p.
+// For other operating systems, only p is returned.
+func (p Platform) Compatible() []Platform {
+	compatible := []Platform{p}
+
+	release, ok := elRelease(p.OS)
+	if !ok || release < oldestEL {
+		return compatible
+	}
+
+	for r := release; r >= oldestEL; r-- {
+		for _, name := range elFamily {
+			if os := name + strconv.Itoa(r); os != p.OS {
+				q := p
+				q.OS = os
+				compatible = append(compatible, q)
+			}
+		}
+	}
+
+	return compatible
+}
+
+// elRelease returns the major release of os, if an Enterprise Linux rebuild
+func elRelease(os string) (int, bool) {
+	name, version := splitVersioned(os)
+	for _, member := range elFamily {
+		if name == member {
+			release, err := strconv.Atoi(version)
+			return release, err == nil
+		}
+	}
+
+	return 0, false
+}
+
+// WithCompatiblePlatforms makes the Finder fall back, when no top RPM
+// matches the requested platform, to those of the first of its Compatible
+// platforms with a match, e.g. to x86_64-centos7-gcc11-opt RPMs for
+// x86_64-el9-gcc11-opt. Platforms that ParsePlatform rejects are matched
+// as they are.
+func WithCompatiblePlatforms() FinderOption {
+	return func(f *Finder) {
+		f.compatible = true
+	}
+}
+
+// platforms returns the platforms of which the Finder accepts the top
+// RPMs for the given one, in order of preference
+func (f *Finder) platforms(platform string) []string {
+	if !f.compatible {
+		return []string{platform}
+	}
+
+	p, err := ParsePlatform(platform)
+	if err != nil {
+		return []string{platform}
+	}
+
+	var platforms []string
+	for _, q := range p.Compatible() {
+		platforms = append(platforms, q.String())
+	}
+
+	return platforms
+}
+
+// splitVersioned splits a name followed by a version, such as gcc13,
+// returning empty strings if s is not of that form
+func splitVersioned(s string) (string, string) {
+	i := strings.IndexAny(s, "0123456789")
+	if i <= 0 {
+		return "", ""
+	}
+
+	for _, c := range s[:i] {
+		if c < 'a' || c > 'z' {
+			return "", ""
+		}
+	}
+
+	return s[:i], s[i:]
+}
@@ -0,0 +1,71 @@
+package rpm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParsePlatform(t *testing.T) {
+	p, err := ParsePlatform("arm64-el9-gcc13-dbg")
+	if err != nil {
+		t.Fatalf("ParsePlatform failed (%v)", err)
+	}
+
+	if p != (Platform{Arch: "arm64", OS: "el9", Compiler: "gcc13", BuildType: "dbg"}) {
+		t.Errorf("ParsePlatform returned a bad platform %+v", p)
+	}
+
+	if p.String() != "arm64-el9-gcc13-dbg" || p.RPMArch() != "aarch64" {
+		t.Errorf("Platform should format as arm64-el9-gcc13-dbg for aarch64, got %s for %s", p, p.RPMArch())
+	}
+
+	for _, s := range []string{"el9", "x86_64-el9-gcc13", "-el9-gcc13-opt", "x86_64-el-gcc13-opt", "x86_64-el9-13-opt", "x86_64-el9-gcc13-fast"} {
+		if _, err := ParsePlatform(s); err == nil {
+			t.Errorf("ParsePlatform(%q) should fail", s)
+		}
+	}
+}
+
+func TestPlatformCompatible(t *testing.T) {
+	p, _ := ParsePlatform("x86_64-alma8-gcc11-opt")
+
+	var got []string
+	for _, q := range p.Compatible() {
+		got = append(got, q.OS)
+	}
+
+	expect := "alma8 el8 centos8 rocky8 rhel8 el7 centos7 alma7 rocky7 rhel7"
+	if s := strings.Join(got, " "); s != expect {
+		t.Errorf("Compatible should return %s, got %s", expect, s)
+	}
+
+	ubuntu, _ := ParsePlatform("x86_64-ubuntu2204-gcc11-opt")
+	if got := ubuntu.Compatible(); len(got) != 1 || got[0] != ubuntu {
+		t.Errorf("Compatible should only return the platform itself for ubuntu, got %v", got)
+	}
+}
+
+func TestFinderWithCompatiblePlatforms(t *testing.T) {
+	dir := t.TempDir()
+	writeRPM(t, dir, "Athena_22.0.1_x86_64-centos7-gcc11-opt.rpm", fixtureRPM{Name: "Athena", Version: "22.0.1", Release: "1"})
+	writeRPM(t, dir, "Athena_22.0.2_x86_64-el8-gcc11-opt.rpm", fixtureRPM{Name: "Athena", Version: "22.0.2", Release: "1"})
+	writeRPM(t, dir, "Athena_22.0.3_x86_64-el9-gcc13-opt.rpm", fixtureRPM{Name: "Athena", Version: "22.0.3", Release: "1"})
+
+	if _, err := NewFinder(dir).Find("Athena", "x86_64-el9-gcc11-opt"); !errors.Is(err, ErrNoTopRPM) {
+		t.Errorf("Find should not accept other platforms by default, got %v", err)
+	}
+
+	rpms, err := NewFinder(dir, WithCompatiblePlatforms()).Find("Athena", "x86_64-el9-gcc11-opt")
+	if err != nil {
+		t.Fatalf("Find failed (%v)", err)
+	}
+
+	if got := (*rpms)[0].Name(); got != "Athena_22.0.2_x86_64-el8-gcc11-opt.rpm" {
+		t.Errorf("Find should prefer the newest compatible OS release, got %s", got)
+	}
+
+	if _, err := NewFinder(dir, WithCompatiblePlatforms()).Find("Athena", "x86_64-el9-gcc12-opt"); !errors.Is(err, ErrNoTopRPM) {
+		t.Errorf("Find should not accept another compiler, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package rpm
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultConcurrency is the number of files processed in parallel
+// by operations whose concurrency is not configured
+const DefaultConcurrency = 8
+
+// forEach calls fn for each index in [0, n), from up to concurrency
+// goroutines (DefaultConcurrency if below 1). The first error returned by
+// fn stops the remaining calls and is returned, as is a wrapped ctx error
+// if ctx is done first. All goroutines have exited when forEach returns.
+func forEach(ctx context.Context, n, concurrency int, fn func(i int) error) error {
+	if concurrency < 1 {
+		concurrency = DefaultConcurrency
+	}
+
+	poolCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		jobs     = make(chan int)
+	)
+
+	for w := 0; w < min(concurrency, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if poolCtx.Err() != nil || ctx.Err() != nil {
+					continue
+				}
+				if err := fn(i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case jobs <- i:
+		case <-poolCtx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return interrupted(ctx)
+}
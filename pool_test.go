@@ -0,0 +1,119 @@
+package rpm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestForEach(t *testing.T) {
+	var sum int64
+	err := forEach(context.Background(), 100, 4, func(i int) error {
+		atomic.AddInt64(&sum, int64(i))
+		return nil
+	})
+
+	if err != nil || sum != 4950 {
+		t.Errorf("forEach should call fn for each index, got sum %d (%v)", sum, err)
+	}
+}
+
+func TestForEachFirstError(t *testing.T) {
+	boom := errors.New("boom")
+	var calls int64
+	err := forEach(context.Background(), 1000, 1, func(i int) error {
+		atomic.AddInt64(&calls, 1)
+		if i == 10 {
+			return boom
+		}
+		return nil
+	})
+
+	if err != boom {
+		t.Errorf("forEach should return the first error, got %v", err)
+	}
+
+	if calls > 12 {
+		t.Errorf("forEach should stop after the first error, got %d calls", calls)
+	}
+}
+
+func TestForEachCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := forEach(ctx, 10, 2, func(int) error { return nil })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("forEach should return context.Canceled, got %v", err)
+	}
+}
+
+func TestStatDepsOrder(t *testing.T) {
+	dir, names := createStatDir(t, 50)
+
+	deps, err := statDeps(context.Background(), osFileSystem{}, dir, names, 8)
+	if err != nil {
+		t.Fatalf("statDeps failed (%v)", err)
+	}
+
+	for i, name := range deps.Names() {
+		if name != names[i] {
+			t.Fatalf("statDeps should preserve the filenames order, got %s at %d", name, i)
+		}
+	}
+
+	if _, err := statDeps(context.Background(), osFileSystem{}, dir, append(names, "missing.rpm"), 8); err == nil {
+		t.Errorf("statDeps should fail for a missing file, got nil")
+	}
+}
+
+func createStatDir(t testing.TB, n int) (string, []string) {
+	dir := t.TempDir()
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("dep%03d.rpm", i)
+		if err := os.WriteFile(filepath.Join(dir, names[i]), []byte("dep"), 0644); err != nil {
+			t.Fatalf("failed to write %s (%v)", names[i], err)
+		}
+	}
+
+	return dir, names
+}
+
+func benchmarkStatDeps(b *testing.B, concurrency int) {
+	dir, names := createStatDir(b, 500)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := statDeps(context.Background(), osFileSystem{}, dir, names, concurrency); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStatDepsSerial(b *testing.B)   { benchmarkStatDeps(b, 1) }
+func BenchmarkStatDepsParallel(b *testing.B) { benchmarkStatDeps(b, DefaultConcurrency) }
+
+func benchmarkBuildIndex(b *testing.B, concurrency int) {
+	dir := b.TempDir()
+	for i := 0; i < 200; i++ {
+		name := fmt.Sprintf("dep%03d", i)
+		writeRPM(b, dir, name+".rpm", fixtureRPM{
+			Name: name, Version: "1", Release: "1",
+			Provides: []fixtureDep{{Name: "lib" + name + ".so"}},
+		})
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := buildIndex(context.Background(), osFileSystem{}, dir, concurrency); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkBuildIndexSerial(b *testing.B)   { benchmarkBuildIndex(b, 1) }
+func BenchmarkBuildIndexParallel(b *testing.B) { benchmarkBuildIndex(b, DefaultConcurrency) }
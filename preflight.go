@@ -0,0 +1,170 @@
+package rpm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Path length limits checked by Preflight, those of Linux file systems
+const (
+	maxPathLen = 4096
+	maxNameLen = 255
+)
+
+// PreflightReport is the outcome of the checks run by Installer.Preflight
+type PreflightReport struct {
+	// Required is the number of bytes the installation writes
+	Required int64 `json:"required"`
+
+	// Available is the number of bytes available to an unprivileged user
+	// on the file system of the destination directory, -1 if unknown
+	Available int64 `json:"available"`
+
+	// Writable indicates if files can be created in the destination
+	// directory, or in its nearest existing parent if it does not exist
+	Writable bool `json:"writable"`
+
+	// TooLong are the destination paths of the files exceeding the
+	// path or file name length limits
+	TooLong []string `json:"too_long,omitempty"`
+}
+
+// OK indicates that the installation passes every check
+func (p *PreflightReport) OK() bool {
+	return p.Writable && len(p.TooLong) == 0 && !p.lacksSpace()
+}
+
+// lacksSpace indicates if the space available is known to be insufficient
+func (p *PreflightReport) lacksSpace() bool {
+	return p.Available >= 0 && p.Available < p.Required
+}
+
+// Err returns an error listing the failed checks, nil if the report is OK
+func (p *PreflightReport) Err() error {
+	var problems []string
+	if p.lacksSpace() {
+		problems = append(problems, fmt.Sprintf("%d bytes required, %d available", p.Required, p.Available))
+	}
+	if !p.Writable {
+		problems = append(problems, "destination not writable")
+	}
+	if len(p.TooLong) > 0 {
+		problems = append(problems, fmt.Sprintf("%d paths too long", len(p.TooLong)))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("preflight checks failed (%s)", strings.Join(problems, ", "))
+}
+
+// Preflight checks, without writing anything but a probe file, whether
+// Install can extract the RPMs below destDir: that the space available
+// covers the size of the files installed, that destDir is writable, and
+// that no destination path exceeds the length limits. Relocate and Include
+// are honoured. Files already present, which Install replaces, are not
+// deducted from the space required. The returned error reports a failure
+// to run the checks, not the checks failed, see PreflightReport.Err.
+func (in *Installer) Preflight(rpms *RPMs, destDir string) (*PreflightReport, error) {
+	absDir, err := filepath.Abs(destDir)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &PreflightReport{}
+	for _, r := range *rpms {
+		p, err := r.header()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(in.Include) == 0 {
+			report.Required += installedSize(p)
+		}
+
+		files, err := r.Files()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			if !in.includes(file.Path) {
+				continue
+			}
+
+			if len(in.Include) > 0 && file.Mode.IsRegular() {
+				report.Required += file.Size
+			}
+
+			target := filepath.Join(absDir, filepath.FromSlash(in.relocate(p, file.Path)))
+			if tooLong(target) {
+				report.TooLong = append(report.TooLong, target)
+			}
+		}
+	}
+
+	existing, err := existingParent(absDir)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Writable = writable(existing)
+	if report.Available, err = availableSpace(existing); err != nil {
+		return nil, fmt.Errorf("failed to get the space available in %s (%w)", existing, err)
+	}
+
+	return report, nil
+}
+
+// tooLong indicates if path, or any of its elements, exceeds the length limits
+func tooLong(path string) bool {
+	if len(path) > maxPathLen {
+		return true
+	}
+
+	for _, name := range strings.Split(path, string(filepath.Separator)) {
+		if len(name) > maxNameLen {
+			return true
+		}
+	}
+
+	return false
+}
+
+// existingParent returns dir if it exists, else its nearest existing parent
+func existingParent(dir string) (string, error) {
+	for {
+		fi, err := os.Stat(dir)
+		if err == nil {
+			if !fi.IsDir() {
+				return "", fmt.Errorf("%s is not a directory", dir)
+			}
+			return dir, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", err
+		}
+		dir = parent
+	}
+}
+
+// writable indicates if a file can be created in dir
+func writable(dir string) bool {
+	f, err := os.CreateTemp(dir, ".preflight-*")
+	if err != nil {
+		return false
+	}
+
+	f.Close()
+	os.Remove(f.Name())
+	return true
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd
+
+package rpm
+
+// availableSpace returns -1, the space available being unknown on this OS
+func availableSpace(dir string) (int64, error) {
+	return -1, nil
+}
@@ -0,0 +1,16 @@
+//go:build linux || darwin || freebsd
+
+package rpm
+
+import "syscall"
+
+// availableSpace returns the number of bytes available to an
+// unprivileged user on the file system of dir
+func availableSpace(dir string) (int64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
@@ -0,0 +1,62 @@
+package rpm
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRPMsInstalledSize(t *testing.T) {
+	dir := t.TempDir()
+	rpms := RPMs{
+		{Path: writeRPM(t, dir, "a.rpm", fixtureRPM{Name: "a", Version: "1", Release: "1", Installed: 1000})},
+		{Path: writeRPM(t, dir, "b.rpm", fixtureRPM{Name: "b", Version: "1", Release: "1", Installed: 234})},
+		{Path: filepath.Join(dir, "missing.rpm")},
+	}
+
+	if got := rpms.InstalledSize(); got != 1234 {
+		t.Errorf("InstalledSize should sum the installed sizes, got %d", got)
+	}
+}
+
+func TestInstallerPreflight(t *testing.T) {
+	long := "./opt/atlas/" + strings.Repeat("x", 256)
+	path := writeRPM(t, t.TempDir(), "Athena.rpm", fixtureRPM{
+		Name: "Athena", Version: "1", Release: "1", Installed: 41,
+		Files: []fixtureFile{
+			{Path: "/opt/atlas/setup.sh", Mode: 0100644, Size: 16},
+			{Path: "/opt/atlas/bin/athena.py", Mode: 0100755, Size: 22},
+			{Path: long[1:], Mode: 0100644, Size: 3},
+		},
+	})
+	rpms := &RPMs{{Path: path}}
+
+	dest := filepath.Join(t.TempDir(), "not/yet/created")
+	report, err := (&Installer{}).Preflight(rpms, dest)
+	if err != nil {
+		t.Fatalf("Preflight failed (%v)", err)
+	}
+
+	if report.Required != 41 || !report.Writable || report.Available <= 0 {
+		t.Errorf("Preflight should report the installed size, available space and writability, got %+v", report)
+	}
+	if len(report.TooLong) != 1 || !strings.HasSuffix(report.TooLong[0], strings.Repeat("x", 256)) {
+		t.Errorf("Preflight should report the file name above the length limit, got %v", report.TooLong)
+	}
+	if report.OK() || report.Err() == nil || !strings.Contains(report.Err().Error(), "1 paths too long") {
+		t.Errorf("the report should not be OK, got %v", report.Err())
+	}
+
+	report, err = (&Installer{Include: []string{"*.sh"}}).Preflight(rpms, dest)
+	if err != nil || report.Required != 16 || !report.OK() || report.Err() != nil {
+		t.Errorf("Preflight should only count the included files, got %+v (%v)", report, err)
+	}
+
+	full := &PreflightReport{Required: 100, Available: 10, Writable: true}
+	if full.OK() || !strings.Contains(full.Err().Error(), "100 bytes required, 10 available") {
+		t.Errorf("a report lacking space should not be OK, got %v", full.Err())
+	}
+	if unknown := (&PreflightReport{Required: 100, Available: -1, Writable: true}); !unknown.OK() {
+		t.Errorf("an unknown available space should not fail the checks")
+	}
+}
@@ -0,0 +1,117 @@
+package rpm
+
+// EventKind is the kind of an Event
+type EventKind int
+
+const (
+	// EventResolved is an RPM found by a Finder, the top RPM
+	// or one of its dependencies
+	EventResolved EventKind = iota
+
+	// EventDownloading reports the bytes of a file received so far
+	EventDownloading
+
+	// EventDownloaded is a file completely downloaded and verified
+	EventDownloaded
+
+	// EventInstalled is an RPM extracted by an Installer
+	EventInstalled
+
+	// EventError is a failure. Downloads report every failed attempt,
+	// including those that are retried.
+	EventError
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventResolved:
+		return "resolved"
+	case EventDownloading:
+		return "downloading"
+	case EventDownloaded:
+		return "downloaded"
+	case EventInstalled:
+		return "installed"
+	case EventError:
+		return "error"
+	}
+
+	return "unknown"
+}
+
+// Event is a step of the work of a Finder, Downloader or Installer
+type Event struct {
+	Kind EventKind
+
+	// Path is the local path of the RPM or file concerned, if any
+	Path string
+
+	// URL is the URL of the file, for download events
+	URL string
+
+	// Bytes is the number of bytes downloaded so far, and Total the size
+	// of the file, 0 if unknown, for download events
+	Bytes int64
+	Total int64
+
+	// Err is the failure, for error events
+	Err error
+}
+
+// Progress receives the events of a Finder, Downloader or Installer, e.g.
+// to display live progress. Report may be called from several goroutines
+// at once, and should return quickly, as the work waits for it.
+type Progress interface {
+	Report(e Event)
+}
+
+// ProgressFunc adapts a function to the Progress interface
+type ProgressFunc func(e Event)
+
+// Report calls f(e)
+func (f ProgressFunc) Report(e Event) {
+	f(e)
+}
+
+// WithProgress makes the Finder report the RPMs it finds, and its
+// failures, to p. A RemoteFinder also reports its downloads, unless its
+// Downloader has a Progress of its own.
+func WithProgress(p Progress) FinderOption {
+	return func(f *Finder) {
+		f.progress = p
+	}
+}
+
+// report sends e to p, if set
+func report(p Progress, e Event) {
+	if p != nil {
+		p.Report(e)
+	}
+}
+
+// reportFound reports the RPMs found, or the failure, to the Finder's
+// Progress and returns them
+func (f *Finder) reportFound(rpms *RPMs, err error) (*RPMs, error) {
+	if err != nil {
+		report(f.progress, Event{Kind: EventError, Err: err})
+		return nil, err
+	}
+
+	for _, rr := range *rpms {
+		report(f.progress, Event{Kind: EventResolved, Path: rr.Path})
+	}
+
+	return rpms, nil
+}
+
+// progressWriter reports the bytes written through it as download events
+type progressWriter struct {
+	progress Progress
+	event    Event
+}
+
+func (pw *progressWriter) Write(data []byte) (int, error) {
+	pw.event.Bytes += int64(len(data))
+	pw.progress.Report(pw.event)
+	return len(data), nil
+}
@@ -0,0 +1,106 @@
+package rpm
+
+import (
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// eventLog is a Progress recording the events it receives
+type eventLog struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (l *eventLog) Report(e Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, e)
+}
+
+// of returns the events of the given kind
+func (l *eventLog) of(kind EventKind) []Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var events []Event
+	for _, e := range l.events {
+		if e.Kind == kind {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func TestFinderWithProgress(t *testing.T) {
+	dir := createChainDir(t)
+
+	log := &eventLog{}
+	f := NewFinder(dir, WithProgress(log))
+	if _, err := f.Find("a", "el9"); err != nil {
+		t.Fatalf("Find failed (%v)", err)
+	}
+
+	if got := log.of(EventResolved); len(got) != 3 || got[0].Path != filepath.Join(dir, "a_1.0_el9.rpm") {
+		t.Errorf("Find should report the top RPM and its 2 dependencies, got %v", got)
+	}
+
+	if _, err := f.Find("missing", "el9"); err == nil {
+		t.Fatalf("Find should fail for a missing project")
+	}
+
+	if got := log.of(EventError); len(got) != 1 || got[0].Err == nil {
+		t.Errorf("Find should report its failure, got %v", got)
+	}
+}
+
+func TestDownloaderProgress(t *testing.T) {
+	content := []byte(strings.Repeat("0123456789", 100))
+	srv := httptest.NewServer(&flakyServer{content: content, script: []string{"503"}})
+	defer srv.Close()
+
+	log := &eventLog{}
+	d := &Downloader{Backoff: time.Millisecond, Progress: log}
+	path := filepath.Join(t.TempDir(), "Athena.rpm")
+	if err := d.Fetch(context.Background(), Download{URL: srv.URL, Path: path, Size: int64(len(content))}); err != nil {
+		t.Fatalf("Fetch failed (%v)", err)
+	}
+
+	if got := log.of(EventError); len(got) != 1 || got[0].URL != srv.URL {
+		t.Errorf("Fetch should report the failed attempt, got %v", got)
+	}
+
+	downloading := log.of(EventDownloading)
+	if len(downloading) == 0 {
+		t.Fatalf("Fetch should report the bytes downloaded")
+	}
+	if last := downloading[len(downloading)-1]; last.Bytes != int64(len(content)) || last.Total != int64(len(content)) {
+		t.Errorf("Fetch should report all %d bytes, got %d of %d", len(content), last.Bytes, last.Total)
+	}
+
+	if got := log.of(EventDownloaded); len(got) != 1 || got[0].Path != path {
+		t.Errorf("Fetch should report the completed download, got %v", got)
+	}
+}
+
+func TestInstallerProgress(t *testing.T) {
+	path := writeRPM(t, t.TempDir(), "Athena.rpm", fixtureRPM{
+		Name: "Athena", Version: "1", Release: "1",
+		Compression: "gzip",
+		Payload:     cpioPayload(t, "gzip", installerEntries),
+	})
+
+	log := &eventLog{}
+	in := &Installer{Progress: log}
+	if err := in.Install(context.Background(), &RPMs{{Path: path}}, t.TempDir()); err != nil {
+		t.Fatalf("Install failed (%v)", err)
+	}
+
+	if got := log.of(EventInstalled); len(got) != 1 || got[0].Path != path {
+		t.Errorf("Install should report the installed RPM, got %v", got)
+	}
+}
@@ -0,0 +1,147 @@
+package rpm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// RetentionPolicy tells a Pruner which builds of a project and platform
+// to keep. A build is kept if either rule keeps it.
+type RetentionPolicy struct {
+	// KeepLast keeps the given number of highest version builds
+	KeepLast int
+
+	// KeepWithin keeps the builds whose top RPM file was
+	// modified within this duration, e.g. 7*24*time.Hour
+	KeepWithin time.Duration
+}
+
+// PruneTarget is a project and platform whose builds a Pruner prunes
+type PruneTarget struct {
+	Project  string
+	Platform string
+}
+
+// Pruner removes the obsolete builds of a directory of RPMs, such as a
+// nightly area: the top RPMs that its policy does not keep, along with
+// those of their dependencies that no kept build uses.
+type Pruner struct {
+	// Finder looks up the builds and their dependencies. Its options, such
+	// as WithTransitive or WithPattern, decide what a build is made of.
+	Finder *Finder
+
+	// Policy is the retention policy applied to each target
+	Policy RetentionPolicy
+
+	// DryRun makes Prune only return the RPMs that it would remove
+	DryRun bool
+
+	// now is the reference time of KeepWithin, time.Now if nil
+	now func() time.Time
+}
+
+// NewPruner creates a Pruner of the builds found in basedir by a Finder
+// with the given options
+func NewPruner(basedir string, policy RetentionPolicy, opts ...FinderOption) *Pruner {
+	return &Pruner{Finder: NewFinder(basedir, opts...), Policy: policy}
+}
+
+// Prune removes the RPMs of the obsolete builds of the targets and returns
+// them, see PruneContext
+func (p *Pruner) Prune(targets ...PruneTarget) (*RPMs, error) {
+	return p.PruneContext(context.Background(), targets...)
+}
+
+// PruneContext removes the top RPMs of the builds of each target that the
+// policy does not keep, and those of their dependencies that no kept build
+// of any target uses, then returns them. With DryRun, nothing is removed.
+// RPMs of projects that are not among the targets are not considered, so
+// all the projects sharing dependencies in the directory must be targets.
+// Targets without any build are skipped. Nothing is removed unless the
+// dependencies of every build are resolved, nor when ctx is done.
+func (p *Pruner) PruneContext(ctx context.Context, targets ...PruneTarget) (*RPMs, error) {
+	if p.Policy.KeepLast < 1 && p.Policy.KeepWithin <= 0 {
+		return nil, errors.New("retention policy keeps no builds")
+	}
+
+	var kept, pruned RPMs
+	for _, target := range targets {
+		tops, err := p.Finder.FindAll(target.Project, target.Platform)
+		if errors.Is(err, ErrNoTopRPM) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for i, top := range tops {
+			keep, err := p.keeps(i, top)
+			if err != nil {
+				return nil, err
+			}
+
+			build, err := p.Finder.resolve(ctx, top.Path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve build %s (%w)", top.Name(), err)
+			}
+
+			if keep {
+				kept = union(kept, *build)
+			} else {
+				pruned = union(pruned, *build)
+			}
+		}
+	}
+
+	used := toLUT(kept.Paths())
+	obsolete := pruned.Filter(func(rr *RPM) bool {
+		_, keyExists := used[rr.Path]
+		return !keyExists
+	})
+	if p.DryRun {
+		return &obsolete, nil
+	}
+
+	if p.Finder.fsys != nil {
+		return nil, errors.New("cannot remove RPMs from a Finder file system other than that of the OS")
+	}
+
+	for _, rr := range obsolete {
+		if err := interrupted(ctx); err != nil {
+			return nil, err
+		}
+
+		if err := os.Remove(rr.Path); err != nil {
+			return nil, fmt.Errorf("failed to prune %s (%w)", rr.Path, err)
+		}
+	}
+
+	return &obsolete, nil
+}
+
+// keeps indicates if the policy keeps the top RPM of the given rank,
+// 0 being the highest version
+func (p *Pruner) keeps(rank int, top *RPM) (bool, error) {
+	if rank < p.Policy.KeepLast {
+		return true, nil
+	}
+
+	if p.Policy.KeepWithin <= 0 {
+		return false, nil
+	}
+
+	fi, err := top.files().Stat(top.Path)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now
+	if p.now != nil {
+		now = p.now
+	}
+
+	return now().Sub(fi.ModTime()) < p.Policy.KeepWithin, nil
+}
@@ -0,0 +1,82 @@
+package rpm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func createNightlyDir(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for filename, spec := range map[string]fixtureRPM{
+		"Athena_22.0.1_el9.rpm": {Name: "Athena", Version: "22.0.1", Release: "1", Requires: []fixtureDep{{Name: "old-dep"}, {Name: "shared"}}},
+		"Athena_22.0.2_el9.rpm": {Name: "Athena", Version: "22.0.2", Release: "1", Requires: []fixtureDep{{Name: "mid-dep"}, {Name: "shared"}}},
+		"Athena_22.0.3_el9.rpm": {Name: "Athena", Version: "22.0.3", Release: "1", Requires: []fixtureDep{{Name: "shared"}, {Name: "new-dep"}}},
+		"AthSim_1.0_el9.rpm":    {Name: "AthSim", Version: "1.0", Release: "1", Requires: []fixtureDep{{Name: "mid-dep"}}},
+		"old-dep.rpm":           {Name: "old-dep", Version: "1", Release: "1"},
+		"mid-dep.rpm":           {Name: "mid-dep", Version: "1", Release: "1"},
+		"new-dep.rpm":           {Name: "new-dep", Version: "1", Release: "1"},
+		"shared.rpm":            {Name: "shared", Version: "1", Release: "1"},
+		"unrelated.rpm":         {Name: "unrelated", Version: "1", Release: "1"},
+	} {
+		writeRPM(t, dir, filename, spec)
+	}
+
+	return dir
+}
+
+func TestPrunerDryRun(t *testing.T) {
+	dir := createNightlyDir(t)
+	targets := []PruneTarget{{"Athena", "el9"}, {"AthSim", "el9"}, {"AthAnalysis", "el9"}}
+
+	p := NewPruner(dir, RetentionPolicy{KeepLast: 1})
+	p.DryRun = true
+	obsolete, err := p.Prune(targets...)
+	if err != nil {
+		t.Fatalf("Prune failed (%v)", err)
+	}
+
+	if got := strings.Join(obsolete.Names(), ","); got != "Athena_22.0.2_el9.rpm,Athena_22.0.1_el9.rpm,old-dep.rpm" {
+		t.Errorf("Prune should return the old builds and their unused dependencies, got %s", got)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 9 {
+		t.Errorf("Prune should not remove anything in dry run, got %d files", len(entries))
+	}
+
+	if _, err := NewPruner(dir, RetentionPolicy{}).Prune(targets...); err == nil {
+		t.Errorf("Prune should refuse a policy that keeps nothing")
+	}
+}
+
+func TestPrunerKeepWithin(t *testing.T) {
+	dir := createNightlyDir(t)
+	now := time.Now()
+	os.Chtimes(filepath.Join(dir, "Athena_22.0.2_el9.rpm"), now, now.Add(-time.Hour))
+	os.Chtimes(filepath.Join(dir, "Athena_22.0.1_el9.rpm"), now, now.Add(-10*24*time.Hour))
+
+	p := NewPruner(dir, RetentionPolicy{KeepLast: 1, KeepWithin: 2 * 24 * time.Hour})
+	p.now = func() time.Time { return now }
+	obsolete, err := p.Prune(PruneTarget{"Athena", "el9"})
+	if err != nil {
+		t.Fatalf("Prune failed (%v)", err)
+	}
+
+	if got := strings.Join(obsolete.Names(), ","); got != "Athena_22.0.1_el9.rpm,old-dep.rpm" {
+		t.Errorf("Prune should only remove the build older than 2 days, got %s", got)
+	}
+
+	for _, name := range obsolete.Names() {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("Prune should remove %s, got %v", name, err)
+		}
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 7 {
+		t.Errorf("Prune should leave 7 files, got %d", len(entries))
+	}
+}
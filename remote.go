@@ -0,0 +1,361 @@
+package rpm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cavaliergopher/rpm"
+)
+
+// RemoteFinder locates RPMs in remote repos, from their repodata, and
+// downloads those needed into a local cache directory
+type RemoteFinder struct {
+	// repos are sorted by priority
+	repos  Repos
+	finder *Finder
+}
+
+// NewRemoteFinder creates a RemoteFinder of the given repo, caching the
+// downloaded RPMs in cacheDir. The options are those of a Finder, and apply
+// to the resolution of dependencies among the downloaded RPMs.
+func NewRemoteFinder(repo Repo, cacheDir string, opts ...FinderOption) *RemoteFinder {
+	return &RemoteFinder{
+		repos:  Repos{repo},
+		finder: NewFinder(cacheDir, opts...),
+	}
+}
+
+// NewReposFinder is like NewRemoteFinder, for the enabled repos of the
+// collection. As with dnf, a package found in several repos is taken from
+// that of the highest priority, whatever its version there, see
+// Repos.ByPriority. Among repos of the same priority, the highest version
+// wins, then the earliest repo. The package file names must be unique
+// across the repos, as they share the cache directory.
+func NewReposFinder(repos Repos, cacheDir string, opts ...FinderOption) *RemoteFinder {
+	return &RemoteFinder{
+		repos:  repos.Enabled().ByPriority(),
+		finder: NewFinder(cacheDir, opts...),
+	}
+}
+
+// CacheDir returns the path to the directory into which RPMs are downloaded
+func (rf *RemoteFinder) CacheDir() string {
+	return rf.finder.SrcDir()
+}
+
+// Find is like Finder.Find, but for the RPMs of the remote repos
+func (rf *RemoteFinder) Find(project, platform string) (*RPMs, error) {
+	return rf.FindContext(context.Background(), project, platform)
+}
+
+// FindContext selects the top RPM for the given project and platform and
+// its dependencies from the repodata of the remote repos, downloads those
+// not already cached, then resolves them locally as Finder.FindContext does.
+// If a repo has gpgcheck enabled, the signature of every RPM selected from
+// it is verified against its gpgkey first. On a network or server error,
+// the repodata and RPMs are fetched from the next of the repo BaseURLs.
+// Only the direct dependencies are downloaded, unless WithTransitive is set.
+// The top RPM is always the newest match, as WithSelector needs the headers.
+func (rf *RemoteFinder) FindContext(ctx context.Context, project, platform string) (*RPMs, error) {
+	return rf.finder.reportFound(rf.find(ctx, project, platform))
+}
+
+func (rf *RemoteFinder) find(ctx context.Context, project, platform string) (*RPMs, error) {
+	if rf.finder.err != nil {
+		return nil, rf.finder.err
+	}
+
+	if len(rf.repos) == 0 {
+		return nil, errors.New("no enabled repos to find RPMs in")
+	}
+
+	var pkgs []repoPackage
+	bases := make([][]string, len(rf.repos))
+	for i, repo := range rf.repos {
+		repoPkgs, repoBases, err := rf.fetch(ctx, repo)
+		if err != nil {
+			return nil, err
+		}
+
+		for j := range repoPkgs {
+			repoPkgs[j].repo = i
+		}
+		pkgs = append(pkgs, repoPkgs...)
+		bases[i] = repoBases
+	}
+	pkgs = rf.shadow(pkgs)
+
+	top, err := rf.topPackage(pkgs, project, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	needed := rf.closure(pkgs, top)
+	if err := os.MkdirAll(rf.CacheDir(), 0755); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	if err := rf.download(ctx, bases, pkgs, needed); err != nil {
+		return nil, err
+	}
+	rf.finder.log().Info("downloaded RPMs", "needed", len(needed), "elapsed", time.Since(start))
+
+	for i, repo := range rf.repos {
+		if repo.gpgChecked() {
+			if err := rf.verify(ctx, i, pkgs, needed); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return rf.finder.resolve(ctx, filepath.Join(rf.CacheDir(), pkgs[top].Filename()))
+}
+
+// fetch returns the packages of the primary metadata of the repo, and its
+// base URLs, that which served the metadata first
+func (rf *RemoteFinder) fetch(ctx context.Context, repo Repo) ([]repoPackage, []string, error) {
+	bases, err := rf.mirrors(ctx, repo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pkgs []repoPackage
+	start := time.Now()
+	serving, err := eachMirror(ctx, bases, func(base string) error {
+		pkgs, err = repo.at(base).fetchPrimary(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	rf.finder.log().Info("fetched repodata", "repo", repo.Label, "url", bases[serving], "packages", len(pkgs), "elapsed", time.Since(start))
+
+	// Prefer the mirror that served the repodata for the packages
+	bases = append([]string{bases[serving]}, append(bases[:serving:serving], bases[serving+1:]...)...)
+	return pkgs, bases, nil
+}
+
+// shadow drops the packages of which one of the same name is in a repo of
+// higher priority
+func (rf *RemoteFinder) shadow(pkgs []repoPackage) []repoPackage {
+	best := map[string]int{}
+	for _, p := range pkgs {
+		if priority, keyExists := best[p.Name]; !keyExists || rf.repos[p.repo].priority() < priority {
+			best[p.Name] = rf.repos[p.repo].priority()
+		}
+	}
+
+	var kept []repoPackage
+	for _, p := range pkgs {
+		if rf.repos[p.repo].priority() == best[p.Name] {
+			kept = append(kept, p)
+		}
+	}
+
+	return kept
+}
+
+// topPackage returns the index of the highest version package of the
+// repo whose file name matches the Finder pattern, for the first of the
+// Finder's platforms with a match
+func (rf *RemoteFinder) topPackage(pkgs []repoPackage, project, platform string) (int, error) {
+	for _, candidate := range rf.finder.platforms(platform) {
+		pattern := fmt.Sprintf(rf.finder.pattern, project, candidate)
+
+		top := -1
+		for i, p := range pkgs {
+			if ok, _ := path.Match(pattern, p.Filename()); !ok {
+				continue
+			}
+
+			if top < 0 || compareEVR(p.evr(), pkgs[top].evr()) > 0 {
+				top = i
+			}
+		}
+
+		if top >= 0 {
+			return top, nil
+		}
+	}
+
+	pattern := fmt.Sprintf(rf.finder.pattern, project, platform)
+	var urls []string
+	for _, repo := range rf.repos {
+		urls = append(urls, repo.packageURL(pattern))
+	}
+	return -1, fmt.Errorf("%w to install (%s)", ErrNoTopRPM, strings.Join(urls, ", "))
+}
+
+// closure returns the indexes of the top package and of the packages that
+// provide its requires, and weak dependencies if set, recursively if the
+// Finder is transitive. As in a local directory, a package provides a
+// capability if one of its provides is in the version range of every
+// requirement of the package on it, and the highest version of those
+// packages is chosen. Requires that no package provides are left for the
+// local resolution to report.
+func (rf *RemoteFinder) closure(pkgs []repoPackage, top int) []int {
+	type provide struct {
+		pkg int
+		dep constraint
+	}
+
+	providers := map[string][]provide{}
+	for i, p := range pkgs {
+		v := p.evr()
+		self := constraint{name: p.Name, flags: rpm.DepFlagEqual, version: formatEVR(v.epoch, v.version, v.release)}
+		providers[p.Name] = append(providers[p.Name], provide{i, self})
+		for _, prov := range p.Provides {
+			if prov.Name != p.Name {
+				providers[prov.Name] = append(providers[prov.Name], provide{i, prov.constraint()})
+			}
+		}
+	}
+
+	seen := map[int]struct{}{top: {}}
+	needed := []int{top}
+	for next := 0; next < len(needed); next++ {
+		if next > 0 && !rf.finder.transitive {
+			break
+		}
+
+		var names []string
+		reqs := map[string][]constraint{}
+		for _, req := range pkgs[needed[next]].dependencies(rf.finder.weak) {
+			if _, keyExists := reqs[req.Name]; !keyExists {
+				names = append(names, req.Name)
+			}
+			reqs[req.Name] = append(reqs[req.Name], req.constraint())
+		}
+
+		for _, name := range names {
+			best := -1
+			for _, prov := range providers[name] {
+				if prov.pkg == needed[next] || !inRange(prov.dep, reqs[name]) {
+					continue
+				}
+
+				if best < 0 || compareEVR(pkgs[prov.pkg].evr(), pkgs[best].evr()) > 0 {
+					best = prov.pkg
+				}
+			}
+
+			if _, keyExists := seen[best]; best >= 0 && !keyExists {
+				seen[best] = struct{}{}
+				needed = append(needed, best)
+			}
+		}
+	}
+
+	return needed
+}
+
+// inRange indicates if the provide is in the version range of every
+// given requirement
+func inRange(prov constraint, reqs []constraint) bool {
+	for _, req := range reqs {
+		if !overlaps(req, prov) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// download fetches the needed package files into the cache directory,
+// except those of which a file of the same name and size is already there.
+// With WithChecksumCheck, the cached files must also match the repodata
+// checksum, as must the downloaded files. Each file is fetched from the
+// first of the base URLs of its repo, falling back to the next ones.
+func (rf *RemoteFinder) download(ctx context.Context, bases [][]string, pkgs []repoPackage, needed []int) error {
+	var downloads []Download
+	for _, i := range needed {
+		p := pkgs[i]
+		dst := filepath.Join(rf.CacheDir(), p.Filename())
+		if fi, err := os.Stat(dst); err == nil && fi.Size() == p.Size.Package {
+			if !rf.finder.checksumCheck || p.verifyChecksum(dst) == nil {
+				rf.finder.log().Debug("skipped cached RPM", "path", dst)
+				continue
+			}
+		}
+
+		dl := Download{Path: dst}
+		for i, base := range bases[p.repo] {
+			url := rf.repos[p.repo].at(base).packageURL(p.Location.Href)
+			if i == 0 {
+				dl.URL = url
+			} else {
+				dl.Mirrors = append(dl.Mirrors, url)
+			}
+		}
+		if rf.finder.checksumCheck {
+			dl.Size = p.Size.Package
+			algo, ok := checksumAlgos[p.Checksum.Type]
+			if !ok {
+				return fmt.Errorf("%s: unsupported repodata checksum type %q", p.Filename(), p.Checksum.Type)
+			}
+			dl.Checksum, dl.ChecksumType = p.Checksum.Value, algo
+		}
+		downloads = append(downloads, dl)
+	}
+
+	return rf.downloader().FetchAll(ctx, downloads)
+}
+
+// mirrors returns the base URLs of the repo, fastest
+// first if the Finder is created WithFastestMirror
+func (rf *RemoteFinder) mirrors(ctx context.Context, repo Repo) ([]string, error) {
+	bases, err := repo.BaseURLs(ctx)
+	if err != nil || !rf.finder.fastestMirror || len(bases) < 2 {
+		return bases, err
+	}
+
+	return probeMirrors(ctx, bases, rf.finder.concurrency), nil
+}
+
+// downloader returns the Finder's Downloader, else a default one,
+// reporting to the Finder's Progress and Logger unless it has its own
+func (rf *RemoteFinder) downloader() *Downloader {
+	d := Downloader{Concurrency: rf.finder.concurrency}
+	if rf.finder.downloader != nil {
+		d = *rf.finder.downloader
+	}
+
+	if d.Progress == nil {
+		d.Progress = rf.finder.progress
+	}
+
+	if d.Logger == nil {
+		d.Logger = rf.finder.logger
+	}
+
+	return &d
+}
+
+// verify checks the signatures of the selected packages of the
+// repo of the given index against the keys of the repo
+func (rf *RemoteFinder) verify(ctx context.Context, repo int, pkgs []repoPackage, needed []int) error {
+	keyring, err := rf.repos[repo].Keyring(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range needed {
+		if pkgs[i].repo != repo {
+			continue
+		}
+
+		path := filepath.Join(rf.CacheDir(), pkgs[i].Filename())
+		if err := VerifySignature(path, keyring); err != nil {
+			return fmt.Errorf("repo %s has gpgcheck enabled (%w)", rf.repos[repo].Label, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package rpm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cavaliergopher/rpm"
+)
+
+// writeRepodata writes the repomd.xml and gzipped primary.xml of a repo
+// holding the given fixture RPMs, whose files are below Packages/ in dir
+func writeRepodata(t *testing.T, dir string, specs map[string]fixtureRPM) {
+	t.Helper()
+
+	var pkgs strings.Builder
+	for filename, spec := range specs {
+		path := writeRPM(t, filepath.Join(dir, "Packages"), filename, spec)
+		fi, _ := os.Stat(path)
+		sum, _ := (&RPM{Path: path}).Checksum("sha256")
+
+		var provides, requires strings.Builder
+		for _, dep := range spec.Provides {
+			fmt.Fprintf(&provides, `<rpm:entry name="%s"/>`, dep.Name)
+		}
+		for _, dep := range spec.Requires {
+			fmt.Fprintf(&requires, `<rpm:entry name="%s"/>`, dep.Name)
+		}
+
+		fmt.Fprintf(&pkgs, `<package type="rpm"><name>%s</name><arch>x86_64</arch>`+
+			`<version epoch="%d" ver="%s" rel="%s"/><checksum type="sha256" pkgid="YES">%s</checksum>`+
+			`<size package="%d"/>`+
+			`<location href="Packages/%s"/><format><rpm:provides>%s</rpm:provides>`+
+			`<rpm:requires>%s</rpm:requires></format></package>`,
+			spec.Name, spec.Epoch, spec.Version, spec.Release, sum, fi.Size(),
+			filename, provides.String(), requires.String())
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	fmt.Fprintf(gz, `<?xml version="1.0"?><metadata xmlns="http://linux.duke.edu/metadata/common" `+
+		`xmlns:rpm="http://linux.duke.edu/metadata/rpm">%s</metadata>`, pkgs.String())
+	gz.Close()
+
+	repodata := filepath.Join(dir, "repodata")
+	os.MkdirAll(repodata, 0755)
+	os.WriteFile(filepath.Join(repodata, "abc-primary.xml.gz"), buf.Bytes(), 0644)
+	os.WriteFile(filepath.Join(repodata, "repomd.xml"), []byte(`<?xml version="1.0"?>`+
+		`<repomd xmlns="http://linux.duke.edu/metadata/repo">`+
+		`<data type="other"><location href="repodata/abc-other.xml.gz"/></data>`+
+		`<data type="primary"><location href="repodata/abc-primary.xml.gz"/></data>`+
+		`</repomd>`), 0644)
+}
+
+func createRemoteRepo(t *testing.T) *httptest.Server {
+	t.Helper()
+	return serveRemoteRepo(t, t.TempDir())
+}
+
+// serveRemoteRepo writes a test repo in dir, and serves it
+func serveRemoteRepo(t *testing.T, dir string) *httptest.Server {
+	t.Helper()
+
+	os.MkdirAll(filepath.Join(dir, "Packages"), 0755)
+	writeRepodata(t, dir, map[string]fixtureRPM{
+		"Athena_22.0.1_x86_64.rpm": {Name: "Athena", Version: "22.0.1", Release: "1"},
+		"Athena_22.0.2_x86_64.rpm": {Name: "Athena", Version: "22.0.2", Release: "1", Requires: []fixtureDep{{Name: "libGaudi.so"}}},
+		"Gaudi-1.0.rpm":            {Name: "Gaudi", Version: "1.0", Release: "1", Requires: []fixtureDep{{Name: "tbb"}}, Provides: []fixtureDep{{Name: "libGaudi.so"}}},
+		"tbb-2020.rpm":             {Name: "tbb", Version: "2020", Release: "1"},
+		"unrelated-1.rpm":          {Name: "unrelated", Version: "1", Release: "1"},
+	})
+
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRemoteFinderFind(t *testing.T) {
+	srv := createRemoteRepo(t)
+	cache := filepath.Join(t.TempDir(), "cache")
+
+	rpms, err := NewRemoteFinder(Repo{URL: srv.URL}, cache).Find("Athena", "x86_64")
+	if err != nil {
+		t.Fatalf("Find failed (%v)", err)
+	}
+
+	if got := strings.Join(rpms.Names(), ","); got != "Athena_22.0.2_x86_64.rpm,Gaudi-1.0.rpm" {
+		t.Errorf("Find should return the latest Athena and Gaudi, got %s", got)
+	}
+
+	entries, _ := os.ReadDir(cache)
+	if len(entries) != 2 {
+		t.Errorf("Find should only download the needed RPMs, got %d files", len(entries))
+	}
+
+	rpms, err = NewRemoteFinder(Repo{URL: srv.URL}, cache, WithTransitive()).Find("Athena", "x86_64")
+	if err != nil {
+		t.Fatalf("Find failed (%v)", err)
+	}
+
+	if got := strings.Join(rpms.Names(), ","); got != "Athena_22.0.2_x86_64.rpm,Gaudi-1.0.rpm,tbb-2020.rpm" {
+		t.Errorf("Find should return the dependency closure, got %s", got)
+	}
+}
+
+func TestRemoteFinderErrors(t *testing.T) {
+	srv := createRemoteRepo(t)
+	cache := t.TempDir()
+
+	if _, err := NewRemoteFinder(Repo{URL: srv.URL}, cache).Find("AthSimulation", "x86_64"); err == nil {
+		t.Errorf("Find should fail without a matching top RPM, got nil")
+	}
+
+	if _, err := NewRemoteFinder(Repo{URL: srv.URL + "/nowhere"}, cache).Find("Athena", "x86_64"); err == nil {
+		t.Errorf("Find should fail without repodata, got nil")
+	}
+}
+
+func TestRemoteFinderWithChecksumCheck(t *testing.T) {
+	dir := t.TempDir()
+	srv := serveRemoteRepo(t, dir)
+
+	// Corrupt Gaudi on the server, keeping its header readable
+	f, _ := os.OpenFile(filepath.Join(dir, "Packages", "Gaudi-1.0.rpm"), os.O_APPEND|os.O_WRONLY, 0644)
+	f.Write([]byte("junk"))
+	f.Close()
+
+	if _, err := NewRemoteFinder(Repo{URL: srv.URL}, t.TempDir()).Find("Athena", "x86_64"); err != nil {
+		t.Errorf("Find should not check checksums by default, got %v", err)
+	}
+
+	cache := t.TempDir()
+	_, err := NewRemoteFinder(Repo{URL: srv.URL}, cache, WithChecksumCheck()).Find("Athena", "x86_64")
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Find should fail with ErrChecksumMismatch, got %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(cache, "Gaudi-1.0.rpm")); !os.IsNotExist(err) {
+		t.Errorf("Find should remove the corrupt download, got %v", err)
+	}
+
+	// A corrupt cached file of the right size is downloaded again
+	top := filepath.Join(cache, "Athena_22.0.2_x86_64.rpm")
+	content, _ := os.ReadFile(top)
+	content[len(content)-1]++
+	os.WriteFile(top, content, 0644)
+	os.Remove(filepath.Join(dir, "Packages", "Gaudi-1.0.rpm"))
+	writeRPM(t, filepath.Join(dir, "Packages"), "Gaudi-1.0.rpm", fixtureRPM{
+		Name: "Gaudi", Version: "1.0", Release: "1", Requires: []fixtureDep{{Name: "tbb"}}, Provides: []fixtureDep{{Name: "libGaudi.so"}},
+	})
+
+	if _, err := NewRemoteFinder(Repo{URL: srv.URL}, cache, WithChecksumCheck()).Find("Athena", "x86_64"); err != nil {
+		t.Errorf("Find should download the corrupt cached file again, got %v", err)
+	}
+}
+
+func TestRemoteFinderVersionedRequires(t *testing.T) {
+	dir := t.TempDir()
+	writeRPM(t, dir, "Athena_22.0.1_x86_64.rpm", fixtureRPM{
+		Name: "Athena", Version: "22.0.1", Release: "1",
+		Requires: []fixtureDep{
+			{Name: "Gaudi", Flags: rpm.DepFlagGreaterOrEqual, Version: "2.0"},
+			{Name: "Gaudi", Flags: rpm.DepFlagLesser, Version: "3.0"},
+			{Name: "libtbb.so"},
+		},
+	})
+	writeRPM(t, dir, "Gaudi-1.0.rpm", fixtureRPM{Name: "Gaudi", Version: "1.0", Release: "1"})
+	writeRPM(t, dir, "Gaudi-2.0.rpm", fixtureRPM{Name: "Gaudi", Version: "2.0", Release: "1"})
+	writeRPM(t, dir, "Gaudi-3.0.rpm", fixtureRPM{Name: "Gaudi", Version: "3.0", Release: "1"})
+	writeRPM(t, dir, "tbb-2019.rpm", fixtureRPM{Name: "tbb", Version: "2019", Release: "1", Provides: []fixtureDep{{Name: "libtbb.so"}}})
+	writeRPM(t, dir, "tbb-2020.rpm", fixtureRPM{Name: "tbb", Version: "2020", Release: "1", Provides: []fixtureDep{{Name: "libtbb.so"}}})
+	if err := CreateRepo(dir); err != nil {
+		t.Fatalf("CreateRepo failed (%v)", err)
+	}
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	t.Cleanup(srv.Close)
+
+	rpms, err := NewRemoteFinder(Repo{URL: srv.URL}, t.TempDir()).Find("Athena", "x86_64")
+	if err != nil {
+		t.Fatalf("Find failed (%v)", err)
+	}
+
+	if got := strings.Join(rpms.Names(), ","); got != "Athena_22.0.1_x86_64.rpm,Gaudi-2.0.rpm,tbb-2020.rpm" {
+		t.Errorf("Find should download the highest version in the required range, got %s", got)
+	}
+}
@@ -1,17 +1,60 @@
 package rpm
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
-    "github.com/cavaliergopher/rpm"
+	"github.com/cavaliergopher/rpm"
 )
 
 // Repos is a collection of RPM repo instances
 type Repos []Repo
 
+// Enabled returns the enabled repos of the collection
+func (r Repos) Enabled() Repos {
+	var repos Repos
+	for _, repo := range r {
+		if repo.Enabled {
+			repos = append(repos, repo)
+		}
+	}
+
+	return repos
+}
+
+// ByLabel returns the repo with the given label, and whether it was found.
+// The match is case-sensitive, as labels map to file names. Should several
+// repos share the label, the first one is returned.
+func (r Repos) ByLabel(label string) (Repo, bool) {
+	for _, repo := range r {
+		if repo.Label == label {
+			return repo, true
+		}
+	}
+
+	return Repo{}, false
+}
+
+// ByName returns the repos with the given display name,
+// matched without regard to case
+func (r Repos) ByName(name string) Repos {
+	var repos Repos
+	for _, repo := range r {
+		if strings.EqualFold(repo.Name, name) {
+			repos = append(repos, repo)
+		}
+	}
+
+	return repos
+}
+
 // Repo represents an RPM repository
 type Repo struct {
 	Name    string
@@ -19,6 +62,31 @@ type Repo struct {
 	URL     string
 	Prefix  string
 	Enabled bool
+
+	// Mirrors are further, space separated, base URLs of the same repo,
+	// tried in turn when URL fails with a network or server error
+	Mirrors string
+
+	// MirrorList and Metalink, if set, are the URLs of files listing
+	// more mirrors, see BaseURLs
+	MirrorList string
+	Metalink   string
+
+	// GPGCheck, if set, overrides the signature check setting of yum/dnf
+	GPGCheck *bool
+
+	// GPGKey is the URL, or space separated URLs, of the signing keys
+	GPGKey string
+
+	// Priority of the repo, lower is preferred. Zero means unset.
+	Priority int
+
+	// Proxy is the URL of the proxy through which the repo is accessed
+	Proxy string
+
+	// MetadataExpire is the age after which the repodata is refreshed,
+	// in the yum/dnf format, e.g. 6h or -1 for never
+	MetadataExpire string
 }
 
 // Filename returns the file name into which this repo will write its description
@@ -29,26 +97,167 @@ func (r Repo) String() string {
 	var tokens []string
 	tokens = append(tokens, fmt.Sprintf("[%s]", r.Label))
 	tokens = append(tokens, fmt.Sprintf("name=%s", r.Name))
-	tokens = append(tokens, fmt.Sprintf("baseurl=%s", r.URL))
+	tokens = append(tokens, fmt.Sprintf("baseurl=%s", strings.Join(append([]string{r.URL}, strings.Fields(r.Mirrors)...), " ")))
 	tokens = append(tokens, fmt.Sprintf("enabled=%t", r.Enabled))
 	if len(r.Prefix) > 0 {
 		tokens = append(tokens, fmt.Sprintf("prefix=%s", r.Prefix))
 	}
+	if len(r.MirrorList) > 0 {
+		tokens = append(tokens, fmt.Sprintf("mirrorlist=%s", r.MirrorList))
+	}
+	if len(r.Metalink) > 0 {
+		tokens = append(tokens, fmt.Sprintf("metalink=%s", r.Metalink))
+	}
+	if r.GPGCheck != nil {
+		tokens = append(tokens, fmt.Sprintf("gpgcheck=%d", boolToInt(*r.GPGCheck)))
+	}
+	if len(r.GPGKey) > 0 {
+		tokens = append(tokens, fmt.Sprintf("gpgkey=%s", r.GPGKey))
+	}
+	if r.Priority != 0 {
+		tokens = append(tokens, fmt.Sprintf("priority=%d", r.Priority))
+	}
+	if len(r.Proxy) > 0 {
+		tokens = append(tokens, fmt.Sprintf("proxy=%s", r.Proxy))
+	}
+	if len(r.MetadataExpire) > 0 {
+		tokens = append(tokens, fmt.Sprintf("metadata_expire=%s", r.MetadataExpire))
+	}
 	return strings.Join(tokens, "\n") + "\n"
 }
 
+// RepoFromPathArg returns the dnf command line arguments that define this
+// repo on the fly, without writing its description to a file
+func (r Repo) RepoFromPathArg() string {
+	var tokens []string
+	tokens = append(tokens, fmt.Sprintf("--repofrompath=%s,%s", r.Label, r.URL))
+	tokens = append(tokens, fmt.Sprintf("--setopt=%s.enabled=%t", r.Label, r.Enabled))
+	if r.GPGCheck != nil {
+		tokens = append(tokens, fmt.Sprintf("--setopt=%s.gpgcheck=%t", r.Label, *r.GPGCheck))
+	}
+	if len(r.GPGKey) > 0 {
+		tokens = append(tokens, fmt.Sprintf("--setopt=%s.gpgkey=%s", r.Label, r.GPGKey))
+	}
+	return strings.Join(tokens, " ")
+}
+
+// ConflictsInDir reports whether a .repo file in dir, other than the one
+// this repo writes to, already defines a section with the same label, in
+// which case yum would silently ignore one of the two. The name of the
+// offending file is returned alongside. The .repo files are read with
+// ParseRepoFile, a file that does not parse being reported as an error.
+func (r Repo) ConflictsInDir(dir string) (bool, string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.repo"))
+	if err != nil {
+		return false, "", err
+	}
+
+	for _, path := range paths {
+		if filepath.Base(path) == r.Filename() {
+			continue
+		}
+
+		repos, err := ParseRepoFile(path)
+		if err != nil {
+			return false, "", err
+		}
+
+		if _, found := repos.ByLabel(r.Label); found {
+			return true, filepath.Base(path), nil
+		}
+	}
+
+	return false, "", nil
+}
+
 // ---------------------------------------------------------------------
 
 // NewFinder creates a new RPM Finder object
-func NewFinder(path string) *Finder {
-	return &Finder{
+func NewFinder(path string, opts ...FinderOption) *Finder {
+	f := &Finder{
 		basedir: path,
+		pattern: DefaultPattern,
+		assumed: DefaultAssumedPresent,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f
+}
+
+// FinderOption configures optional Finder behaviour
+type FinderOption func(*Finder)
+
+// WithFilenameMatch sets how the Finder matches dependencies to RPM filenames
+func WithFilenameMatch(match FilenameMatch) FinderOption {
+	return func(f *Finder) {
+		f.match = match
+	}
+}
+
+// WithTransitive makes Find return the full dependency closure
+// of the top RPM rather than only its direct dependencies
+func WithTransitive() FinderOption {
+	return func(f *Finder) {
+		f.transitive = true
+	}
+}
+
+// WithConcurrency sets the number of RPM files that the Finder stats, or
+// whose header it parses, in parallel, DefaultConcurrency if not set
+func WithConcurrency(n int) FinderOption {
+	return func(f *Finder) {
+		f.concurrency = n
 	}
 }
 
 // Finder is the object that locates RPMs below a given base directory
 type Finder struct {
-	basedir string
+	basedir    string
+	pattern    string
+	match      FilenameMatch
+	strict     bool
+	assumed    []string
+	matchBy    MatchStrategy
+	transitive bool
+	selector   Selector
+	fsys       fileSystem
+
+	// conflictCheck fails Find on several versions of the same package
+	conflictCheck bool
+
+	// checksumCheck fails a RemoteFinder on a repodata checksum mismatch
+	checksumCheck bool
+
+	// downloader fetches the RPMs of a RemoteFinder
+	downloader *Downloader
+
+	// fastestMirror makes a RemoteFinder prefer its fastest mirrors
+	fastestMirror bool
+
+	// compatible makes top RPM lookups accept compatible platforms
+	compatible bool
+
+	// weak are the weak dependencies looked up on top of the requires
+	weak WeakDeps
+
+	// progress, if set, receives the events of the lookups
+	progress Progress
+
+	// logger, if set, receives the records of the lookups
+	logger *slog.Logger
+
+	// concurrency is the number of files stat'ed or parsed in parallel
+	concurrency int
+
+	// err records an invalid option, reported by every lookup
+	err error
+
+	// index is the capability index of basedir, guarded by indexMu
+	// as concurrent lookups of the Finder share it
+	index   *capIndex
+	indexMu sync.Mutex
 }
 
 // SrcDir returns the path to the root directory below which RPMs are found
@@ -58,52 +267,176 @@ func (f *Finder) SrcDir() string {
 
 type pathGlob func(string) ([]string, error)
 
-// findTopRPM finds the top RPM which we need to install (with its dependencies)
+// TopRPM returns the path of the top RPM that Find would
+// select for the given project and platform
+func (f *Finder) TopRPM(project, platform string) (string, error) {
+	return f.findTopRPM(f.files().Glob, project, platform)
+}
+
+// findTopRPM finds the top RPM which we need to install (with its dependencies).
+// If several match, the Finder selector picks one, by default the one with
+// the highest version.
 func (f *Finder) findTopRPM(glob pathGlob, project, platform string) (string, error) {
-	fname := fmt.Sprintf("%s_*_%s.rpm", project, platform)
-	fpath := filepath.Join(f.basedir, fname)
-	matches, err := glob(fpath)
+	matches, err := f.findTopRPMs(glob, project, platform)
 	if err != nil {
 		return "", err
 	}
 
-	if len(matches) == 0 {
-		return "", fmt.Errorf("no top RPM found to install (%s)", fpath)
+	selector := f.selector
+	if selector == nil {
+		selector = SelectNewest
+	}
+
+	candidates := make(RPMs, len(matches))
+	for i, path := range matches {
+		candidates[i] = &RPM{Path: path, fsys: f.fsys}
+	}
+
+	top, err := selector(candidates)
+	if err != nil {
+		return "", fmt.Errorf("failed to select the top RPM for %s/%s (%w)", project, platform, err)
+	}
+	f.log().Debug("selected top RPM", "path", top.Path, "candidates", len(candidates))
+
+	return top.Path, nil
+}
+
+// findTopRPMs finds all the candidate top RPMs, highest version first,
+// of the first of the Finder's platforms with any
+func (f *Finder) findTopRPMs(glob pathGlob, project, platform string) ([]string, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	pattern := f.pattern
+	if pattern == "" {
+		pattern = DefaultPattern
 	}
 
-	return matches[0], nil
+	for _, candidate := range f.platforms(platform) {
+		fpath := f.files().Join(f.basedir, fmt.Sprintf(pattern, project, candidate))
+		matches, err := glob(fpath)
+		if err != nil {
+			return nil, err
+		}
+		f.log().Debug("globbed top RPMs", "pattern", fpath, "matches", len(matches))
+
+		if len(matches) > 0 {
+			return sortByVersionDesc(f.files(), matches), nil
+		}
+	}
+
+	fpath := f.files().Join(f.basedir, fmt.Sprintf(pattern, project, platform))
+	return nil, fmt.Errorf("%w to install (%s)", ErrNoTopRPM, fpath)
 }
 
-// Find is the method that finds RPMs
+// FindAll returns all the top RPMs matching the given project and
+// platform, e.g. several builds of a release, highest version first
+func (f *Finder) FindAll(project, platform string) (RPMs, error) {
+	matches, err := f.findTopRPMs(f.files().Glob, project, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	var tops RPMs
+	for _, path := range matches {
+		top, err := newRPM(f.files(), path)
+		if err != nil {
+			return nil, err
+		}
+		tops = append(tops, top)
+	}
+
+	return tops, nil
+}
+
+// Find is the method that finds RPMs. It returns the top RPM followed by
+// its direct dependencies, or by its full dependency closure, each once,
+// when the Finder is created WithTransitive.
 func (f *Finder) Find(project, platform string) (*RPMs, error) {
-	path, err := f.findTopRPM(filepath.Glob, project, platform)
+	return f.FindContext(context.Background(), project, platform)
+}
+
+// FindContext is like Find, but gives up as soon as ctx is done: it is
+// checked between the glob, the top RPM lookup and each dependency lookup.
+// No RPMs are returned when interrupted, only the wrapped ctx error.
+func (f *Finder) FindContext(ctx context.Context, project, platform string) (*RPMs, error) {
+	return f.reportFound(f.find(ctx, project, platform))
+}
+
+func (f *Finder) find(ctx context.Context, project, platform string) (*RPMs, error) {
+	if err := interrupted(ctx); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	path, err := f.findTopRPM(f.files().Glob, project, platform)
+	if err != nil {
+		return nil, err
+	}
+	f.log().Info("found top RPM", "path", path, "elapsed", time.Since(start))
+
+	return f.resolve(ctx, path)
+}
+
+// interrupted returns a wrapped ctx error if ctx is done, else nil
+func interrupted(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("rpm lookup interrupted (%w)", err)
+	}
+
+	return nil
+}
+
+// resolve returns the RPM at the given path, prepended to its dependencies
+func (f *Finder) resolve(ctx context.Context, path string) (*RPMs, error) {
+	if err := interrupted(ctx); err != nil {
+		return nil, err
+	}
+
+	topRPM, err := newRPM(f.files(), path)
 	if err != nil {
 		return nil, err
 	}
-	topRPM, err := New(path)
 	if topRPM.Size == 0 {
-		return nil, fmt.Errorf("%s: RPM has zero size", path)
+		return nil, &ErrZeroSizeRPM{Paths: []string{path}}
 	}
 
-	deps, err := topRPM.LocalDependencies()
+	start := time.Now()
+	deps, missing, err := f.dependencies(ctx, topRPM)
 	if err != nil {
 		return nil, err
 	}
+	f.log().Info("resolved dependencies", "path", path, "found", len(*deps), "unresolved", len(missing), "elapsed", time.Since(start))
 
-	// Ensure that no dependencies have zero size, else fail
-	emptyDeps := deps.ZeroSize()
-	if len(emptyDeps) > 0 {
+	// In strict mode, ensure that all dependencies
+	// are either found or assumed present, else fail
+	if missing = f.reportMissing(missing); f.strict && len(missing) > 0 {
 		err = fmt.Errorf(
-			"%d rpm dependencies in %s have zero size:\n%s",
-			len(emptyDeps),
+			"%d rpm dependencies of %s not found in %s:\n%w",
+			len(missing),
 			path,
-			strings.Join(emptyDeps, "\n"),
+			f.basedir,
+			missingDependencies(missing),
 		)
 		return nil, err
 	}
 
+	// Ensure that no dependencies have zero size, else fail
+	if err := deps.zeroSize(); err != nil {
+		return nil, fmt.Errorf("rpm dependencies of %s (%w)", path, err)
+	}
+
 	// Prepend the topRPM
 	allRPMs := RPMs(append([]*RPM{topRPM}, *deps...))
+
+	// Optionally, ensure that each package is found with only one version
+	if f.conflictCheck {
+		if err := checkConflicts(path, &allRPMs); err != nil {
+			return nil, err
+		}
+	}
+
 	return &allRPMs, nil
 }
 
@@ -137,9 +470,9 @@ func (r *RPMs) ZeroSize() []string {
 }
 
 // Paths returns the paths to each of the RPM instances
-func (r *RPMs) Paths() []string {
+func (r RPMs) Paths() []string {
 	var paths []string
-	for _, rpm := range *r {
+	for _, rpm := range r {
 		paths = append(paths, rpm.Path)
 	}
 
@@ -147,21 +480,48 @@ func (r *RPMs) Paths() []string {
 }
 
 // Names returns the names of each of the RPM instances
-func (r *RPMs) Names() []string {
+func (r RPMs) Names() []string {
 	var names []string
-	for _, rpm := range *r {
+	for _, rpm := range r {
 		names = append(names, rpm.Name())
 	}
 
 	return names
 }
 
+// SortBySizeDesc sorts the RPMs in place, largest first
+func (r RPMs) SortBySizeDesc() {
+	sort.SliceStable(r, func(i, j int) bool {
+		return r[i].Size > r[j].Size
+	})
+}
+
+// Largest returns the n largest RPMs, largest first,
+// leaving the original collection untouched
+func (r RPMs) Largest(n int) RPMs {
+	largest := append(RPMs(nil), r...)
+	largest.SortBySizeDesc()
+	if n < len(largest) {
+		largest = largest[:max(n, 0)]
+	}
+
+	return largest
+}
+
 // ---------------------------------------------------------------------
 
 // RPM is the basic wrapper around the given RPM path
 type RPM struct {
 	Path string
 	Size int64
+
+	// fsys holds the file, the OS file system if nil
+	fsys fileSystem
+
+	// parsed header, cached on first access
+	hdrOnce sync.Once
+	hdr     *rpm.Package
+	hdrErr  error
 }
 
 // Name returns the name of the RPM
@@ -177,25 +537,84 @@ func (r *RPM) NameStartsWith(prefix string) bool {
 // LocalDependencies finds only those dependencies
 // that are in the same directory as the RPM
 func (r *RPM) LocalDependencies() (*RPMs, error) {
-	deps, err := listDeps(r.Path)
+	return r.LocalDependenciesContext(context.Background())
+}
+
+// LocalDependenciesContext is like LocalDependencies,
+// but gives up as soon as ctx is done
+func (r *RPM) LocalDependenciesContext(ctx context.Context) (*RPMs, error) {
+	deps, _, err := r.ResolveLocalContext(ctx)
+	return deps, err
+}
+
+// ResolveLocal matches each of the RPM's Requires to the RPMs in the same
+// directory that provide it, falling back to filename matching for those
+// capabilities that no readable RPM provides. The capabilities that could
+// not be resolved either way are returned alongside the dependencies.
+func (r *RPM) ResolveLocal() (*RPMs, []string, error) {
+	return r.ResolveLocalContext(context.Background())
+}
+
+// ResolveLocalContext is like ResolveLocal, but gives up as soon as ctx
+// is done: it is checked while indexing the directory and stat'ing the
+// dependencies. Only the wrapped ctx error is returned when interrupted.
+func (r *RPM) ResolveLocalContext(ctx context.Context) (*RPMs, []string, error) {
+	rs, err := localResolver(ctx, r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rs.resolve(ctx, r)
+}
+
+// AllDependencies finds the full dependency closure of the RPM within its
+// directory: its dependencies, their own dependencies and so on. Each RPM
+// is returned once, even where dependencies are shared or cyclic.
+func (r *RPM) AllDependencies() (*RPMs, error) {
+	return r.AllDependenciesContext(context.Background())
+}
+
+// AllDependenciesContext is like AllDependencies,
+// but gives up as soon as ctx is done
+func (r *RPM) AllDependenciesContext(ctx context.Context) (*RPMs, error) {
+	rs, err := localResolver(ctx, r)
 	if err != nil {
 		return nil, err
 	}
 
-	deps, err = listDir(filepath.Dir(r.Path), deps)
+	deps, _, err := rs.closure(ctx, r)
+	return deps, err
+}
+
+// localResolver returns the default resolver of the directory of the RPM
+func localResolver(ctx context.Context, r *RPM) (*resolver, error) {
+	fsys := r.files()
+	dir := fsys.Dir(r.Path)
+	idx, err := buildIndex(ctx, fsys, dir, DefaultConcurrency)
 	if err != nil {
 		return nil, err
 	}
 
-	var localdeps []*RPM
-	for _, dep := range deps {
-		depPath := filepath.Join(filepath.Dir(r.Path), dep)
-		fi, err := os.Stat(depPath)
+	return &resolver{fsys: fsys, dir: dir, index: idx, strategy: CapabilityThenFilename}, nil
+}
+
+// statDeps creates the RPM instances for the given dependency filenames in
+// dir. Up to concurrency files are stat'ed in parallel, the order of the
+// returned RPMs following that of the filenames regardless. The first
+// failure stops the remaining work and is returned.
+func statDeps(ctx context.Context, fsys fileSystem, dir string, filenames []string, concurrency int) (*RPMs, error) {
+	localdeps := make([]*RPM, len(filenames))
+	err := forEach(ctx, len(filenames), concurrency, func(i int) error {
+		depPath := fsys.Join(dir, filenames[i])
+		fi, err := fsys.Stat(depPath)
 		if err != nil {
-			return nil, fmt.Errorf("cannot get file size for dependency %s (%w)", depPath, err)
+			return fmt.Errorf("cannot get file size for dependency %s (%w)", depPath, err)
 		}
-		depSize := fi.Size()
-		localdeps = append(localdeps, &RPM{depPath, depSize})
+		localdeps[i] = &RPM{Path: depPath, Size: fi.Size(), fsys: ownFS(fsys)}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	rpmsList := RPMs(localdeps)
@@ -204,25 +623,8 @@ func (r *RPM) LocalDependencies() (*RPMs, error) {
 
 // --------------------------------------------------------------------
 
-// listDeps is a helper function to get the names of
-// dependencies of a given starting root RPM
-func listDeps(path string) ([]string, error) {
-	p, err := rpm.OpenPackageFile(path)
-	if err != nil {
-		return nil, err
-	}
-
-	deps := p.Requires()
-	names := make([]string, len(deps))
-	for _, dep := range deps {
-		names = append(names, dep.Name())
-	}
-
-	return names, nil
-}
-
-func listDir(dir string, filenames []string) ([]string, error) {
-	entries, err := os.ReadDir(dir)
+func listDir(fsys fileSystem, dir string, filenames []string, match FilenameMatch) ([]string, error) {
+	entries, err := fsys.ReadDir(dir)
 	if err != nil {
 		return nil, err
 	}
@@ -232,7 +634,7 @@ func listDir(dir string, filenames []string) ([]string, error) {
 	var found []string
 	for _, entry := range entries {
 		name := entry.Name()
-		if _, keyExists := lut[name]; keyExists && !entry.IsDir() {
+		if _, keyExists := lut[match.key(name)]; keyExists && !entry.IsDir() {
 			found = append(found, name)
 		}
 	}
@@ -257,3 +659,14 @@ func fileSize(path string) (int64, error) {
 
 	return fi.Size(), nil
 }
+
+// InstalledSize returns the total size in bytes of the RPMs once installed,
+// as declared by their headers, those that cannot be read counting for 0
+func (r RPMs) InstalledSize() int64 {
+	var size int64
+	for _, rpm := range r {
+		size += rpm.InstalledSize()
+	}
+
+	return size
+}
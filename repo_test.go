@@ -1,9 +1,12 @@
 package rpm
 
 import (
+	"context"
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
 )
 
@@ -40,6 +43,15 @@ func TestRepoStringer(t *testing.T) {
 	}
 }
 
+func TestRepoFromPathArg(t *testing.T) {
+	got := createRepo().RepoFromPathArg()
+	expect := "--repofrompath=label,https://example.repo --setopt=label.enabled=false"
+
+	if got != expect {
+		t.Errorf("Repo RepoFromPathArg should return %s, got %s", expect, got)
+	}
+}
+
 func TestRepoName(t *testing.T) {
 	got := createRepo().Filename()
 	if got != "label.repo" {
@@ -47,6 +59,68 @@ func TestRepoName(t *testing.T) {
 	}
 }
 
+func TestRepoConflictsInDir(t *testing.T) {
+	dir := t.TempDir()
+	repo := createRepo()
+
+	// The repo's own file is not a conflict, as writing the repo replaces it
+	if err := os.WriteFile(filepath.Join(dir, repo.Filename()), []byte(repo.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "other.repo"), []byte("[other]\nname=other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conflict, _, err := repo.ConflictsInDir(dir)
+	if err != nil || conflict {
+		t.Errorf("Repo ConflictsInDir should find no conflict, got %t (%v)", conflict, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "clash.repo"), []byte("[other]\n\n [ label ] \nname=clash\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conflict, filename, err := repo.ConflictsInDir(dir)
+	if err != nil || !conflict || filename != "clash.repo" {
+		t.Errorf("Repo ConflictsInDir should report clash.repo, got %t %s (%v)", conflict, filename, err)
+	}
+}
+
+func TestReposLookups(t *testing.T) {
+	var empty Repos
+	if got := empty.Enabled(); len(got) != 0 {
+		t.Errorf("Enabled on an empty collection should return nothing, got %v", got)
+	}
+	if _, found := empty.ByLabel("label"); found {
+		t.Errorf("ByLabel on an empty collection should find nothing")
+	}
+	if got := empty.ByName("repo"); len(got) != 0 {
+		t.Errorf("ByName on an empty collection should return nothing, got %v", got)
+	}
+
+	repos := Repos{
+		{Name: "Nightly", Label: "nightly", URL: "https://first", Enabled: true},
+		{Name: "nightly", Label: "nightly", URL: "https://second"},
+		{Name: "Release", Label: "release", Enabled: true},
+	}
+
+	if got := repos.Enabled(); len(got) != 2 || got[0].URL != "https://first" || got[1].Label != "release" {
+		t.Errorf("Enabled should return the first and last repos, got %v", got)
+	}
+
+	repo, found := repos.ByLabel("nightly")
+	if !found || repo.URL != "https://first" {
+		t.Errorf("ByLabel should return the first repo with a duplicate label, got %v", repo)
+	}
+	if _, found := repos.ByLabel("Nightly"); found {
+		t.Errorf("ByLabel should be case-sensitive")
+	}
+
+	if got := repos.ByName("NIGHTLY"); len(got) != 2 {
+		t.Errorf("ByName should match both nightly repos regardless of case, got %v", got)
+	}
+}
+
 func TestRPMsNames(t *testing.T) {
 	rpms := createRPMs()
 	got := len(rpms.Names())
@@ -55,6 +129,28 @@ func TestRPMsNames(t *testing.T) {
 	}
 }
 
+func TestRPMsLargest(t *testing.T) {
+	rpms := append(*createRPMs(), &RPM{Path: "/blip/blop3", Size: 99999})
+
+	got := rpms.Largest(2)
+	if len(got) != 2 || got[0].Name() != "blop3" || got[1].Name() != "blop" {
+		t.Errorf("RPMs Largest(2) should return blop3 and blop, got %v", got.Names())
+	}
+
+	if rpms[0].Name() != "blop" {
+		t.Errorf("RPMs Largest should not reorder the collection, got %v", rpms.Names())
+	}
+
+	if got := rpms.Largest(10); len(got) != 3 {
+		t.Errorf("RPMs Largest(10) should return 3 RPMs, got %d", len(got))
+	}
+
+	rpms.SortBySizeDesc()
+	if rpms[2].Name() != "blop2" {
+		t.Errorf("RPMs SortBySizeDesc should put blop2 last, got %v", rpms.Names())
+	}
+}
+
 func TestRPMsZeroLength(t *testing.T) {
 	got := createRPMs().ZeroSize()
 	if len(got) != 1 {
@@ -68,7 +164,7 @@ func TestRPMsZeroLength(t *testing.T) {
 
 func TestRPMFinderInexistantPath(t *testing.T) {
 	// Inexistant path, so expect an error
-	f := Finder{"/blip/blop"}
+	f := Finder{basedir: "/blip/blop"}
 	_, err := f.findTopRPM(filepath.Glob, "project", "platform")
 	if err == nil {
 		t.Errorf("RPM finder should have returned an error, got nil")
@@ -77,7 +173,7 @@ func TestRPMFinderInexistantPath(t *testing.T) {
 
 func TestRPMFinderTopRPM(t *testing.T) {
 	// Inexistant path, so expect an error
-	f := Finder{"/blip/blop"}
+	f := Finder{basedir: "/blip/blop"}
 	getMatches := func(string) ([]string, error) {
 		return []string{"topRPM.rpm"}, nil
 	}
@@ -88,6 +184,140 @@ func TestRPMFinderTopRPM(t *testing.T) {
 	}
 }
 
+func TestRPMLocalDependencies(t *testing.T) {
+	dir := t.TempDir()
+	path := writeRPM(t, dir, "top.rpm", fixtureRPM{
+		Name: "top", Version: "1", Release: "1",
+		Requires: []fixtureDep{{Name: "a"}, {Name: "b"}, {Name: "a", Flags: 8, Version: "1.0"}, {Name: "c"}},
+	})
+	for _, name := range []string{"a", "b", "c"} {
+		writeRPM(t, dir, name+".rpm", fixtureRPM{Name: name, Version: "1.0", Release: "1"})
+	}
+
+	got, err := (&RPM{Path: path}).LocalDependencies()
+	if err != nil {
+		t.Fatalf("LocalDependencies failed (%v)", err)
+	}
+
+	if len(*got) != 3 {
+		t.Errorf("LocalDependencies should return 3 unique Requires, got %d (%q)", len(*got), got.Names())
+	}
+
+	for _, name := range got.Names() {
+		if name == "" {
+			t.Errorf("LocalDependencies should not return blank names, got %q", got.Names())
+		}
+	}
+}
+
+func TestRPMResolveLocal(t *testing.T) {
+	dir := t.TempDir()
+	top := writeRPM(t, dir, "top.rpm", fixtureRPM{
+		Name: "top", Version: "1", Release: "1",
+		Requires: []fixtureDep{
+			{Name: "libstdc++.so.6"},
+			{Name: "atlas-project", Flags: 12, Version: "1.2"},
+			{Name: "libmissing.so"},
+		},
+	})
+	gcc := writeRPM(t, dir, "gcc-libs-11.2-1.x86_64.rpm", fixtureRPM{
+		Name: "gcc-libs", Version: "11.2", Release: "1",
+		Provides: []fixtureDep{{Name: "libstdc++.so.6"}},
+	})
+	project := writeRPM(t, dir, "AtlasProject_22.0.1_x86_64.rpm", fixtureRPM{
+		Name: "atlas-project", Version: "22.0.1", Release: "1",
+	})
+
+	deps, unresolved, err := (&RPM{Path: top}).ResolveLocal()
+	if err != nil {
+		t.Fatalf("ResolveLocal failed (%v)", err)
+	}
+
+	if got := deps.Paths(); len(got) != 2 || got[0] != gcc || got[1] != project {
+		t.Errorf("ResolveLocal should resolve to %s and %s, got %v", gcc, project, got)
+	}
+
+	if len(unresolved) != 1 || unresolved[0] != "libmissing.so" {
+		t.Errorf("ResolveLocal should leave libmissing.so unresolved, got %v", unresolved)
+	}
+}
+
+// countdownCtx is a context that becomes cancelled, for good, after a number
+// of Err calls. The calls are counted atomically, as the workers of forEach
+// make them concurrently, so that it is cancelled at the same point of a
+// lookup whichever worker makes the last call.
+type countdownCtx struct {
+	context.Context
+	calls atomic.Int64
+}
+
+func newCountdownCtx(calls int64) *countdownCtx {
+	c := &countdownCtx{Context: context.Background()}
+	c.calls.Store(calls)
+	return c
+}
+
+func (c *countdownCtx) Err() error {
+	if c.calls.Add(-1) < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestFindContextCancelledMidWalk(t *testing.T) {
+	dir := t.TempDir()
+	writeRPM(t, dir, "project_1.0_el9.rpm", fixtureRPM{
+		Name: "project", Version: "1.0", Release: "1",
+		Requires: []fixtureDep{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+	})
+	for _, name := range []string{"a", "b", "c"} {
+		writeRPM(t, dir, name+".rpm", fixtureRPM{Name: name, Version: "1", Release: "1"})
+	}
+
+	f := NewFinder(dir)
+	if rpms, err := f.FindContext(context.Background(), "project", "el9"); err != nil || len(*rpms) != 4 {
+		t.Fatalf("FindContext should return 4 RPMs, got %v (%v)", rpms, err)
+	}
+
+	// Pass the glob, top RPM and first dependency checks, then cancel
+	ctx := newCountdownCtx(3)
+	rpms, err := f.FindContext(ctx, "project", "el9")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("FindContext should return context.Canceled, got %v", err)
+	}
+
+	if rpms != nil {
+		t.Errorf("FindContext should not return partial results, got %v", rpms.Names())
+	}
+}
+
+func TestRPMDependenciesContext(t *testing.T) {
+	dir := createChainDir(t)
+	top, _ := New(filepath.Join(dir, "a_1.0_el9.rpm"))
+
+	deps, err := top.LocalDependenciesContext(context.Background())
+	if err != nil || len(*deps) != 2 {
+		t.Errorf("LocalDependenciesContext should find b and c, got %v (%v)", deps, err)
+	}
+
+	// Cancelled while indexing the directory
+	ctx := newCountdownCtx(2)
+	if _, err := top.LocalDependenciesContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("LocalDependenciesContext should return context.Canceled, got %v", err)
+	}
+
+	ctx = newCountdownCtx(2)
+	if _, err := top.AllDependenciesContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("AllDependenciesContext should return context.Canceled, got %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewFinder(dir).FindFallbackContext(cancelled, "a", []string{"el8", "el9"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("FindFallbackContext should return context.Canceled, got %v", err)
+	}
+}
+
 func TestNewRPM(t *testing.T) {
 	dir, err := ioutil.TempDir("", "atlas-rpm-installer-test")
 	if err != nil {
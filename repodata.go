@@ -0,0 +1,190 @@
+package rpm
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/cavaliergopher/rpm"
+)
+
+// repomdPath is the location of the repo metadata index, relative to the repo
+const repomdPath = "repodata/repomd.xml"
+
+// repomd is the repo metadata index, listing the metadata files of a repo
+type repomd struct {
+	Data []struct {
+		Type     string       `xml:"type,attr"`
+		Location repoLocation `xml:"location"`
+	} `xml:"data"`
+}
+
+type repoLocation struct {
+	Href string `xml:"href,attr"`
+}
+
+// primary is the primary metadata of a repo, describing all its packages
+type primary struct {
+	Packages []repoPackage `xml:"package"`
+}
+
+// repoPackage is a package entry of the primary metadata
+type repoPackage struct {
+	Name    string `xml:"name"`
+	Arch    string `xml:"arch"`
+	Version struct {
+		Epoch string `xml:"epoch,attr"`
+		Ver   string `xml:"ver,attr"`
+		Rel   string `xml:"rel,attr"`
+	} `xml:"version"`
+	Checksum struct {
+		Type  string `xml:"type,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"checksum"`
+	Size struct {
+		Package int64 `xml:"package,attr"`
+	} `xml:"size"`
+	Location repoLocation `xml:"location"`
+	Provides []repoEntry  `xml:"format>provides>entry"`
+	Requires []repoEntry  `xml:"format>requires>entry"`
+
+	Recommends []repoEntry `xml:"format>recommends>entry"`
+	Suggests   []repoEntry `xml:"format>suggests>entry"`
+
+	// repo is the index of the repo listing the package in a RemoteFinder
+	repo int
+}
+
+// repoEntry is a provides, requires or weak dependency entry of a package
+type repoEntry struct {
+	Name  string `xml:"name,attr"`
+	Flags string `xml:"flags,attr"`
+	Epoch string `xml:"epoch,attr"`
+	Ver   string `xml:"ver,attr"`
+	Rel   string `xml:"rel,attr"`
+}
+
+// Filename returns the base name of the package file
+func (p repoPackage) Filename() string {
+	return path.Base(p.Location.Href)
+}
+
+// dependencies returns the requires of the package, followed by
+// its weak dependencies of the given setting
+func (p repoPackage) dependencies(weak WeakDeps) []repoEntry {
+	deps := p.Requires
+	if weak >= WithRecommendsDeps {
+		deps = append(deps[:len(deps):len(deps)], p.Recommends...)
+	}
+	if weak >= WithSuggestsDeps {
+		deps = append(deps[:len(deps):len(deps)], p.Suggests...)
+	}
+
+	return deps
+}
+
+// checksumAlgos maps the checksum types of the repodata to hash names,
+// "sha" being the legacy name of sha1
+var checksumAlgos = map[string]string{
+	"md5":    "md5",
+	"sha":    "sha1",
+	"sha1":   "sha1",
+	"sha256": "sha256",
+	"sha512": "sha512",
+}
+
+// verifyChecksum checks the package file at path against the checksum
+// recorded in the repodata, returning an error wrapping ErrChecksumMismatch
+// if they differ
+func (p repoPackage) verifyChecksum(path string) error {
+	algo, ok := checksumAlgos[p.Checksum.Type]
+	if !ok {
+		return fmt.Errorf("%s: unsupported repodata checksum type %q", p.Filename(), p.Checksum.Type)
+	}
+
+	got, err := (&RPM{Path: path}).Checksum(algo)
+	if err != nil {
+		return err
+	}
+
+	if want := strings.TrimSpace(p.Checksum.Value); got != want {
+		return fmt.Errorf("%s: %w (%s %s, repodata has %s)", p.Filename(), ErrChecksumMismatch, algo, got, want)
+	}
+
+	return nil
+}
+
+func (p repoPackage) evr() evr {
+	epoch, _ := strconv.Atoi(p.Version.Epoch)
+	return evr{epoch: epoch, version: p.Version.Ver, release: p.Version.Rel}
+}
+
+// repoFlags maps the repodata names of comparisons to dependency flags,
+// the inverse of mdFlags
+var repoFlags = map[string]int{
+	"LT": rpm.DepFlagLesser,
+	"GT": rpm.DepFlagGreater,
+	"EQ": rpm.DepFlagEqual,
+	"LE": rpm.DepFlagLesserOrEqual,
+	"GE": rpm.DepFlagGreaterOrEqual,
+}
+
+// constraint returns the entry as a dependency, to be compared with overlaps
+func (e repoEntry) constraint() constraint {
+	epoch, _ := strconv.Atoi(e.Epoch)
+	return constraint{name: e.Name, flags: repoFlags[e.Flags], version: formatEVR(epoch, e.Ver, e.Rel)}
+}
+
+// fetchPrimary downloads and parses the primary metadata of the repo
+func (r Repo) fetchPrimary(ctx context.Context) ([]repoPackage, error) {
+	body, err := r.get(ctx, repomdPath)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var md repomd
+	if err := xml.NewDecoder(body).Decode(&md); err != nil {
+		return nil, fmt.Errorf("failed to parse %s (%w)", r.packageURL(repomdPath), err)
+	}
+
+	for _, data := range md.Data {
+		if data.Type == "primary" {
+			return r.parsePrimary(ctx, data.Location.Href)
+		}
+	}
+
+	return nil, fmt.Errorf("no primary metadata in %s", r.packageURL(repomdPath))
+}
+
+// parsePrimary downloads and parses the primary metadata file at href,
+// which is gzip compressed if its name ends with .gz
+func (r Repo) parsePrimary(ctx context.Context, href string) ([]repoPackage, error) {
+	body, err := r.get(ctx, href)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var src io.Reader = body
+	if strings.HasSuffix(href, ".gz") {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s (%w)", r.packageURL(href), err)
+		}
+		defer gz.Close()
+		src = gz
+	}
+
+	var md primary
+	if err := xml.NewDecoder(src).Decode(&md); err != nil {
+		return nil, fmt.Errorf("failed to parse %s (%w)", r.packageURL(href), err)
+	}
+
+	return md.Packages, nil
+}
@@ -0,0 +1,241 @@
+package rpm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseRepo parses a repo description with a single [label] section,
+// in the format written by Repo.String
+func ParseRepo(r io.Reader) (Repo, error) {
+	repos, err := ParseRepos(r)
+	if err != nil {
+		return Repo{}, err
+	}
+
+	if len(repos) != 1 {
+		return Repo{}, fmt.Errorf("expected a single repo section, got %d", len(repos))
+	}
+
+	return repos[0], nil
+}
+
+// ParseRepoFile parses all the repo sections of the .repo file at path
+func ParseRepoFile(path string) (Repos, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	repos, err := ParseRepos(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return repos, nil
+}
+
+// ParseReposFile parses all the repo sections of the .repo file at path
+//
+// Deprecated: use ParseRepoFile
+func ParseReposFile(path string) (Repos, error) {
+	return ParseRepoFile(path)
+}
+
+// ParseRepos parses all the repo sections of a .repo file, in the ini-like
+// format of yum and dnf. Blank lines, comments and surrounding whitespace
+// are tolerated, keys not modelled by Repo ignored.
+func ParseRepos(r io.Reader) (Repos, error) {
+	var repos Repos
+	scanner := bufio.NewScanner(r)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			label := strings.TrimSpace(line[1 : len(line)-1])
+			if label == "" {
+				return nil, fmt.Errorf("line %d: empty section label", lineno)
+			}
+			repos = append(repos, Repo{Label: label})
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected key=value, got %q", lineno, line)
+		}
+		if len(repos) == 0 {
+			return nil, fmt.Errorf("line %d: %q outside of a [label] section", lineno, line)
+		}
+
+		if err := repos[len(repos)-1].set(strings.TrimSpace(key), strings.TrimSpace(value)); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineno, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(repos) == 0 {
+		return nil, fmt.Errorf("no [label] section found")
+	}
+
+	return repos, nil
+}
+
+// set assigns the value of a repo file key
+func (r *Repo) set(key, value string) error {
+	switch key {
+	case "name":
+		r.Name = value
+	case "baseurl":
+		// Several URLs may be given, separated by spaces or commas
+		urls := strings.FieldsFunc(value, func(c rune) bool {
+			return c == ',' || unicode.IsSpace(c)
+		})
+		r.URL, r.Mirrors = "", ""
+		if len(urls) > 0 {
+			r.URL, r.Mirrors = urls[0], strings.Join(urls[1:], " ")
+		}
+	case "mirrorlist":
+		r.MirrorList = value
+	case "metalink":
+		r.Metalink = value
+	case "prefix":
+		r.Prefix = value
+	case "enabled":
+		enabled, err := parseBool(value)
+		if err != nil {
+			return fmt.Errorf("bad enabled value (%w)", err)
+		}
+		r.Enabled = enabled
+	case "gpgcheck":
+		check, err := parseBool(value)
+		if err != nil {
+			return fmt.Errorf("bad gpgcheck value (%w)", err)
+		}
+		r.GPGCheck = &check
+	case "gpgkey":
+		r.GPGKey = value
+	case "priority":
+		priority, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("bad priority value (%w)", err)
+		}
+		r.Priority = priority
+	case "proxy":
+		r.Proxy = value
+	case "metadata_expire":
+		r.MetadataExpire = value
+	}
+
+	return nil
+}
+
+// parseBool parses the boolean values accepted by yum and dnf
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "1", "true", "yes", "on":
+		return true, nil
+	case "0", "false", "no", "off":
+		return false, nil
+	}
+
+	return false, fmt.Errorf("%q is not a boolean", value)
+}
+
+// boolToInt renders a boolean as the 0 or 1 written in repo files
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+// WriteOption configures Repos.WriteTo
+type WriteOption func(*writeOptions)
+
+type writeOptions struct {
+	includeDisabled bool
+}
+
+// IncludeDisabled sets whether Repos.WriteTo writes disabled repos,
+// which it does by default
+func IncludeDisabled(include bool) WriteOption {
+	return func(o *writeOptions) {
+		o.includeDisabled = include
+	}
+}
+
+// WriteTo writes each repo to its Filename in dir, creating dir if needed
+func (r Repos) WriteTo(dir string, opts ...WriteOption) error {
+	o := writeOptions{includeDisabled: true}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	for _, repo := range r {
+		if !repo.Enabled && !o.includeDisabled {
+			continue
+		}
+
+		if err := repo.Write(dir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Write writes the repo description to its Filename in dir, creating dir if
+// needed. The file is written in full to a temporary file first, then moved
+// into place, so that a failure never leaves a truncated file behind.
+func (r Repo) Write(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, r.Filename())
+	if err := writeFileAtomic(path, []byte(r.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write repo %s (%w)", path, err)
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path, then renames it
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
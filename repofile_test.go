@@ -0,0 +1,149 @@
+package rpm
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRepoRoundTrip(t *testing.T) {
+	for _, repo := range []Repo{*createRepo(), {Name: "n", Label: "l", URL: "file:///x", Enabled: true}} {
+		got, err := ParseRepo(strings.NewReader(repo.String()))
+		if err != nil {
+			t.Fatalf("ParseRepo failed (%v)", err)
+		}
+
+		if got != repo {
+			t.Errorf("ParseRepo should reproduce %+v, got %+v", repo, got)
+		}
+	}
+}
+
+func TestParseRepoExtraFields(t *testing.T) {
+	input := "[atlas]\nname=ATLAS\nbaseurl=https://atlas.repo\nenabled=1\ngpgcheck=1\n" +
+		"gpgkey=file:///etc/pki/rpm-gpg/RPM-GPG-KEY-atlas\npriority=10\n" +
+		"proxy=http://proxy:3128\nmetadata_expire=6h\nskip_if_unavailable=1\n"
+	got, err := ParseRepo(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseRepo failed (%v)", err)
+	}
+
+	if got.GPGCheck == nil || !*got.GPGCheck || got.GPGKey != "file:///etc/pki/rpm-gpg/RPM-GPG-KEY-atlas" ||
+		got.Priority != 10 || got.Proxy != "http://proxy:3128" || got.MetadataExpire != "6h" {
+		t.Errorf("ParseRepo should parse the extra fields, got %+v", got)
+	}
+
+	again, err := ParseRepo(strings.NewReader(got.String()))
+	if err != nil || !reflect.DeepEqual(again, got) {
+		t.Errorf("ParseRepo should reproduce %+v, got %+v (%v)", got, again, err)
+	}
+
+	expect := "--repofrompath=atlas,https://atlas.repo --setopt=atlas.enabled=true " +
+		"--setopt=atlas.gpgcheck=true --setopt=atlas.gpgkey=file:///etc/pki/rpm-gpg/RPM-GPG-KEY-atlas"
+	if arg := got.RepoFromPathArg(); arg != expect {
+		t.Errorf("RepoFromPathArg should return %s, got %s", expect, arg)
+	}
+}
+
+func TestParseRepoTolerant(t *testing.T) {
+	input := "\n# comment\n  [ label ]  \n\n name = repo \nbaseurl=https://example.repo\nenabled = 1\ngpgcheck=0\n"
+	got, err := ParseRepo(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseRepo failed (%v)", err)
+	}
+
+	gpgcheck := false
+	expect := Repo{Name: "repo", Label: "label", URL: "https://example.repo", Enabled: true, GPGCheck: &gpgcheck}
+	if !reflect.DeepEqual(got, expect) {
+		t.Errorf("ParseRepo should return %+v, got %+v", expect, got)
+	}
+}
+
+func TestParseRepoErrors(t *testing.T) {
+	inputs := []string{
+		"",
+		"name=repo\n",
+		"[label]\nenabled=maybe\n",
+		"[label]\ngpgcheck=maybe\n",
+		"[label]\npriority=high\n",
+		"[label]\njunk\n",
+		"[]\n",
+		"[one]\n[two]\n",
+	}
+
+	for _, input := range inputs {
+		if _, err := ParseRepo(strings.NewReader(input)); err == nil {
+			t.Errorf("ParseRepo should fail for %q, got nil", input)
+		}
+	}
+}
+
+func TestParseRepoFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "multi.repo")
+	other := Repo{Name: "other", Label: "other", URL: "https://other.repo"}
+	os.WriteFile(path, []byte(createRepo().String()+"\n"+other.String()), 0644)
+
+	repos, err := ParseRepoFile(path)
+	if err != nil {
+		t.Fatalf("ParseRepoFile failed (%v)", err)
+	}
+
+	if len(repos) != 2 || repos[0] != *createRepo() || repos[1] != other {
+		t.Errorf("ParseRepoFile should return both repos, got %+v", repos)
+	}
+
+	if _, err := ParseRepoFile("/blip/blop.repo"); err == nil {
+		t.Errorf("ParseRepoFile should fail for an inexistant file, got nil")
+	}
+}
+
+func TestReposWriteTo(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "yum.repos.d")
+	enabled := Repo{Name: "on", Label: "on", URL: "https://on.repo", Enabled: true}
+	repos := Repos{*createRepo(), enabled}
+
+	if err := repos.WriteTo(dir, IncludeDisabled(false)); err != nil {
+		t.Fatalf("WriteTo failed (%v)", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "label.repo")); !os.IsNotExist(err) {
+		t.Errorf("WriteTo should skip disabled repos when asked, got %v", err)
+	}
+
+	if err := repos.WriteTo(dir); err != nil {
+		t.Fatalf("WriteTo failed (%v)", err)
+	}
+
+	for _, repo := range repos {
+		path := filepath.Join(dir, repo.Filename())
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("WriteTo should have written %s (%v)", path, err)
+		}
+
+		if fi.Mode().Perm() != 0644 {
+			t.Errorf("WriteTo should write %s with 0644 permissions, got %v", path, fi.Mode())
+		}
+
+		got, err := ParseRepoFile(path)
+		if err != nil || len(got) != 1 || got[0] != repo {
+			t.Errorf("WriteTo should write %+v to %s, got %+v (%v)", repo, path, got, err)
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("WriteTo should leave no temporary files behind, got %d entries", len(entries))
+	}
+}
+
+func TestRepoWriteFailure(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(file, nil, 0644)
+
+	if err := createRepo().Write(file); err == nil {
+		t.Errorf("Write into a regular file should fail, got nil")
+	}
+}
@@ -0,0 +1,101 @@
+package rpm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ErrRepoConflict is returned when merging repos that define
+// the same label differently
+var ErrRepoConflict = errors.New("conflicting repo definitions")
+
+// defaultPriority is the priority of repos with none set, as with dnf
+const defaultPriority = 99
+
+// priority returns the priority of the repo, defaultPriority if unset
+func (r Repo) priority() int {
+	if r.Priority == 0 {
+		return defaultPriority
+	}
+
+	return r.Priority
+}
+
+// Merge returns the repos of the collection followed by those of other
+// whose label it lacks. A repo of other with the same label as one of the
+// collection must have the same definition, and is then dropped, else an
+// error wrapping ErrRepoConflict lists the conflicting labels. Neither
+// collection is modified.
+func (r Repos) Merge(other Repos) (Repos, error) {
+	merged := append(Repos(nil), r...)
+
+	var conflicts []error
+	for _, repo := range other {
+		existing, found := merged.ByLabel(repo.Label)
+		switch {
+		case !found:
+			merged = append(merged, repo)
+		case !reflect.DeepEqual(existing, repo):
+			conflicts = append(conflicts, fmt.Errorf("%w for label %s", ErrRepoConflict, repo.Label))
+		}
+	}
+
+	if err := errors.Join(conflicts...); err != nil {
+		return nil, err
+	}
+
+	return merged, nil
+}
+
+// Dedupe returns the repos of the collection without those whose label
+// is that of an earlier one, which yum would ignore
+func (r Repos) Dedupe() Repos {
+	var repos Repos
+	seen := map[string]struct{}{}
+	for _, repo := range r {
+		if _, keyExists := seen[repo.Label]; !keyExists {
+			seen[repo.Label] = struct{}{}
+			repos = append(repos, repo)
+		}
+	}
+
+	return repos
+}
+
+// Enable enables, in place, the repos with the given label, and
+// indicates if there is any
+func (r Repos) Enable(label string) bool {
+	return r.setEnabled(label, true)
+}
+
+// Disable disables, in place, the repos with the given label, and
+// indicates if there is any
+func (r Repos) Disable(label string) bool {
+	return r.setEnabled(label, false)
+}
+
+func (r Repos) setEnabled(label string, enabled bool) bool {
+	found := false
+	for i := range r {
+		if r[i].Label == label {
+			r[i].Enabled = enabled
+			found = true
+		}
+	}
+
+	return found
+}
+
+// ByPriority returns the repos sorted by priority, the preferred (lowest)
+// first, repos with no priority set having that of dnf, 99. Repos of the
+// same priority keep their order.
+func (r Repos) ByPriority() Repos {
+	repos := append(Repos(nil), r...)
+	sort.SliceStable(repos, func(i, j int) bool {
+		return repos[i].priority() < repos[j].priority()
+	})
+
+	return repos
+}
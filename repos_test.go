@@ -0,0 +1,143 @@
+package rpm
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReposMerge(t *testing.T) {
+	stable := Repos{
+		{Label: "stable", URL: "https://stable", Enabled: true},
+		{Label: "nightly", URL: "https://nightly"},
+	}
+	others := Repos{
+		{Label: "nightly", URL: "https://nightly"},
+		{Label: "testing", URL: "https://testing"},
+	}
+
+	merged, err := stable.Merge(others)
+	if err != nil {
+		t.Fatalf("Merge failed (%v)", err)
+	}
+	if got := labels(merged); got != "stable,nightly,testing" {
+		t.Errorf("Merge should append the new repos and drop the identical ones, got %s", got)
+	}
+
+	conflicting := Repos{{Label: "nightly", URL: "https://elsewhere"}, {Label: "stable", URL: "https://stable"}}
+	if _, err := stable.Merge(conflicting); !errors.Is(err, ErrRepoConflict) || !strings.Contains(err.Error(), "label nightly") {
+		t.Errorf("Merge should fail on a conflicting definition, got %v", err)
+	}
+
+	if len(stable) != 2 || len(others) != 2 {
+		t.Errorf("Merge should not modify the collections")
+	}
+}
+
+func TestReposDedupeEnableDisable(t *testing.T) {
+	repos := Repos{
+		{Label: "nightly", URL: "https://first"},
+		{Label: "stable", URL: "https://stable", Enabled: true},
+		{Label: "nightly", URL: "https://second"},
+	}
+
+	if got := repos.Dedupe(); len(got) != 2 || got[0].URL != "https://first" || got[1].Label != "stable" {
+		t.Errorf("Dedupe should keep the first repo of each label, got %v", got)
+	}
+
+	if !repos.Enable("nightly") || !repos[0].Enabled || !repos[2].Enabled {
+		t.Errorf("Enable should enable both nightly repos in place, got %v", repos)
+	}
+	if !repos.Disable("stable") || repos[1].Enabled {
+		t.Errorf("Disable should disable the stable repo in place, got %v", repos)
+	}
+	if repos.Enable("testing") || repos.Disable("testing") {
+		t.Errorf("Enable and Disable should report an unknown label")
+	}
+}
+
+func TestReposByPriority(t *testing.T) {
+	repos := Repos{
+		{Label: "default"},
+		{Label: "low", Priority: 500},
+		{Label: "high", Priority: 1},
+		{Label: "explicit-default", Priority: 99},
+	}
+
+	if got := labels(repos.ByPriority()); got != "high,default,explicit-default,low" {
+		t.Errorf("ByPriority should sort the repos by priority, unset being 99, got %s", got)
+	}
+	if repos[0].Label != "default" {
+		t.Errorf("ByPriority should not modify the collection")
+	}
+}
+
+func TestReposFinder(t *testing.T) {
+	serve := func(rpms map[string]fixtureRPM) string {
+		dir := t.TempDir()
+		for filename, spec := range rpms {
+			writeRPM(t, dir, filename, spec)
+		}
+		if err := CreateRepo(dir); err != nil {
+			t.Fatalf("CreateRepo failed (%v)", err)
+		}
+
+		srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+		t.Cleanup(srv.Close)
+		return srv.URL
+	}
+
+	stable := serve(map[string]fixtureRPM{
+		"Athena_22.0.1_x86_64.rpm": {Name: "Athena", Version: "22.0.1", Release: "1", Requires: []fixtureDep{{Name: "Gaudi"}, {Name: "tbb"}}},
+		"Gaudi-1.0.rpm":            {Name: "Gaudi", Version: "1.0", Release: "1"},
+	})
+	nightly := serve(map[string]fixtureRPM{
+		"Athena_22.0.2_x86_64.rpm": {Name: "Athena", Version: "22.0.2", Release: "1"},
+		"Gaudi-2.0.rpm":            {Name: "Gaudi", Version: "2.0", Release: "1"},
+		"tbb-2020.rpm":             {Name: "tbb", Version: "2020", Release: "1"},
+	})
+
+	repos := Repos{
+		{Label: "nightly", URL: nightly, Enabled: true},
+		{Label: "stable", URL: stable, Enabled: true, Priority: 10},
+		{Label: "disabled", URL: "http://127.0.0.1:1"},
+	}
+
+	rpms, err := NewReposFinder(repos, t.TempDir()).Find("Athena", "x86_64")
+	if err != nil {
+		t.Fatalf("Find failed (%v)", err)
+	}
+	if got := strings.Join(rpms.Names(), ","); got != "Athena_22.0.1_x86_64.rpm,Gaudi-1.0.rpm,tbb-2020.rpm" {
+		t.Errorf("Find should take Athena and Gaudi from the stable repo, of a higher priority, got %s", got)
+	}
+
+	repos[1].Priority = 0
+	rpms, err = NewReposFinder(repos, t.TempDir()).Find("Athena", "x86_64")
+	if err != nil {
+		t.Fatalf("Find failed (%v)", err)
+	}
+	if got := strings.Join(rpms.Names(), ","); got != "Athena_22.0.2_x86_64.rpm" {
+		t.Errorf("Find should take the highest version among repos of the same priority, got %s", got)
+	}
+
+	if _, err := NewReposFinder(Repos{repos[2]}, t.TempDir()).Find("Athena", "x86_64"); err == nil {
+		t.Errorf("Find should fail without enabled repos")
+	}
+
+	_, err = NewReposFinder(repos, t.TempDir()).Find("Gaudi", "x86_64")
+	if !errors.Is(err, ErrNoTopRPM) || !strings.Contains(err.Error(), stable) || !strings.Contains(err.Error(), nightly) {
+		t.Errorf("Find should report the URLs of all the repos, got %v", err)
+	}
+}
+
+// labels joins the labels of the repos
+func labels(repos Repos) string {
+	var l []string
+	for _, repo := range repos {
+		l = append(l, repo.Label)
+	}
+
+	return strings.Join(l, ",")
+}
@@ -0,0 +1,104 @@
+package rpm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ResolveAll resolves the local dependencies of each of the given top RPMs
+// and returns their combined closure: each top RPM followed by those of
+// its dependencies not already included, so that dependencies shared
+// between several tops appear only once. The options configure the Finder
+// resolving the tops of each directory, e.g. WithTransitive to include the
+// full dependency closure of every top rather than its direct dependencies.
+// The capabilities of a directory are indexed once for all of its tops.
+func ResolveAll(tops RPMs, opts ...FinderOption) (*RPMs, error) {
+	ctx := context.Background()
+	finders := map[string]*Finder{}
+
+	var all RPMs
+	for _, top := range tops {
+		dir := top.files().Dir(top.Path)
+		f, found := finders[dir]
+		if !found {
+			f = NewFinder(dir, opts...)
+			f.fsys = top.fsys
+			finders[dir] = f
+		}
+
+		deps, _, err := f.dependencies(ctx, top)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve dependencies of %s (%w)", top.Name(), err)
+		}
+
+		all = union(all, append(RPMs{top}, *deps...))
+	}
+
+	// Ensure that no RPMs have zero size, else fail
+	if err := all.zeroSize(); err != nil {
+		return nil, fmt.Errorf("closure of %d top rpms (%w)", len(tops), err)
+	}
+
+	return &all, nil
+}
+
+// union appends to a those RPMs of b whose path is not yet in a
+func union(a, b RPMs) RPMs {
+	lut := toLUT(a.Paths())
+	for _, rr := range b {
+		if _, keyExists := lut[rr.Path]; !keyExists {
+			lut[rr.Path] = struct{}{}
+			a = append(a, rr)
+		}
+	}
+
+	return a
+}
+
+// UnusedRPMs returns the RPMs of the Finder's directory that are not
+// part of the closure found for the given project and platform
+func (f *Finder) UnusedRPMs(project, platform string) (*RPMs, error) {
+	closure, err := f.Find(project, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := listRPMs(f.files(), f.basedir)
+	if err != nil {
+		return nil, err
+	}
+
+	used := toLUT(closure.Paths())
+	var unused RPMs
+	for _, rr := range all {
+		if _, keyExists := used[rr.Path]; !keyExists {
+			unused = append(unused, rr)
+		}
+	}
+
+	return &unused, nil
+}
+
+// listRPMs returns all the RPM files found directly in dir
+func listRPMs(fsys fileSystem, dir string) (RPMs, error) {
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var rpms RPMs
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".rpm") {
+			continue
+		}
+
+		rr, err := newRPM(fsys, fsys.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		rpms = append(rpms, rr)
+	}
+
+	return rpms, nil
+}
@@ -0,0 +1,155 @@
+package rpm
+
+import (
+	"bytes"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveAll(t *testing.T) {
+	dir := t.TempDir()
+	shared := writeRPM(t, dir, "shared.rpm", fixtureRPM{Name: "shared", Version: "1", Release: "1"})
+	only := writeRPM(t, dir, "only.rpm", fixtureRPM{Name: "only", Version: "1", Release: "1"})
+	topA := writeRPM(t, dir, "a.rpm", fixtureRPM{
+		Name: "a", Version: "1", Release: "1",
+		Requires: []fixtureDep{{Name: "shared.rpm"}, {Name: "only.rpm"}},
+	})
+	topB := writeRPM(t, dir, "b.rpm", fixtureRPM{
+		Name: "b", Version: "1", Release: "1",
+		Requires: []fixtureDep{{Name: "shared.rpm"}},
+	})
+
+	var tops RPMs
+	for _, path := range []string{topA, topB} {
+		r, err := New(path)
+		if err != nil {
+			t.Fatalf("New failed (%v)", err)
+		}
+		tops = append(tops, r)
+	}
+
+	all, err := ResolveAll(tops)
+	if err != nil {
+		t.Fatalf("ResolveAll failed (%v)", err)
+	}
+
+	got := all.Paths()
+	if len(got) != 4 {
+		t.Fatalf("ResolveAll should return 4 RPMs, got %v", got)
+	}
+
+	lut := toLUT(got)
+	for _, path := range []string{topA, topB, shared, only} {
+		if _, ok := lut[path]; !ok {
+			t.Errorf("ResolveAll result should contain %s, got %v", path, got)
+		}
+	}
+}
+
+func TestUnion(t *testing.T) {
+	a := *createRPMs()
+	b := RPMs{a[1], &RPM{Path: "/blip/blop3"}}
+
+	if got := union(a, b); len(got) != 3 {
+		t.Errorf("union should return 3 RPMs, got %v", got.Paths())
+	}
+}
+
+func TestUnusedRPMs(t *testing.T) {
+	dir := t.TempDir()
+	writeRPM(t, dir, "project_1.0_el9.rpm", fixtureRPM{
+		Name: "project", Version: "1.0", Release: "1",
+		Requires: []fixtureDep{{Name: "dep.rpm"}},
+	})
+	writeRPM(t, dir, "dep.rpm", fixtureRPM{Name: "dep", Version: "1", Release: "1"})
+	stray := writeRPM(t, dir, "stray.rpm", fixtureRPM{Name: "stray", Version: "1", Release: "1"})
+
+	unused, err := NewFinder(dir).UnusedRPMs("project", "el9")
+	if err != nil {
+		t.Fatalf("UnusedRPMs failed (%v)", err)
+	}
+
+	if got := unused.Paths(); len(got) != 1 || got[0] != stray {
+		t.Errorf("UnusedRPMs should return only %s, got %v", stray, got)
+	}
+}
+
+func createChainDir(t *testing.T) string {
+	// a -> b -> c, with c -> b closing a cycle and a -> c a diamond
+	dir := t.TempDir()
+	writeRPM(t, dir, "a_1.0_el9.rpm", fixtureRPM{
+		Name: "a", Version: "1", Release: "1",
+		Requires: []fixtureDep{{Name: "b"}, {Name: "c"}},
+	})
+	writeRPM(t, dir, "b.rpm", fixtureRPM{
+		Name: "b", Version: "1", Release: "1",
+		Requires: []fixtureDep{{Name: "c"}},
+	})
+	writeRPM(t, dir, "c.rpm", fixtureRPM{
+		Name: "c", Version: "1", Release: "1",
+		Requires: []fixtureDep{{Name: "b"}, {Name: "d"}},
+	})
+	writeRPM(t, dir, "d.rpm", fixtureRPM{Name: "d", Version: "1", Release: "1"})
+
+	return dir
+}
+
+func TestRPMAllDependencies(t *testing.T) {
+	dir := createChainDir(t)
+	top, _ := New(filepath.Join(dir, "a_1.0_el9.rpm"))
+
+	deps, err := top.AllDependencies()
+	if err != nil {
+		t.Fatalf("AllDependencies failed (%v)", err)
+	}
+
+	got := deps.Names()
+	if len(got) != 3 || got[0] != "b.rpm" || got[1] != "c.rpm" || got[2] != "d.rpm" {
+		t.Errorf("AllDependencies should return [b.rpm c.rpm d.rpm], got %v", got)
+	}
+}
+
+func TestFindTransitive(t *testing.T) {
+	dir := createChainDir(t)
+
+	rpms, err := NewFinder(dir).Find("a", "el9")
+	if err != nil || len(*rpms) != 3 {
+		t.Errorf("Find should return a and its 2 direct dependencies, got %v (%v)", rpms, err)
+	}
+
+	rpms, err = NewFinder(dir, WithTransitive()).Find("a", "el9")
+	if err != nil || len(*rpms) != 4 {
+		t.Errorf("Find with transitive resolution should return 4 RPMs, got %v (%v)", rpms, err)
+	}
+}
+
+func TestResolveAllWithTransitive(t *testing.T) {
+	dir := createChainDir(t)
+	var tops RPMs
+	for _, name := range []string{"a_1.0_el9.rpm", "b.rpm"} {
+		r, _ := New(filepath.Join(dir, name))
+		tops = append(tops, r)
+	}
+
+	all, err := ResolveAll(tops)
+	if err != nil || len(*all) != 3 {
+		t.Errorf("ResolveAll should return a, b and c, got %v (%v)", all, err)
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	all, err = ResolveAll(tops, WithTransitive(), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("ResolveAll failed (%v)", err)
+	}
+
+	if got := strings.Join(all.Names(), ","); got != "a_1.0_el9.rpm,b.rpm,c.rpm,d.rpm" {
+		t.Errorf("ResolveAll WithTransitive should return the full closure, got %s", got)
+	}
+
+	if n := strings.Count(buf.String(), "indexed capabilities"); n != 1 {
+		t.Errorf("ResolveAll should index the directory once, got %d times", n)
+	}
+}
@@ -0,0 +1,322 @@
+package rpm
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// SBOMFormat is a software bill of materials format written by RPMs.SBOM
+type SBOMFormat string
+
+// The supported SBOM formats, both JSON encoded
+const (
+	SPDX      SBOMFormat = "spdx"
+	CycloneDX SBOMFormat = "cyclonedx"
+)
+
+// sbomNow is the creation time recorded in SBOMs
+var sbomNow = time.Now
+
+// sbomTool is the tool recorded as the creator of SBOMs
+const sbomTool = "atlas-rpm"
+
+// sbomPackage is what an SBOM records of each RPM
+type sbomPackage struct {
+	name     string
+	evr      string
+	nevra    string
+	license  string
+	summary  string
+	sha256   string
+	purl     string
+	requires []int
+}
+
+// SBOM returns a software bill of materials of the RPMs, in the given
+// format: SPDX 2.3 or CycloneDX 1.5 JSON. Each package is recorded with
+// its NEVRA as a package URL, its License header tag, the SHA-256 checksum
+// of the RPM file, and a dependency on each of the other RPMs of the
+// collection providing one of its requires. The document is named after
+// the first RPM, i.e. the top RPM in the output of Finder.Find, and
+// identified by a digest of the checksums, so that the SBOM of the same
+// RPMs only differs by its creation time.
+func (r *RPMs) SBOM(format SBOMFormat) ([]byte, error) {
+	if format != SPDX && format != CycloneDX {
+		return nil, fmt.Errorf("unsupported SBOM format %q", format)
+	}
+
+	if len(*r) == 0 {
+		return nil, errors.New("cannot create an SBOM of no RPMs")
+	}
+
+	pkgs, err := r.sbomPackages()
+	if err != nil {
+		return nil, err
+	}
+
+	var doc any
+	if format == SPDX {
+		doc = spdxDocument(pkgs)
+	} else {
+		doc = cycloneDXDocument(pkgs)
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s SBOM (%w)", format, err)
+	}
+
+	return append(data, '\n'), nil
+}
+
+// sbomPackages reads what the SBOM records of each of the RPMs
+func (r *RPMs) sbomPackages() ([]sbomPackage, error) {
+	sums, err := r.Checksums("sha256")
+	if err != nil {
+		return nil, err
+	}
+
+	edges, err := r.requireEdges()
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs := make([]sbomPackage, len(*r))
+	for i, rr := range *r {
+		p, err := rr.header()
+		if err != nil {
+			return nil, err
+		}
+
+		qualifiers := url.Values{"arch": {p.Architecture()}}
+		if p.Epoch() != 0 {
+			qualifiers.Set("epoch", strconv.Itoa(p.Epoch()))
+		}
+
+		pkgs[i] = sbomPackage{
+			name:     p.Name(),
+			evr:      formatEVR(p.Epoch(), p.Version(), p.Release()),
+			nevra:    nevra(p),
+			license:  p.License(),
+			summary:  p.Summary(),
+			sha256:   sums[rr.Path],
+			purl:     fmt.Sprintf("pkg:rpm/%s@%s-%s?%s", url.PathEscape(p.Name()), url.PathEscape(p.Version()), url.PathEscape(p.Release()), qualifiers.Encode()),
+			requires: edges[i],
+		}
+	}
+
+	return pkgs, nil
+}
+
+// sbomSerial returns a UUID, in its textual form, derived from the
+// checksums of the packages
+func sbomSerial(pkgs []sbomPackage) string {
+	h := sha256.New()
+	for _, pkg := range pkgs {
+		fmt.Fprintln(h, pkg.nevra, pkg.sha256)
+	}
+
+	b := h.Sum(nil)[:16]
+	b[6] = b[6]&0x0f | 0x50 // version 5, name based
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// orNoAssertion returns s, or NOASSERTION if empty, as SPDX requires
+func orNoAssertion(s string) string {
+	if s == "" {
+		return "NOASSERTION"
+	}
+	return s
+}
+
+// ---------------------------------------------------------------------
+// SPDX
+
+type spdxDoc struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	Summary          string            `json:"summary,omitempty"`
+	Checksums        []spdxChecksum    `json:"checksums"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// spdxID returns the SPDX identifier of the i-th package
+func spdxID(i int) string {
+	return fmt.Sprintf("SPDXRef-Package-%d", i)
+}
+
+// spdxDocument describes the packages in an SPDX document, which
+// DESCRIBES the first one. The License tag of RPMs is recorded as the
+// declared license as is, SPDX expressions only being used there by
+// recent distributions.
+func spdxDocument(pkgs []sbomPackage) *spdxDoc {
+	doc := &spdxDoc{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              pkgs[0].nevra,
+		DocumentNamespace: fmt.Sprintf("https://spdx.org/spdxdocs/%s-%s", pkgs[0].name, sbomSerial(pkgs)),
+		CreationInfo: spdxCreationInfo{
+			Created:  sbomNow().UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: " + sbomTool},
+		},
+		Relationships: []spdxRelationship{{"SPDXRef-DOCUMENT", "DESCRIBES", spdxID(0)}},
+	}
+
+	for i, pkg := range pkgs {
+		doc.Packages = append(doc.Packages, spdxPackage{
+			Name:             pkg.name,
+			SPDXID:           spdxID(i),
+			VersionInfo:      pkg.evr,
+			DownloadLocation: "NOASSERTION",
+			LicenseConcluded: "NOASSERTION",
+			LicenseDeclared:  orNoAssertion(pkg.license),
+			CopyrightText:    "NOASSERTION",
+			Summary:          pkg.summary,
+			Checksums:        []spdxChecksum{{"SHA256", pkg.sha256}},
+			ExternalRefs:     []spdxExternalRef{{"PACKAGE-MANAGER", "purl", pkg.purl}},
+		})
+
+		for _, j := range pkg.requires {
+			doc.Relationships = append(doc.Relationships, spdxRelationship{spdxID(i), "DEPENDS_ON", spdxID(j)})
+		}
+	}
+
+	return doc
+}
+
+// ---------------------------------------------------------------------
+// CycloneDX
+
+type cdxBOM struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []cdxComponent  `json:"components"`
+	Dependencies []cdxDependency `json:"dependencies"`
+}
+
+type cdxMetadata struct {
+	Timestamp string   `json:"timestamp"`
+	Tools     cdxTools `json:"tools"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	Type        string       `json:"type"`
+	BOMRef      string       `json:"bom-ref,omitempty"`
+	Name        string       `json:"name"`
+	Version     string       `json:"version,omitempty"`
+	Description string       `json:"description,omitempty"`
+	PURL        string       `json:"purl,omitempty"`
+	Hashes      []cdxHash    `json:"hashes,omitempty"`
+	Licenses    []cdxLicense `json:"licenses,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxLicense struct {
+	License cdxLicenseName `json:"license"`
+}
+
+type cdxLicenseName struct {
+	Name string `json:"name"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// cycloneDXDocument describes the packages in a CycloneDX BOM. The License
+// tag of RPMs is recorded as a license name, as older distributions do not
+// use SPDX expressions there.
+func cycloneDXDocument(pkgs []sbomPackage) *cdxBOM {
+	bom := &cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + sbomSerial(pkgs),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: sbomNow().UTC().Format(time.RFC3339),
+			Tools:     cdxTools{Components: []cdxComponent{{Type: "application", Name: sbomTool}}},
+		},
+	}
+
+	for _, pkg := range pkgs {
+		component := cdxComponent{
+			Type:        "library",
+			BOMRef:      pkg.purl,
+			Name:        pkg.name,
+			Version:     pkg.evr,
+			Description: pkg.summary,
+			PURL:        pkg.purl,
+			Hashes:      []cdxHash{{"SHA-256", pkg.sha256}},
+		}
+		if pkg.license != "" {
+			component.Licenses = []cdxLicense{{cdxLicenseName{pkg.license}}}
+		}
+		bom.Components = append(bom.Components, component)
+
+		dependency := cdxDependency{Ref: pkg.purl, DependsOn: []string{}}
+		for _, j := range pkg.requires {
+			dependency.DependsOn = append(dependency.DependsOn, pkgs[j].purl)
+		}
+		bom.Dependencies = append(bom.Dependencies, dependency)
+	}
+
+	return bom
+}
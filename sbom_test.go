@@ -0,0 +1,99 @@
+package rpm
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func sbomRPMs(t *testing.T) *RPMs {
+	sbomNow = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { sbomNow = time.Now })
+
+	dir := t.TempDir()
+	writeRPM(t, dir, "Athena.rpm", fixtureRPM{
+		Name: "Athena", Version: "22.0.1", Release: "1", Epoch: 2,
+		License: "Apache-2.0", Summary: "ATLAS offline software",
+		Requires: []fixtureDep{{Name: "libgcc"}},
+	})
+	writeRPM(t, dir, "libgcc.rpm", fixtureRPM{Name: "libgcc", Version: "13.1", Release: "2"})
+
+	return &RPMs{{Path: filepath.Join(dir, "Athena.rpm")}, {Path: filepath.Join(dir, "libgcc.rpm")}}
+}
+
+func TestSBOMSPDX(t *testing.T) {
+	rpms := sbomRPMs(t)
+	data, err := rpms.SBOM(SPDX)
+	if err != nil {
+		t.Fatalf("SBOM failed (%v)", err)
+	}
+
+	var doc spdxDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("the SBOM should be JSON (%v)", err)
+	}
+
+	if doc.SPDXVersion != "SPDX-2.3" || doc.Name != "Athena-2:22.0.1-1.x86_64" || doc.CreationInfo.Created != "2024-05-01T12:00:00Z" {
+		t.Errorf("unexpected SPDX document %+v", doc)
+	}
+
+	athena := doc.Packages[0]
+	sum, _ := (*rpms)[0].Checksum("sha256")
+	if athena.VersionInfo != "2:22.0.1-1" || athena.LicenseDeclared != "Apache-2.0" || athena.Checksums[0].ChecksumValue != sum {
+		t.Errorf("unexpected SPDX package %+v", athena)
+	}
+	if purl := athena.ExternalRefs[0].ReferenceLocator; purl != "pkg:rpm/Athena@22.0.1-1?arch=x86_64&epoch=2" {
+		t.Errorf("unexpected package URL %q", purl)
+	}
+	if license := doc.Packages[1].LicenseDeclared; license != "NOASSERTION" {
+		t.Errorf("a package without license should have NOASSERTION, got %q", license)
+	}
+
+	want := []spdxRelationship{
+		{"SPDXRef-DOCUMENT", "DESCRIBES", "SPDXRef-Package-0"},
+		{"SPDXRef-Package-0", "DEPENDS_ON", "SPDXRef-Package-1"},
+	}
+	if len(doc.Relationships) != len(want) || doc.Relationships[0] != want[0] || doc.Relationships[1] != want[1] {
+		t.Errorf("unexpected relationships %+v", doc.Relationships)
+	}
+
+	again, _ := rpms.SBOM(SPDX)
+	if string(again) != string(data) {
+		t.Errorf("the SBOM of the same RPMs should be the same")
+	}
+}
+
+func TestSBOMCycloneDX(t *testing.T) {
+	data, err := sbomRPMs(t).SBOM(CycloneDX)
+	if err != nil {
+		t.Fatalf("SBOM failed (%v)", err)
+	}
+
+	var bom cdxBOM
+	if err := json.Unmarshal(data, &bom); err != nil {
+		t.Fatalf("the SBOM should be JSON (%v)", err)
+	}
+
+	if bom.BOMFormat != "CycloneDX" || len(bom.SerialNumber) != len("urn:uuid:")+36 || len(bom.Components) != 2 {
+		t.Errorf("unexpected CycloneDX BOM %+v", bom)
+	}
+
+	athena := bom.Components[0]
+	if athena.Version != "2:22.0.1-1" || athena.Licenses[0].License.Name != "Apache-2.0" || athena.Hashes[0].Alg != "SHA-256" {
+		t.Errorf("unexpected component %+v", athena)
+	}
+
+	if deps := bom.Dependencies; len(deps) != 2 || len(deps[0].DependsOn) != 1 || deps[0].DependsOn[0] != bom.Components[1].PURL || len(deps[1].DependsOn) != 0 {
+		t.Errorf("unexpected dependencies %+v", deps)
+	}
+}
+
+func TestSBOMErrors(t *testing.T) {
+	if _, err := (&RPMs{}).SBOM(SPDX); err == nil {
+		t.Errorf("SBOM of no RPMs should fail")
+	}
+	if _, err := sbomRPMs(t).SBOM("swid"); err == nil {
+		t.Errorf("SBOM should fail on an unsupported format")
+	}
+}
@@ -0,0 +1,56 @@
+package rpm
+
+import (
+	"fmt"
+)
+
+// Selector picks the top RPM to install among the candidates matching
+// the project and platform, which are sorted by decreasing version. Only
+// the Path of the candidates is set, their header being read on demand.
+type Selector func(candidates RPMs) (*RPM, error)
+
+// WithSelector sets how the Finder picks the top RPM when several match,
+// SelectNewest if not set
+func WithSelector(s Selector) FinderOption {
+	return func(f *Finder) {
+		f.selector = s
+	}
+}
+
+// SelectNewest picks the candidate with the highest version
+func SelectNewest(candidates RPMs) (*RPM, error) {
+	return candidates[0], nil
+}
+
+// SelectOldest picks the candidate with the lowest version
+func SelectOldest(candidates RPMs) (*RPM, error) {
+	return candidates[len(candidates)-1], nil
+}
+
+// SelectVersion returns a Selector that picks the candidate of the given
+// [epoch:]version[-release]. The release, if not given, is not compared.
+func SelectVersion(version string) Selector {
+	want := parseEVR(version)
+	return func(candidates RPMs) (*RPM, error) {
+		for _, r := range candidates {
+			m, err := r.Metadata()
+			if err != nil {
+				continue
+			}
+
+			got := evr{epoch: m.Epoch, version: m.Version, release: m.Release}
+			if compareEVR(want, got) == 0 {
+				return r, nil
+			}
+		}
+
+		return nil, fmt.Errorf("%w of version %s among %d candidates", ErrNoTopRPM, version, len(candidates))
+	}
+}
+
+// CompareEVR compares two [epoch:]version[-release] strings with the rules
+// of rpmvercmp, returning -1, 0 or 1 if a is older, equal to or newer than
+// b. As with rpm, the release is only compared if both have one.
+func CompareEVR(a, b string) int {
+	return compareEVR(parseEVR(a), parseEVR(b))
+}
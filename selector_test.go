@@ -0,0 +1,63 @@
+package rpm
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompareEVR(t *testing.T) {
+	cases := []struct {
+		a, b   string
+		expect int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0", "1.0.1", -1},
+		{"1.10", "1.9", 1},
+		{"1:1.0", "2.0", 1},
+		{"1.0-2", "1.0-10", -1},
+		{"1.0-2", "1.0", 0},
+		{"1.0a", "1.0", 1},
+		{"1.0~rc1", "1.0", -1},
+	}
+
+	for _, c := range cases {
+		if got := CompareEVR(c.a, c.b); got != c.expect {
+			t.Errorf("CompareEVR(%s, %s) should return %d, got %d", c.a, c.b, c.expect, got)
+		}
+	}
+}
+
+func TestFinderWithSelector(t *testing.T) {
+	dir := t.TempDir()
+	for _, v := range []string{"22.0.1", "22.0.11", "22.0.2"} {
+		writeRPM(t, dir, "Athena_"+v+"_x86_64.rpm", fixtureRPM{Name: "Athena", Version: v, Release: "1"})
+	}
+
+	selections := []struct {
+		selector Selector
+		expect   string
+	}{
+		{SelectNewest, "Athena_22.0.11_x86_64.rpm"},
+		{SelectOldest, "Athena_22.0.1_x86_64.rpm"},
+		{SelectVersion("22.0.2"), "Athena_22.0.2_x86_64.rpm"},
+		{SelectVersion("22.0.2-1"), "Athena_22.0.2_x86_64.rpm"},
+	}
+
+	for _, s := range selections {
+		got, err := NewFinder(dir, WithSelector(s.selector)).TopRPM("Athena", "x86_64")
+		if err != nil || filepath.Base(got) != s.expect {
+			t.Errorf("TopRPM should select %s, got %s (%v)", s.expect, got, err)
+		}
+	}
+
+	if _, err := NewFinder(dir, WithSelector(SelectVersion("23.0.0"))).TopRPM("Athena", "x86_64"); err == nil {
+		t.Errorf("TopRPM should fail without a candidate of the requested version, got nil")
+	}
+
+	errCustom := errors.New("none suits")
+	custom := func(RPMs) (*RPM, error) { return nil, errCustom }
+	if _, err := NewFinder(dir, WithSelector(custom)).Find("Athena", "x86_64"); !errors.Is(err, errCustom) {
+		t.Errorf("Find should return the selector error, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package rpm
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"time"
+)
+
+// ServeClosure resolves the RPMs for the given project and platform and
+// streams them, file by file, as a tar archive in the HTTP response,
+// followed by their repodata below repodata/, as CreateRepo writes it, so
+// that the extracted archive is a repo for yum, dnf or a RemoteFinder. If
+// resolution or reading the repodata fails, nothing is written and the
+// caller remains in charge of the response. Once streaming has started,
+// errors (including ctx being done) can only abort the transfer.
+func (f *Finder) ServeClosure(ctx context.Context, w http.ResponseWriter, project, platform string) error {
+	rpms, err := f.FindContext(ctx, project, platform)
+	if err != nil {
+		return err
+	}
+
+	repodata, err := closureRepodata(ctx, rpms, f.concurrency)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.Header().Set(
+		"Content-Disposition",
+		fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("%s_%s.tar", project, platform)),
+	)
+
+	tw := tar.NewWriter(w)
+	for _, rr := range *rpms {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("closure streaming interrupted (%w)", err)
+		}
+
+		if err := writeTarFile(tw, rr); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for _, file := range repodata {
+		hdr := &tar.Header{
+			Name:    path.Join(path.Dir(repomdPath), file.name),
+			Mode:    0644,
+			Size:    int64(len(file.data)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			return fmt.Errorf("failed to stream %s (%w)", hdr.Name, err)
+		}
+	}
+
+	return tw.Close()
+}
+
+// closureRepodata renders the repodata of the RPMs, each located by its
+// name at the top of the repo
+func closureRepodata(ctx context.Context, rpms *RPMs, concurrency int) ([]repodataFile, error) {
+	pkgs := make([]*mdPackage, len(*rpms))
+	err := forEach(ctx, len(pkgs), concurrency, func(i int) error {
+		pkg, err := newMDPackage((*rpms)[i], (*rpms)[i].Name())
+		pkgs[i] = pkg
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return repodataFiles(pkgs)
+}
+
+// writeTarFile streams the RPM file into the archive under its name
+func writeTarFile(tw *tar.Writer, rr *RPM) error {
+	file, err := rr.open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fi, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	hdr := &tar.Header{
+		Name:    rr.Name(),
+		Mode:    0644,
+		Size:    fi.Size(),
+		ModTime: fi.ModTime(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(tw, file); err != nil {
+		return fmt.Errorf("failed to stream %s (%w)", rr.Path, err)
+	}
+
+	return nil
+}
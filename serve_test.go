@@ -0,0 +1,84 @@
+package rpm
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServeClosure(t *testing.T) {
+	dir := t.TempDir()
+	writeRPM(t, dir, "project_1.0_el9.rpm", fixtureRPM{
+		Name: "project", Version: "1.0", Release: "1",
+		Requires: []fixtureDep{{Name: "dep"}},
+	})
+	writeRPM(t, dir, "dep.rpm", fixtureRPM{Name: "dep", Version: "1", Release: "1"})
+
+	rec := httptest.NewRecorder()
+	if err := NewFinder(dir).ServeClosure(context.Background(), rec, "project", "el9"); err != nil {
+		t.Fatalf("ServeClosure failed (%v)", err)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-tar" {
+		t.Errorf("ServeClosure should set a tar Content-Type, got %s", ct)
+	}
+
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="project_el9.tar"` {
+		t.Errorf("ServeClosure set a bad Content-Disposition %s", cd)
+	}
+
+	// Extract the archive, to serve it as a repo
+	var names []string
+	repo := t.TempDir()
+	os.Mkdir(filepath.Join(repo, "repodata"), 0755)
+	tr := tar.NewReader(rec.Body)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ServeClosure wrote a bad tar archive (%v)", err)
+		}
+		names = append(names, hdr.Name)
+
+		content, _ := io.ReadAll(tr)
+		os.WriteFile(filepath.Join(repo, filepath.FromSlash(hdr.Name)), content, 0644)
+	}
+
+	if len(names) != 6 || names[0] != "project_1.0_el9.rpm" || names[1] != "dep.rpm" || names[5] != "repodata/repomd.xml" {
+		t.Errorf("ServeClosure should stream the top RPM, dep.rpm and their repodata, got %v", names)
+	}
+
+	srv := httptest.NewServer(http.FileServer(http.Dir(repo)))
+	t.Cleanup(srv.Close)
+
+	rpms, err := NewRemoteFinder(Repo{URL: srv.URL}, t.TempDir()).Find("project", "el9")
+	if err != nil || strings.Join(rpms.Names(), ",") != "project_1.0_el9.rpm,dep.rpm" {
+		t.Errorf("the archive should be a repo holding the closure, got %v (%v)", rpms, err)
+	}
+}
+
+func TestServeClosureErrors(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := NewFinder(t.TempDir()).ServeClosure(context.Background(), rec, "project", "el9"); err == nil {
+		t.Errorf("ServeClosure should fail without a top RPM, got nil")
+	}
+
+	if rec.Body.Len() != 0 || rec.Header().Get("Content-Type") != "" {
+		t.Errorf("ServeClosure should not write a response when resolution fails")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewFinder(t.TempDir()).FindContext(ctx, "project", "el9"); !errors.Is(err, context.Canceled) {
+		t.Errorf("FindContext should return context.Canceled, got %v", err)
+	}
+}
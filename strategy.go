@@ -0,0 +1,243 @@
+package rpm
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/cavaliergopher/rpm"
+)
+
+// MatchStrategy is the way in which a Finder matches
+// the dependencies of an RPM to the files of its directory
+type MatchStrategy int
+
+const (
+	// CapabilityThenFilename matches each dependency to the RPMs that
+	// provide it, falling back to filename matching for dependencies that
+	// no readable RPM provides. This is the default.
+	CapabilityThenFilename MatchStrategy = iota
+
+	// CapabilityOnly matches dependencies to the RPMs that provide them
+	CapabilityOnly
+
+	// FilenameOnly matches dependencies to RPM filenames, see FilenameMatch
+	FilenameOnly
+)
+
+// WithMatchStrategy sets how the Finder matches dependencies to RPMs
+func WithMatchStrategy(strategy MatchStrategy) FinderOption {
+	return func(f *Finder) {
+		f.matchBy = strategy
+	}
+}
+
+// resolver matches the requires of RPMs to the files of a directory
+type resolver struct {
+	fsys     fileSystem
+	dir      string
+	index    *capIndex
+	strategy MatchStrategy
+	match    FilenameMatch
+
+	// concurrency is the number of dependency files stat'ed in parallel
+	concurrency int
+
+	// logger, if set, receives how each dependency is resolved
+	logger *slog.Logger
+
+	// weak are the weak dependencies resolved on top of the requires
+	weak WeakDeps
+}
+
+// resolver returns the resolver that follows the Finder's match strategy
+func (f *Finder) resolver(ctx context.Context) (*resolver, error) {
+	rs := &resolver{
+		fsys:        f.files(),
+		dir:         f.basedir,
+		strategy:    f.matchBy,
+		match:       f.match,
+		concurrency: f.concurrency,
+		logger:      f.logger,
+		weak:        f.weak,
+	}
+	if f.matchBy != FilenameOnly {
+		idx, err := f.capabilities(ctx)
+		if err != nil {
+			return nil, err
+		}
+		rs.index = idx
+	}
+
+	return rs, nil
+}
+
+// dependencies finds the dependencies of r in the Finder's directory,
+// following the Finder's match strategy. The names of the dependencies
+// that could not be matched are returned alongside.
+func (f *Finder) dependencies(ctx context.Context, r *RPM) (*RPMs, []string, error) {
+	rs, err := f.resolver(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if f.transitive {
+		return rs.closure(ctx, r)
+	}
+
+	return rs.resolve(ctx, r)
+}
+
+// resolve finds the dependencies of r, returning alongside
+// the names of the dependencies that could not be matched
+func (rs *resolver) resolve(ctx context.Context, r *RPM) (*RPMs, []string, error) {
+	names, reqs, err := requiresByName(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files, unresolved, err := rs.lookup(r, names, reqs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if rs.weak != NoWeakDeps {
+		names, deps, err := weakByName(r, rs.weak)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Unmatched weak dependencies are not missing
+		weak, _, err := rs.lookup(r, names, deps)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, weak...)
+	}
+
+	deps, err := statDeps(ctx, rs.fsys, rs.dir, unique(files), rs.concurrency)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return deps, unresolved, nil
+}
+
+// lookup matches the named dependencies of r to the files of the
+// directory, returning the files and the names that could not be matched
+func (rs *resolver) lookup(r *RPM, names []string, reqs map[string][]rpm.Dependency) ([]string, []string, error) {
+	var files, unresolved []string
+	for _, name := range names {
+		if rs.strategy == FilenameOnly {
+			unresolved = append(unresolved, name)
+			continue
+		}
+
+		provider, ok := rs.index.provider(name, reqs[name], r.Name())
+		if !ok {
+			unresolved = append(unresolved, name)
+			continue
+		}
+		orDiscard(rs.logger).Debug("resolved dependency by capability", "rpm", r.Name(), "name", name, "file", provider)
+		files = append(files, provider)
+	}
+
+	if rs.strategy != CapabilityOnly && len(unresolved) > 0 {
+		found, err := listDir(rs.fsys, rs.dir, unresolved, rs.match)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, file := range found {
+			orDiscard(rs.logger).Debug("resolved dependency by filename", "rpm", r.Name(), "file", file)
+		}
+		files = append(files, found...)
+		unresolved = unmatched(unresolved, found, rs.match)
+	}
+
+	return files, unresolved, nil
+}
+
+// closure walks the dependency graph of r breadth first and returns all
+// its direct and indirect dependencies, each once, as well as the names of
+// all the dependencies that could not be matched along the way
+func (rs *resolver) closure(ctx context.Context, r *RPM) (*RPMs, []string, error) {
+	seen := map[string]struct{}{r.Path: {}}
+	queue := []*RPM{r}
+
+	var all RPMs
+	var missing []string
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+
+		deps, unresolved, err := rs.resolve(ctx, next)
+		if err != nil {
+			return nil, nil, err
+		}
+		missing = append(missing, unresolved...)
+
+		for _, dep := range *deps {
+			if _, keyExists := seen[dep.Path]; keyExists {
+				continue
+			}
+			seen[dep.Path] = struct{}{}
+			all = append(all, dep)
+			queue = append(queue, dep)
+		}
+	}
+
+	return &all, unique(missing), nil
+}
+
+// provider returns the first file, other than self, that provides the
+// capability in a version satisfying all the given requirements on it
+func (idx *capIndex) provider(capability string, reqs []rpm.Dependency, self string) (string, bool) {
+	for _, p := range idx.Provides[capability] {
+		if p.File != self && p.satisfies(capability, reqs) {
+			return p.File, true
+		}
+	}
+
+	return "", false
+}
+
+// requiresByName returns the unique names of the Requires of r, each
+// alongside all the Requires entries, and so version constraints, on it
+func requiresByName(r *RPM) ([]string, map[string][]rpm.Dependency, error) {
+	p, err := r.header()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names, reqs := byName(p.Requires())
+	return names, reqs, nil
+}
+
+// byName returns the unique names of the dependencies, in order of first
+// occurrence, each along with all the dependencies on it
+func byName(deps []rpm.Dependency) ([]string, map[string][]rpm.Dependency) {
+	var names []string
+	byName := map[string][]rpm.Dependency{}
+	for _, dep := range deps {
+		if _, keyExists := byName[dep.Name()]; !keyExists {
+			names = append(names, dep.Name())
+		}
+		byName[dep.Name()] = append(byName[dep.Name()], dep)
+	}
+
+	return names, byName
+}
+
+// unique drops repeated items, preserving the order of first occurrence
+func unique(items []string) []string {
+	seen := map[string]struct{}{}
+	var kept []string
+	for _, item := range items {
+		if _, keyExists := seen[item]; !keyExists {
+			seen[item] = struct{}{}
+			kept = append(kept, item)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,106 @@
+package rpm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cavaliergopher/rpm"
+)
+
+func createStrategyDir(t *testing.T) string {
+	dir := t.TempDir()
+	writeRPM(t, dir, "project_1.0_el9.rpm", fixtureRPM{
+		Name: "project", Version: "1.0", Release: "1",
+		Requires: []fixtureDep{{Name: "libfoo.so.1"}, {Name: "legacy.rpm"}},
+		Provides: []fixtureDep{{Name: "libfoo.so.1"}},
+	})
+	writeRPM(t, dir, "libfoo-1.0-1.x86_64.rpm", fixtureRPM{
+		Name: "libfoo", Version: "1.0", Release: "1",
+		Provides: []fixtureDep{{Name: "libfoo.so.1"}},
+	})
+
+	// An RPM with an unreadable header can only be matched by filename
+	os.WriteFile(filepath.Join(dir, "legacy.rpm"), []byte("legacy"), 0644)
+	return dir
+}
+
+func TestFinderMatchStrategies(t *testing.T) {
+	dir := createStrategyDir(t)
+
+	tests := []struct {
+		strategy MatchStrategy
+		expect   []string
+	}{
+		{CapabilityThenFilename, []string{"project_1.0_el9.rpm", "libfoo-1.0-1.x86_64.rpm", "legacy.rpm"}},
+		{CapabilityOnly, []string{"project_1.0_el9.rpm", "libfoo-1.0-1.x86_64.rpm"}},
+		{FilenameOnly, []string{"project_1.0_el9.rpm", "legacy.rpm"}},
+	}
+
+	for _, tt := range tests {
+		rpms, err := NewFinder(dir, WithMatchStrategy(tt.strategy)).Find("project", "el9")
+		if err != nil {
+			t.Fatalf("Find with strategy %d failed (%v)", tt.strategy, err)
+		}
+
+		got := rpms.Names()
+		if len(got) != len(tt.expect) {
+			t.Errorf("Find with strategy %d should return %v, got %v", tt.strategy, tt.expect, got)
+			continue
+		}
+
+		for i := range got {
+			if got[i] != tt.expect[i] {
+				t.Errorf("Find with strategy %d should return %v, got %v", tt.strategy, tt.expect, got)
+				break
+			}
+		}
+	}
+}
+
+func TestUnique(t *testing.T) {
+	got := unique([]string{"b", "a", "b", "c", "a"})
+	if len(got) != 3 || got[0] != "b" || got[1] != "a" || got[2] != "c" {
+		t.Errorf("unique should return [b a c], got %v", got)
+	}
+}
+
+func TestFinderVersionConstraints(t *testing.T) {
+	dir := t.TempDir()
+	writeRPM(t, dir, "project_1.0_el9.rpm", fixtureRPM{
+		Name: "project", Version: "1.0", Release: "1",
+		Requires: []fixtureDep{
+			{Name: "libfoo", Flags: rpm.DepFlagGreaterOrEqual, Version: "2.0"},
+			{Name: "libfoo", Flags: rpm.DepFlagLesser, Version: "3.0"},
+			{Name: "python(abi)", Flags: rpm.DepFlagEqual, Version: "3.9"},
+		},
+	})
+	writeRPM(t, dir, "libfoo-1.5-1.x86_64.rpm", fixtureRPM{Name: "libfoo", Version: "1.5", Release: "1"})
+	writeRPM(t, dir, "libfoo-2.1-1.x86_64.rpm", fixtureRPM{Name: "libfoo", Version: "2.1", Release: "1"})
+	writeRPM(t, dir, "libfoo-3.0-1.x86_64.rpm", fixtureRPM{Name: "libfoo", Version: "3.0", Release: "1"})
+	writeRPM(t, dir, "python3.6-3.6.8-1.x86_64.rpm", fixtureRPM{
+		Name: "python3.6", Version: "3.6.8", Release: "1",
+		Provides: []fixtureDep{{Name: "python(abi)", Flags: rpm.DepFlagEqual, Version: "3.6"}},
+	})
+	writeRPM(t, dir, "python3.9-3.9.2-1.x86_64.rpm", fixtureRPM{
+		Name: "python3.9", Version: "3.9.2", Release: "1",
+		Provides: []fixtureDep{{Name: "python(abi)", Flags: rpm.DepFlagEqual, Version: "3.9"}},
+	})
+
+	rpms, err := NewFinder(dir, WithMatchStrategy(CapabilityOnly)).Find("project", "el9")
+	if err != nil {
+		t.Fatalf("Find failed (%v)", err)
+	}
+
+	got := strings.Join(rpms.Names(), ",")
+	if got != "project_1.0_el9.rpm,libfoo-2.1-1.x86_64.rpm,python3.9-3.9.2-1.x86_64.rpm" {
+		t.Errorf("Find should pick the providers within the version constraints, got %s", got)
+	}
+
+	os.Remove(filepath.Join(dir, "libfoo-2.1-1.x86_64.rpm"))
+	_, err = NewFinder(dir, WithMatchStrategy(CapabilityOnly), WithStrict()).Find("project", "el9")
+	if err == nil || !strings.Contains(err.Error(), "libfoo") {
+		t.Errorf("Find should report libfoo as missing without a provider in range, got %v", err)
+	}
+}
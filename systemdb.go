@@ -0,0 +1,213 @@
+package rpm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// systemDBFormat is the rpm query format of the packages of a SystemDB.
+// The epoch is spelt out for rpm versions without %{EPOCHNUM}.
+const systemDBFormat = `%{NAME}\t%|EPOCH?{%{EPOCH}}:{0}|\t%{VERSION}\t%{RELEASE}\t%{ARCH}\n`
+
+// SystemDB is the set of packages installed on a host, as listed by its rpm
+// database, against which the RPMs to install can be compared
+type SystemDB struct {
+	// Root is the root directory of the host, empty for /
+	Root string
+
+	// byName indexes the installed packages by name and arch
+	byName map[string][]Metadata
+}
+
+// SkewedPackage is an RPM installed on the host at another version
+type SkewedPackage struct {
+	RPM *RPM
+
+	// Installed are the versions installed, as [epoch:]version-release
+	Installed []string
+}
+
+// OpenSystemDB reads the packages installed below root (/ if empty) with
+// `rpm -qa`, see RPMCommand. The rpm database itself is not read, its
+// format (sqlite, ndb or Berkeley DB) depending on the host rpm version.
+func OpenSystemDB(ctx context.Context, root string) (*SystemDB, error) {
+	if RPMCommand == "" {
+		return nil, ErrExecDisabled
+	}
+
+	args := []string{"-qa", "--queryformat", systemDBFormat}
+	if root != "" {
+		args = append(args, "--root", root)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, RPMCommand, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s -qa failed (%w):\n%s", RPMCommand, err, stderr.Bytes())
+	}
+
+	db := &SystemDB{Root: root, byName: map[string][]Metadata{}}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if scanner.Text() == "" {
+			continue
+		}
+
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("unexpected %s -qa output %q", RPMCommand, scanner.Text())
+		}
+
+		epoch, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected epoch in %s -qa output %q (%w)", RPMCommand, scanner.Text(), err)
+		}
+
+		m := Metadata{Name: fields[0], Epoch: epoch, Version: fields[2], Release: fields[3], Arch: fields[4]}
+		key := systemKey(m.Name, m.Arch)
+		db.byName[key] = append(db.byName[key], m)
+	}
+
+	return db, scanner.Err()
+}
+
+// Len returns the number of packages installed
+func (db *SystemDB) Len() int {
+	n := 0
+	for _, installed := range db.byName {
+		n += len(installed)
+	}
+
+	return n
+}
+
+// Installed returns the packages installed on the host, sorted by name,
+// arch then version
+func (db *SystemDB) Installed() []Metadata {
+	var all []Metadata
+	for _, installed := range db.byName {
+		all = append(all, installed...)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		a, b := all[i], all[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Arch != b.Arch {
+			return a.Arch < b.Arch
+		}
+		return compareEVR(evr{a.Epoch, a.Version, a.Release}, evr{b.Epoch, b.Version, b.Release}) < 0
+	})
+
+	return all
+}
+
+// AlreadyInstalled returns the RPMs installed on the host at the same
+// epoch, version and release, which need not be installed. The arch must
+// be the same, except that noarch packages match any arch both ways.
+// RPMs whose header cannot be read are reported in the returned error,
+// as by MissingOnSystem and Skewed.
+func (db *SystemDB) AlreadyInstalled(rpms *RPMs) (RPMs, error) {
+	var installed RPMs
+	err := db.compare(rpms, func(r *RPM, m *Metadata, versions []Metadata) {
+		if sameVersion(m, versions) {
+			installed = append(installed, r)
+		}
+	})
+
+	return installed, err
+}
+
+// MissingOnSystem returns the RPMs of which no version is installed on
+// the host for a compatible arch, see AlreadyInstalled
+func (db *SystemDB) MissingOnSystem(rpms *RPMs) (RPMs, error) {
+	var missing RPMs
+	err := db.compare(rpms, func(r *RPM, m *Metadata, versions []Metadata) {
+		if len(versions) == 0 {
+			missing = append(missing, r)
+		}
+	})
+
+	return missing, err
+}
+
+// Skewed returns the RPMs installed on the host for a compatible arch,
+// but only at versions other than that of the RPM, see AlreadyInstalled
+func (db *SystemDB) Skewed(rpms *RPMs) ([]SkewedPackage, error) {
+	var skewed []SkewedPackage
+	err := db.compare(rpms, func(r *RPM, m *Metadata, versions []Metadata) {
+		if len(versions) == 0 || sameVersion(m, versions) {
+			return
+		}
+
+		s := SkewedPackage{RPM: r}
+		for _, v := range versions {
+			s.Installed = append(s.Installed, formatEVR(v.Epoch, v.Version, v.Release))
+		}
+		sort.Strings(s.Installed)
+		skewed = append(skewed, s)
+	})
+
+	return skewed, err
+}
+
+// compare calls fn, in order, with each of the RPMs, its metadata and
+// the versions installed of the same name and a compatible arch
+func (db *SystemDB) compare(rpms *RPMs, fn func(*RPM, *Metadata, []Metadata)) error {
+	var errs []error
+	for _, r := range *rpms {
+		m, err := r.Metadata()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		fn(r, m, db.versions(m.Name, m.Arch))
+	}
+
+	return errors.Join(errs...)
+}
+
+// versions returns the packages installed of the given name and arch,
+// or of noarch, or of any arch if arch is noarch
+func (db *SystemDB) versions(name, arch string) []Metadata {
+	if arch != "noarch" {
+		return append(append([]Metadata(nil), db.byName[systemKey(name, arch)]...), db.byName[systemKey(name, "noarch")]...)
+	}
+
+	var versions []Metadata
+	for _, installed := range db.byName {
+		if len(installed) > 0 && installed[0].Name == name {
+			versions = append(versions, installed...)
+		}
+	}
+
+	return versions
+}
+
+// systemKey is the key of the packages of the given name and arch
+func systemKey(name, arch string) string {
+	return name + "." + arch
+}
+
+// sameVersion indicates if one of the versions is that of m
+func sameVersion(m *Metadata, versions []Metadata) bool {
+	want := evr{m.Epoch, m.Version, m.Release}
+	for _, v := range versions {
+		if compareEVR(want, evr{v.Epoch, v.Version, v.Release}) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,79 @@
+package rpm
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const systemDBOutput = `bash\t0\t5.1.8\t6.el9\tx86_64
+libgcc\t0\t11.4.1\t2.el9\tx86_64
+python3-six\t0\t1.15.0\t9.el9\tnoarch
+tzdata\t0\t2024a\t1.el9\tnoarch
+`
+
+func systemDBRPMs(t *testing.T) *RPMs {
+	dir := t.TempDir()
+	return &RPMs{
+		{Path: writeRPM(t, dir, "bash.rpm", fixtureRPM{Name: "bash", Version: "5.1.8", Release: "6.el9"})},
+		{Path: writeRPM(t, dir, "libgcc.rpm", fixtureRPM{Name: "libgcc", Version: "13.1.0", Release: "1"})},
+		{Path: writeRPM(t, dir, "Athena.rpm", fixtureRPM{Name: "Athena", Version: "22.0.1", Release: "1"})},
+		{Path: writeRPM(t, dir, "tzdata.rpm", fixtureRPM{Name: "tzdata", Version: "2024a", Release: "1.el9", Arch: "noarch"})},
+		{Path: writeRPM(t, dir, "six.rpm", fixtureRPM{Name: "python3-six", Version: "1.15.0", Release: "9.el9"})},
+		{Path: writeRPM(t, dir, "bash-i686.rpm", fixtureRPM{Name: "bash", Version: "5.1.8", Release: "6.el9", Arch: "i686"})},
+	}
+}
+
+func TestOpenSystemDBDisabled(t *testing.T) {
+	if _, err := OpenSystemDB(context.Background(), ""); !errors.Is(err, ErrExecDisabled) {
+		t.Errorf("OpenSystemDB should return ErrExecDisabled, got %v", err)
+	}
+}
+
+func TestSystemDB(t *testing.T) {
+	fakeRPMCommand(t, `[ "$1 $2 $4 $5" = "-qa --queryformat --root /host" ] || exit 2; printf '`+systemDBOutput+`'`)
+
+	db, err := OpenSystemDB(context.Background(), "/host")
+	if err != nil {
+		t.Fatalf("OpenSystemDB failed (%v)", err)
+	}
+
+	if installed := db.Installed(); db.Len() != 4 || installed[0].Name != "bash" || installed[3].Arch != "noarch" {
+		t.Errorf("OpenSystemDB should read the installed packages, got %v", installed)
+	}
+
+	rpms := systemDBRPMs(t)
+	installed, err := db.AlreadyInstalled(rpms)
+	if names := installed.Names(); err != nil || strings.Join(names, " ") != "bash.rpm tzdata.rpm six.rpm" {
+		t.Errorf("AlreadyInstalled should return the RPMs installed at the same version, got %v (%v)", names, err)
+	}
+
+	missing, err := db.MissingOnSystem(rpms)
+	if names := missing.Names(); err != nil || strings.Join(names, " ") != "Athena.rpm bash-i686.rpm" {
+		t.Errorf("MissingOnSystem should return the RPMs not installed, got %v (%v)", names, err)
+	}
+
+	skewed, err := db.Skewed(rpms)
+	if err != nil || len(skewed) != 1 || skewed[0].RPM.Name() != "libgcc.rpm" || skewed[0].Installed[0] != "11.4.1-2.el9" {
+		t.Errorf("Skewed should return the RPMs installed at another version, got %+v (%v)", skewed, err)
+	}
+
+	_, err = db.MissingOnSystem(&RPMs{{Path: filepath.Join(t.TempDir(), "missing.rpm")}})
+	if err == nil {
+		t.Errorf("MissingOnSystem should report RPMs whose header cannot be read")
+	}
+}
+
+func TestOpenSystemDBFailure(t *testing.T) {
+	fakeRPMCommand(t, `echo "cannot open Packages database" >&2; exit 1`)
+	if _, err := OpenSystemDB(context.Background(), ""); err == nil || !strings.Contains(err.Error(), "cannot open Packages database") {
+		t.Errorf("OpenSystemDB should fail with the rpm error output, got %v", err)
+	}
+
+	fakeRPMCommand(t, `echo "garbage"`)
+	if _, err := OpenSystemDB(context.Background(), ""); err == nil {
+		t.Errorf("OpenSystemDB should fail on unexpected output")
+	}
+}
@@ -0,0 +1,175 @@
+package rpm
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// VerifyReport lists the differences between an installed tree and the
+// files of the RPMs installed there. Paths are relative to the tree root.
+type VerifyReport struct {
+	// Missing are the files of the RPMs absent from the tree
+	Missing []string `json:"missing,omitempty"`
+
+	// Modified are the files present in the tree that differ from the RPMs
+	Modified []ModifiedFile `json:"modified,omitempty"`
+
+	// Extra are the files and symlinks of the tree that no RPM owns.
+	// Directories are not reported, as many are created unowned.
+	Extra []string `json:"extra,omitempty"`
+}
+
+// ModifiedFile is a file of an installed tree that differs from the RPM
+// owning it, in one or more of its type, size, permissions and digest
+type ModifiedFile struct {
+	Path string `json:"path"`
+	RPM  string `json:"rpm"`
+
+	// Differences lists what differs, among "type", "size", "mode" and "digest"
+	Differences []string `json:"differences"`
+}
+
+// OK indicates that the tree matches the RPMs
+func (v *VerifyReport) OK() bool {
+	return len(v.Missing) == 0 && len(v.Modified) == 0 && len(v.Extra) == 0
+}
+
+// Verify walks installDir, into which the RPMs are installed as by an
+// Installer with no options, and compares the presence, type, size,
+// permission bits and digest of each file with the RPM headers, see
+// Installer.Verify. %ghost files are not expected to be present.
+func Verify(installDir string, rpms *RPMs) (*VerifyReport, error) {
+	return (&Installer{}).Verify(installDir, rpms)
+}
+
+// Verify is like the Verify function, for RPMs installed by this Installer:
+// files are expected at their relocated paths if Relocate is set, and only
+// those matching Include, if set. The returned error reports a failure to
+// read the RPMs or the tree, not the differences found.
+func (in *Installer) Verify(installDir string, rpms *RPMs) (*VerifyReport, error) {
+	report := &VerifyReport{}
+	owned := map[string]struct{}{}
+	for _, r := range *rpms {
+		p, err := r.header()
+		if err != nil {
+			return nil, err
+		}
+
+		files, err := r.Files()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			if !in.includes(file.Path) {
+				continue
+			}
+
+			rel := in.relocate(p, file.Path)
+			owned[rel] = struct{}{}
+
+			differences, err := verifyFile(filepath.Join(installDir, filepath.FromSlash(rel)), file)
+			switch {
+			case os.IsNotExist(err):
+				report.Missing = append(report.Missing, rel)
+			case err != nil:
+				return nil, err
+			case len(differences) > 0:
+				report.Modified = append(report.Modified, ModifiedFile{Path: rel, RPM: r.Name(), Differences: differences})
+			}
+		}
+	}
+
+	err := filepath.WalkDir(installDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(installDir, path)
+		if err != nil {
+			return err
+		}
+
+		if _, keyExists := owned[filepath.ToSlash(rel)]; !keyExists {
+			report.Extra = append(report.Extra, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s (%w)", installDir, err)
+	}
+
+	sort.Strings(report.Missing)
+	sort.Strings(report.Extra)
+	sort.Slice(report.Modified, func(i, j int) bool {
+		return report.Modified[i].Path < report.Modified[j].Path
+	})
+
+	return report, nil
+}
+
+// verifyFile returns how the file at path differs from the packaged file
+func verifyFile(path string, file FileInfo) ([]string, error) {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if fi.Mode().Type() != file.Mode.Type() {
+		return []string{"type"}, nil
+	}
+
+	if !fi.Mode().IsRegular() {
+		return nil, nil
+	}
+
+	var differences []string
+	sized := fi.Size() == file.Size
+	if !sized {
+		differences = append(differences, "size")
+	}
+
+	if fi.Mode().Perm() != file.Mode.Perm() {
+		differences = append(differences, "mode")
+	}
+
+	// A file of another size cannot have the same digest
+	if file.Digest != "" && sized {
+		sum, err := fileDigest(path, file.DigestAlgo)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(sum, file.Digest) {
+			differences = append(differences, "digest")
+		}
+	}
+
+	return differences, nil
+}
+
+// fileDigest returns the hex encoded digest of the file at
+// path, computed with the given algorithm, see newHash
+func fileDigest(path, algo string) (string, error) {
+	h, err := newHash(algo)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to checksum %s (%w)", path, err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,108 @@
+package rpm
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cavaliergopher/cpio"
+	"github.com/cavaliergopher/rpm"
+)
+
+func md5Hex(data string) string {
+	sum := md5.Sum([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestVerify(t *testing.T) {
+	setup, athena := "export ATHENA=1\n", "#!/usr/bin/env python\n"
+	path := writeRPM(t, t.TempDir(), "Athena.rpm", fixtureRPM{
+		Name: "Athena", Version: "1", Release: "1",
+		Compression: "gzip",
+		Payload: cpioPayload(t, "gzip", []payloadEntry{
+			{Name: "./opt/atlas", Mode: cpio.TypeDir | 0755},
+			{Name: "./opt/atlas/setup.sh", Mode: cpio.TypeReg | 0644, Body: setup},
+			{Name: "./opt/atlas/athena.py", Mode: cpio.TypeReg | 0755, Body: athena},
+			{Name: "./opt/atlas/link.sh", Link: "setup.sh"},
+		}),
+		Files: []fixtureFile{
+			{Path: "/opt/atlas", Mode: 040755},
+			{Path: "/opt/atlas/setup.sh", Mode: 0100644, Size: len(setup), Digest: md5Hex(setup)},
+			{Path: "/opt/atlas/athena.py", Mode: 0100755, Size: len(athena), Digest: md5Hex(athena)},
+			{Path: "/opt/atlas/link.sh", Mode: 0120777},
+			{Path: "/opt/atlas/athena.log", Mode: 0100644, Flags: rpm.FileFlagGhost},
+		},
+	})
+	rpms := &RPMs{{Path: path}}
+
+	dir := t.TempDir()
+	if err := (&Installer{}).Install(context.Background(), rpms, dir); err != nil {
+		t.Fatalf("Install failed (%v)", err)
+	}
+
+	report, err := Verify(dir, rpms)
+	if err != nil || !report.OK() {
+		t.Fatalf("Verify should find the fresh install intact, got %+v (%v)", report, err)
+	}
+
+	// Truncate, alter, remove and add files
+	os.WriteFile(filepath.Join(dir, "opt/atlas/setup.sh"), []byte("export"), 0644)
+	os.WriteFile(filepath.Join(dir, "opt/atlas/athena.py"), []byte("#!/usr/bin/env pythoX\n"), 0755)
+	os.Chmod(filepath.Join(dir, "opt/atlas/athena.py"), 0700)
+	os.Remove(filepath.Join(dir, "opt/atlas/link.sh"))
+	os.WriteFile(filepath.Join(dir, "opt/atlas/stray.txt"), nil, 0644)
+
+	report, err = Verify(dir, rpms)
+	if err != nil {
+		t.Fatalf("Verify failed (%v)", err)
+	}
+
+	expect := &VerifyReport{
+		Missing: []string{"opt/atlas/link.sh"},
+		Modified: []ModifiedFile{
+			{Path: "opt/atlas/athena.py", RPM: "Athena.rpm", Differences: []string{"mode", "digest"}},
+			{Path: "opt/atlas/setup.sh", RPM: "Athena.rpm", Differences: []string{"size"}},
+		},
+		Extra: []string{"opt/atlas/stray.txt"},
+	}
+	if !reflect.DeepEqual(report, expect) {
+		t.Errorf("Verify should report\n%+v\ngot\n%+v", expect, report)
+	}
+}
+
+func TestInstallThenVerify(t *testing.T) {
+	entries := []payloadEntry{
+		{Name: "./opt/atlas", Mode: cpio.TypeDir | 0775},
+		{Name: "./opt/atlas/setup.sh", Mode: cpio.TypeReg | 0775, Body: "export ATHENA=1\n"},
+		{Name: "./opt/atlas/shared.txt", Mode: cpio.TypeReg | 0666, Body: "shared"},
+	}
+	path := writeRPM(t, t.TempDir(), "Athena.rpm", fixtureRPM{
+		Name: "Athena", Version: "1", Release: "1",
+		Compression: "gzip",
+		Payload:     cpioPayload(t, "gzip", entries),
+		Files: []fixtureFile{
+			{Path: "/opt/atlas", Mode: 040775},
+			{Path: "/opt/atlas/setup.sh", Mode: 0100775, Size: 16, Digest: md5Hex("export ATHENA=1\n")},
+			{Path: "/opt/atlas/shared.txt", Mode: 0100666, Size: 6, Digest: md5Hex("shared")},
+		},
+	})
+	rpms := &RPMs{{Path: path}}
+
+	dest := t.TempDir()
+	if err := (&Installer{}).Install(context.Background(), rpms, dest); err != nil {
+		t.Fatalf("Install failed (%v)", err)
+	}
+
+	report, err := Verify(dest, rpms)
+	if err != nil || !report.OK() {
+		t.Errorf("a fresh install should verify, got %+v (%v)", report, err)
+	}
+
+	if fi, err := os.Stat(filepath.Join(dest, "opt/atlas")); err != nil || fi.Mode().Perm() != 0775 {
+		t.Errorf("Install should create directories with the RPM mode, got %v (%v)", fi, err)
+	}
+}
@@ -0,0 +1,156 @@
+package rpm
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/cavaliergopher/rpm"
+	"golang.org/x/crypto/openpgp"
+)
+
+var (
+	// ErrUnsigned is returned when verifying the signature
+	// of a package that carries none
+	ErrUnsigned = errors.New("package is not signed")
+
+	// ErrPayloadDigestMismatch is returned when the payload of a package
+	// does not match the digest recorded in its header
+	ErrPayloadDigestMismatch = errors.New("payload digest mismatch")
+)
+
+// Signature header tags holding a header+payload signature,
+// as checked by rpm.GPGCheck
+var signatureTags = []int{
+	1002, // RPMSIGTAG_PGP
+	1005, // RPMSIGTAG_GPG
+	1006, // RPMSIGTAG_PGP5
+}
+
+// payloadDigestAlgos maps the PGPHASHALGO values of the payload
+// and file digest algorithm tags to hash names
+var payloadDigestAlgos = map[int64]string{
+	1:  "md5",
+	2:  "sha1",
+	8:  "sha256",
+	10: "sha512",
+}
+
+// Verify checks the GPG signature of the package against the keyring.
+// It returns an error wrapping ErrUnsigned if the package carries no
+// signature, or rpm.ErrGPGCheckFailed if no key of the keyring signed it.
+func (r *RPM) Verify(keyring openpgp.KeyRing) error {
+	p, err := r.header()
+	if err != nil {
+		return err
+	}
+
+	if !signed(p) {
+		return fmt.Errorf("%s: %w", r.Name(), ErrUnsigned)
+	}
+
+	f, err := r.open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := rpm.GPGCheck(bufio.NewReader(f), keyring); err != nil {
+		return fmt.Errorf("%s: signature verification failed (%w)", r.Name(), err)
+	}
+
+	return nil
+}
+
+// VerifySignature checks the GPG signature of the RPM file at path
+// against the keyring, see RPM.Verify
+func VerifySignature(path string, keyring openpgp.EntityList) error {
+	return (&RPM{Path: path}).Verify(keyring)
+}
+
+// signed indicates if the package carries a header+payload signature
+func signed(p *rpm.Package) bool {
+	for _, tag := range signatureTags {
+		if p.Signature.GetTag(tag) != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// VerifyPayloadDigest recomputes the digest of the package payload and
+// compares it to the one recorded in the header, returning an error
+// wrapping ErrPayloadDigestMismatch if they differ. Packages built without
+// a payload digest are checked against their legacy MD5 checksum instead.
+func (r *RPM) VerifyPayloadDigest() error {
+	f, err := r.open()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	p, err := rpm.Read(br)
+	if err != nil {
+		return fmt.Errorf("failed to read rpm header of %s (%w)", r.Path, err)
+	}
+
+	digests := p.Header.GetTag(5092).StringSlice() // RPMTAG_PAYLOADDIGEST
+	if len(digests) == 0 {
+		// Read the file again from the start, which not every fs.File can seek to
+		again, err := r.open()
+		if err != nil {
+			return err
+		}
+		defer again.Close()
+
+		if err := rpm.MD5Check(bufio.NewReader(again)); err != nil {
+			return fmt.Errorf("%s: %w (%v)", r.Name(), ErrPayloadDigestMismatch, err)
+		}
+		return nil
+	}
+
+	algo, ok := payloadDigestAlgos[p.Header.GetTag(5093).Int64()] // RPMTAG_PAYLOADDIGESTALGO
+	if !ok {
+		algo = "sha256"
+	}
+
+	h, err := newHash(algo)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(h, br); err != nil {
+		return fmt.Errorf("failed to read payload of %s (%w)", r.Path, err)
+	}
+
+	if got := hex.EncodeToString(h.Sum(nil)); got != digests[0] {
+		return fmt.Errorf("%s: %w (%s %s, header has %s)", r.Name(), ErrPayloadDigestMismatch, algo, got, digests[0])
+	}
+
+	return nil
+}
+
+// VerifyAll verifies the signature and payload digest of each of the
+// RPMs, rather than stopping at the first failure. It returns the names
+// of the RPMs that failed alongside the joined verification errors.
+func (r *RPMs) VerifyAll(keyring openpgp.KeyRing) ([]string, error) {
+	var failed []string
+	var errs []error
+	for _, rr := range *r {
+		err := rr.Verify(keyring)
+		if err == nil {
+			err = rr.VerifyPayloadDigest()
+		}
+
+		if err != nil {
+			failed = append(failed, rr.Name())
+			errs = append(errs, err)
+		}
+	}
+
+	return failed, errors.Join(errs...)
+}
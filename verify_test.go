@@ -0,0 +1,85 @@
+package rpm
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cavaliergopher/rpm"
+	"golang.org/x/crypto/openpgp"
+)
+
+func newTestEntity(t *testing.T, name string) *openpgp.Entity {
+	e, err := openpgp.NewEntity(name, "", name+"@example.com", nil)
+	if err != nil {
+		t.Fatalf("failed to create a test key (%v)", err)
+	}
+
+	return e
+}
+
+func TestRPMVerify(t *testing.T) {
+	dir := t.TempDir()
+	signer := newTestEntity(t, "signer")
+	stranger := newTestEntity(t, "stranger")
+
+	signed := &RPM{Path: writeRPM(t, dir, "signed.rpm", fixtureRPM{Name: "signed", Version: "1", Release: "1", Signer: signer})}
+	unsigned := &RPM{Path: writeRPM(t, dir, "unsigned.rpm", fixtureRPM{Name: "unsigned", Version: "1", Release: "1"})}
+
+	if err := signed.Verify(openpgp.EntityList{signer}); err != nil {
+		t.Errorf("Verify should accept a package signed by the keyring, got %v", err)
+	}
+
+	if err := signed.Verify(openpgp.EntityList{stranger}); !errors.Is(err, rpm.ErrGPGCheckFailed) {
+		t.Errorf("Verify should return ErrGPGCheckFailed for an unknown signer, got %v", err)
+	}
+
+	if err := unsigned.Verify(openpgp.EntityList{signer}); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("Verify should return ErrUnsigned for an unsigned package, got %v", err)
+	}
+}
+
+func TestRPMVerifyPayloadDigest(t *testing.T) {
+	dir := t.TempDir()
+	good := &RPM{Path: writeRPM(t, dir, "good.rpm", fixtureRPM{Name: "good", Version: "1", Release: "1"})}
+	bad := &RPM{Path: writeRPM(t, dir, "bad.rpm", fixtureRPM{Name: "bad", Version: "1", Release: "1", BadDigest: true})}
+
+	if err := good.VerifyPayloadDigest(); err != nil {
+		t.Errorf("VerifyPayloadDigest should accept an intact package, got %v", err)
+	}
+
+	if err := bad.VerifyPayloadDigest(); !errors.Is(err, ErrPayloadDigestMismatch) {
+		t.Errorf("VerifyPayloadDigest should return ErrPayloadDigestMismatch, got %v", err)
+	}
+}
+
+func TestRPMsVerifyAll(t *testing.T) {
+	dir := t.TempDir()
+	signer := newTestEntity(t, "signer")
+	rpms := &RPMs{
+		&RPM{Path: writeRPM(t, dir, "ok.rpm", fixtureRPM{Name: "ok", Version: "1", Release: "1", Signer: signer})},
+		&RPM{Path: writeRPM(t, dir, "unsigned.rpm", fixtureRPM{Name: "unsigned", Version: "1", Release: "1"})},
+		&RPM{Path: writeRPM(t, dir, "corrupt.rpm", fixtureRPM{Name: "corrupt", Version: "1", Release: "1", Signer: signer, BadDigest: true})},
+	}
+
+	failed, err := rpms.VerifyAll(openpgp.EntityList{signer})
+	if len(failed) != 2 || failed[0] != "unsigned.rpm" || failed[1] != "corrupt.rpm" {
+		t.Errorf("VerifyAll should report unsigned.rpm and corrupt.rpm, got %v", failed)
+	}
+
+	if !errors.Is(err, ErrUnsigned) || !errors.Is(err, ErrPayloadDigestMismatch) {
+		t.Errorf("VerifyAll should join all failures, got %v", err)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	signer := newTestEntity(t, "signer")
+	path := writeRPM(t, t.TempDir(), "signed.rpm", fixtureRPM{Name: "signed", Version: "1", Release: "1", Signer: signer})
+
+	if err := VerifySignature(path, openpgp.EntityList{signer}); err != nil {
+		t.Errorf("VerifySignature should succeed with the signing key, got %v", err)
+	}
+
+	if err := VerifySignature(path, openpgp.EntityList{newTestEntity(t, "stranger")}); err == nil {
+		t.Errorf("VerifySignature should fail with another key, got nil")
+	}
+}
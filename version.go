@@ -0,0 +1,34 @@
+package rpm
+
+import (
+	"sort"
+
+	"github.com/cavaliergopher/rpm"
+)
+
+// sortByVersionDesc returns the RPM paths sorted by decreasing version,
+// comparing epoch, version and release with the rpm rules. RPMs whose
+// header cannot be read come last. Equal versions keep their order.
+func sortByVersionDesc(fsys fileSystem, paths []string) []string {
+	if len(paths) < 2 {
+		return paths
+	}
+
+	pkgs := make(map[string]*rpm.Package, len(paths))
+	for _, path := range paths {
+		if p, err := (&RPM{Path: path, fsys: ownFS(fsys)}).header(); err == nil {
+			pkgs[path] = p
+		}
+	}
+
+	sorted := append([]string(nil), paths...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := pkgs[sorted[i]], pkgs[sorted[j]]
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return rpm.Compare(a, b) > 0
+	})
+
+	return sorted
+}
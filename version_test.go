@@ -0,0 +1,71 @@
+package rpm
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFinderTopRPMLatest(t *testing.T) {
+	dir := t.TempDir()
+	for _, v := range []string{"22.0.1", "22.0.11", "22.0.2"} {
+		writeRPM(t, dir, "Athena_"+v+"_x86_64.rpm", fixtureRPM{Name: "Athena", Version: v, Release: "1"})
+	}
+
+	got, err := NewFinder(dir).TopRPM("Athena", "x86_64")
+	if err != nil {
+		t.Fatalf("TopRPM failed (%v)", err)
+	}
+
+	if filepath.Base(got) != "Athena_22.0.11_x86_64.rpm" {
+		t.Errorf("TopRPM should select 22.0.11, got %s", got)
+	}
+
+	// A higher epoch wins over any version
+	writeRPM(t, dir, "Athena_21.0.0_x86_64.rpm", fixtureRPM{Name: "Athena", Version: "21.0.0", Release: "1", Epoch: 1})
+	got, _ = NewFinder(dir).TopRPM("Athena", "x86_64")
+	if filepath.Base(got) != "Athena_21.0.0_x86_64.rpm" {
+		t.Errorf("TopRPM should select the epoch 1 build, got %s", got)
+	}
+}
+
+func TestSortByVersionDescTie(t *testing.T) {
+	dir := t.TempDir()
+	a := writeRPM(t, dir, "a.rpm", fixtureRPM{Name: "p", Version: "1", Release: "1"})
+	b := writeRPM(t, dir, "b.rpm", fixtureRPM{Name: "p", Version: "1", Release: "1"})
+
+	if got := sortByVersionDesc(osFileSystem{}, []string{"/blip/blop.rpm", a, b}); got[0] != a || got[2] != "/blip/blop.rpm" {
+		t.Errorf("sortByVersionDesc should keep the first of equal versions first, got %v", got)
+	}
+
+	if got := sortByVersionDesc(osFileSystem{}, []string{"/blip/blop.rpm", "/blip/blop2.rpm"}); got[0] != "/blip/blop.rpm" {
+		t.Errorf("sortByVersionDesc should keep the order of unreadable RPMs, got %v", got)
+	}
+}
+
+func TestFinderFindAll(t *testing.T) {
+	dir := t.TempDir()
+	for _, v := range []string{"22.0.1", "22.0.11", "22.0.2"} {
+		writeRPM(t, dir, "Athena_"+v+"_x86_64.rpm", fixtureRPM{Name: "Athena", Version: v, Release: "1"})
+	}
+
+	tops, err := NewFinder(dir).FindAll("Athena", "x86_64")
+	if err != nil {
+		t.Fatalf("FindAll failed (%v)", err)
+	}
+
+	expect := []string{"Athena_22.0.11_x86_64.rpm", "Athena_22.0.2_x86_64.rpm", "Athena_22.0.1_x86_64.rpm"}
+	got := tops.Names()
+	if len(got) != 3 || got[0] != expect[0] || got[1] != expect[1] || got[2] != expect[2] {
+		t.Errorf("FindAll should return %v, got %v", expect, got)
+	}
+
+	if tops[0].Size == 0 {
+		t.Errorf("FindAll should populate RPM sizes")
+	}
+
+	_, err = NewFinder(dir).FindAll("Athena", "aarch64")
+	if err == nil || !strings.Contains(err.Error(), "Athena_*_aarch64.rpm") {
+		t.Errorf("FindAll should report the glob that found nothing, got %v", err)
+	}
+}
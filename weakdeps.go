@@ -0,0 +1,87 @@
+package rpm
+
+import (
+	"fmt"
+
+	"github.com/cavaliergopher/rpm"
+)
+
+// WeakDeps tells a Finder which weak dependencies of the RPMs it follows
+// on top of their Requires
+type WeakDeps int
+
+const (
+	// NoWeakDeps ignores weak dependencies, as rpm itself does. This is the default.
+	NoWeakDeps WeakDeps = iota
+
+	// WithRecommendsDeps follows the Recommends, as dnf does by default
+	WithRecommendsDeps
+
+	// WithSuggestsDeps follows both the Recommends and the Suggests
+	WithSuggestsDeps
+)
+
+// WithWeakDeps makes the Finder also look up the given weak dependencies of
+// the RPMs. Unlike Requires, weak dependencies that no RPM of the directory
+// (or repository) matches are not reported missing, being optional.
+func WithWeakDeps(weak WeakDeps) FinderOption {
+	return func(f *Finder) {
+		f.weak = weak
+	}
+}
+
+// Recommends returns the Recommends of the RPM, as printed by
+// rpm -q --recommends, e.g. "python3-numpy >= 1.20"
+func (r *RPM) Recommends() ([]string, error) {
+	p, err := r.header()
+	if err != nil {
+		return nil, err
+	}
+
+	return depStrings(p.Recommends()), nil
+}
+
+// Suggests returns the Suggests of the RPM, as printed by rpm -q --suggests
+func (r *RPM) Suggests() ([]string, error) {
+	p, err := r.header()
+	if err != nil {
+		return nil, err
+	}
+
+	return depStrings(p.Suggests()), nil
+}
+
+// depStrings formats each of the dependencies
+func depStrings(deps []rpm.Dependency) []string {
+	var s []string
+	for _, dep := range deps {
+		s = append(s, fmt.Sprint(dep))
+	}
+
+	return s
+}
+
+// weakDeps returns the weak dependencies of p followed with the given setting
+func weakDeps(p *rpm.Package, weak WeakDeps) []rpm.Dependency {
+	var deps []rpm.Dependency
+	if weak >= WithRecommendsDeps {
+		deps = append(deps, p.Recommends()...)
+	}
+	if weak >= WithSuggestsDeps {
+		deps = append(deps, p.Suggests()...)
+	}
+
+	return deps
+}
+
+// weakByName is like requiresByName, for the weak dependencies of r
+// followed with the given setting
+func weakByName(r *RPM, weak WeakDeps) ([]string, map[string][]rpm.Dependency, error) {
+	p, err := r.header()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names, deps := byName(weakDeps(p, weak))
+	return names, deps, nil
+}
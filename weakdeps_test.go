@@ -0,0 +1,79 @@
+package rpm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// createWeakDepsDir writes a top RPM recommending and suggesting packages,
+// one of each being absent
+func createWeakDepsDir(t *testing.T) string {
+	dir := t.TempDir()
+	writeRPM(t, dir, "Athena_22.0.1_x86_64.rpm", fixtureRPM{
+		Name: "Athena", Version: "22.0.1", Release: "1",
+		Requires:   []fixtureDep{{Name: "Gaudi"}},
+		Recommends: []fixtureDep{{Name: "AthenaDocs", Flags: 12, Version: "22.0"}, {Name: "absent-recommended"}},
+		Suggests:   []fixtureDep{{Name: "AthenaExamples"}, {Name: "absent-suggested"}},
+	})
+	writeRPM(t, dir, "Gaudi-1.0.rpm", fixtureRPM{Name: "Gaudi", Version: "1.0", Release: "1"})
+	writeRPM(t, dir, "AthenaDocs-22.0.1.rpm", fixtureRPM{Name: "AthenaDocs", Version: "22.0.1", Release: "1"})
+	writeRPM(t, dir, "AthenaExamples-22.0.1.rpm", fixtureRPM{Name: "AthenaExamples", Version: "22.0.1", Release: "1"})
+
+	return dir
+}
+
+func TestRPMWeakDeps(t *testing.T) {
+	r := &RPM{Path: filepath.Join(createWeakDepsDir(t), "Athena_22.0.1_x86_64.rpm")}
+
+	recommends, err := r.Recommends()
+	if err != nil || strings.Join(recommends, ",") != "AthenaDocs >= 22.0,absent-recommended" {
+		t.Errorf("Recommends should return the recommended packages, got %q (%v)", recommends, err)
+	}
+
+	suggests, err := r.Suggests()
+	if err != nil || strings.Join(suggests, ",") != "AthenaExamples,absent-suggested" {
+		t.Errorf("Suggests should return the suggested packages, got %q (%v)", suggests, err)
+	}
+}
+
+func TestFinderWithWeakDeps(t *testing.T) {
+	dir := createWeakDepsDir(t)
+	for _, tc := range []struct {
+		weak WeakDeps
+		want string
+	}{
+		{NoWeakDeps, "Athena_22.0.1_x86_64.rpm,Gaudi-1.0.rpm"},
+		{WithRecommendsDeps, "Athena_22.0.1_x86_64.rpm,Gaudi-1.0.rpm,AthenaDocs-22.0.1.rpm"},
+		{WithSuggestsDeps, "Athena_22.0.1_x86_64.rpm,Gaudi-1.0.rpm,AthenaDocs-22.0.1.rpm,AthenaExamples-22.0.1.rpm"},
+	} {
+		rpms, err := NewFinder(dir, WithWeakDeps(tc.weak), WithStrict()).Find("Athena", "x86_64")
+		if err != nil {
+			t.Fatalf("Find with weak deps %d failed, absent weak deps should not be missing (%v)", tc.weak, err)
+		}
+
+		if got := strings.Join(rpms.Names(), ","); got != tc.want {
+			t.Errorf("Find with weak deps %d should return %s, got %s", tc.weak, tc.want, got)
+		}
+	}
+}
+
+func TestRemoteFinderWithWeakDeps(t *testing.T) {
+	dir := createWeakDepsDir(t)
+	if err := CreateRepo(dir); err != nil {
+		t.Fatalf("CreateRepo failed (%v)", err)
+	}
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	t.Cleanup(srv.Close)
+
+	rpms, err := NewRemoteFinder(Repo{URL: srv.URL}, t.TempDir(), WithWeakDeps(WithRecommendsDeps)).Find("Athena", "x86_64")
+	if err != nil {
+		t.Fatalf("Find failed (%v)", err)
+	}
+
+	if got := strings.Join(rpms.Names(), ","); got != "Athena_22.0.1_x86_64.rpm,Gaudi-1.0.rpm,AthenaDocs-22.0.1.rpm" {
+		t.Errorf("Find should download and return the recommended packages, got %s", got)
+	}
+}